@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/yourname/multiagent-social/internal/api"
+	"github.com/yourname/multiagent-social/internal/migrate"
 	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/migrations"
 )
 
 func main() {
@@ -25,7 +28,7 @@ func main() {
 
 	if len(os.Args) < 2 {
 		fmt.Println("usage: cli <command> [args]")
-		fmt.Println("commands: create-agent <name>, gen-token <subject> <days>")
+		fmt.Println("commands: create-agent <name>, gen-token <subject> <days>, migrate up|down [steps]|status")
 		return
 	}
 	switch os.Args[1] {
@@ -63,8 +66,64 @@ func main() {
 			log.Fatalf("failed to generate token: %v", err)
 		}
 		fmt.Println(tok)
+	case "migrate":
+		if len(os.Args) < 3 {
+			fmt.Println("usage: cli migrate up|down [steps]|status")
+			return
+		}
+		if err := runMigrate(ctx, store, os.Args[2], os.Args[3:]); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
 	default:
 		fmt.Println("unknown command")
 	}
 }
 
+// runMigrate applies, reverts or lists the embedded schema migrations.
+func runMigrate(ctx context.Context, store *persistence.PostgresStore, cmd string, args []string) error {
+	m, err := migrate.New(store.Pool(), migrations.FS)
+	if err != nil {
+		return err
+	}
+	switch cmd {
+	case "up":
+		ran, err := m.Up(ctx)
+		for _, mig := range ran {
+			fmt.Printf("applied %03d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(ran) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps %q", args[0])
+			}
+		}
+		ran, err := m.Down(ctx, steps)
+		for _, mig := range ran {
+			fmt.Printf("reverted %03d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range status {
+			state := "pending"
+			if st.AppliedAt != nil {
+				state = "applied " + st.AppliedAt.Format(time.RFC3339)
+			}
+			if st.Modified {
+				state += " (modified since applied)"
+			}
+			fmt.Printf("%03d_%s\t%s\n", st.Version, st.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", cmd)
+	}
+}
@@ -1,150 +1,40 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/orchestrator"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+	"github.com/yourname/multiagent-social/internal/ws"
 )
 
-type InMemoryStore struct {
-	mu            sync.RWMutex
-	agents        map[string]agent.Agent
-	conversations map[string]string   // id -> title
-	messages      map[string][]string // convID -> messages
-	counter       int64
-}
-
-func NewInMemoryStore() *InMemoryStore {
-	return &InMemoryStore{
-		agents:        make(map[string]agent.Agent),
-		conversations: make(map[string]string),
-		messages:      make(map[string][]string),
-		counter:       time.Now().UnixNano(),
-	}
-}
-
-func (s *InMemoryStore) nextID(prefix string) string {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.counter++
-	return fmt.Sprintf("%s-%d", prefix, s.counter)
-}
-
-func (s *InMemoryStore) ListAgents() []agent.Agent {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	out := make([]agent.Agent, 0, len(s.agents))
-	for _, a := range s.agents {
-		out = append(out, a)
-	}
-	return out
-}
-
-func (s *InMemoryStore) CreateAgent(name, persona string) string {
-	id := s.nextID("agent")
-	a := agent.Agent{
-		ID:      agent.AgentID(id),
-		Name:    name,
-		Persona: persona,
-	}
-	s.mu.Lock()
-	s.agents[id] = a
-	s.mu.Unlock()
-	return id
-}
-
-func (s *InMemoryStore) GetAgent(id string) (agent.Agent, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	a, ok := s.agents[id]
-	return a, ok
-}
-
-func (s *InMemoryStore) UpdateAgent(id, name, persona string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	a, ok := s.agents[id]
-	if !ok {
-		return false
-	}
-	a.Name = name
-	a.Persona = persona
-	s.agents[id] = a
-	return true
-}
-
-func (s *InMemoryStore) DeleteAgent(id string) bool {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	_, ok := s.agents[id]
-	if !ok {
-		return false
-	}
-	delete(s.agents, id)
-	return true
-}
-
-func (s *InMemoryStore) CreateConversation(title string) string {
-	id := s.nextID("conv")
-	s.mu.Lock()
-	s.conversations[id] = title
-	s.messages[id] = []string{}
-	s.mu.Unlock()
-	return id
-}
-
-func (s *InMemoryStore) InsertMessage(convID, sender, content string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.messages[convID] = append(s.messages[convID], fmt.Sprintf("%s: %s", sender, content))
-}
-
-func (s *InMemoryStore) GetConversationMessages(convID string) []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	cpy := make([]string, len(s.messages[convID]))
-	copy(cpy, s.messages[convID])
-	return cpy
-}
-
-// SSE subscribers for devserver (conversation events)
-var (
-	subsMu      sync.Mutex
-	subscribers = map[string][]chan string{}
-)
-
-func subscribeEvents(convID string) <-chan string {
-	ch := make(chan string, 8)
-	subsMu.Lock()
-	subscribers[convID] = append(subscribers[convID], ch)
-	subsMu.Unlock()
-	return ch
-}
-
-func publishEvent(convID string, msg string) {
-	subsMu.Lock()
-	defer subsMu.Unlock()
-	for _, ch := range subscribers[convID] {
-		select {
-		case ch <- msg:
-		default:
-		}
-	}
-}
-
 func main() {
-	store := NewInMemoryStore()
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	// the devserver runs the real orchestrator on in-memory storage and pubsub
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	embedder, err := embeddings.NewEmbedderFromEnv()
+	if err != nil {
+		log.Fatalf("embeddings: %v", err)
+	}
+	orch := orchestrator.NewOrchestrator(store, broker, embedder)
 	// seed one agent
-	store.CreateAgent("Alice", "music, literature")
-	store.CreateAgent("Bob", "fitness, philosophy")
+	_, _ = store.CreateAgent(ctx, "Alice", "music, literature", nil)
+	_, _ = store.CreateAgent(ctx, "Bob", "fitness, philosophy", nil)
 
 	mux := http.NewServeMux()
 
@@ -155,7 +45,7 @@ func main() {
 
 	mux.HandleFunc("/api/v1/agents", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
-			agents := store.ListAgents()
+			agents, _ := store.ListAgents(r.Context())
 			// normalize to lowercase keys for the simple admin UI
 			out := make([]map[string]interface{}, 0, len(agents))
 			for _, a := range agents {
@@ -180,7 +70,11 @@ func main() {
 				http.Error(w, "invalid body", http.StatusBadRequest)
 				return
 			}
-			id := store.CreateAgent(p.Name, p.Persona)
+			id, err := store.CreateAgent(r.Context(), p.Name, p.Persona, nil)
+			if err != nil {
+				http.Error(w, "failed to create agent", http.StatusInternalServerError)
+				return
+			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusCreated)
 			_ = json.NewEncoder(w).Encode(map[string]string{"id": id})
@@ -197,8 +91,8 @@ func main() {
 		}
 		switch r.Method {
 		case http.MethodGet:
-			a, ok := store.GetAgent(id)
-			if !ok {
+			a, err := store.GetAgent(r.Context(), id)
+			if err != nil {
 				http.Error(w, "not found", http.StatusNotFound)
 				return
 			}
@@ -206,23 +100,24 @@ func main() {
 			w.Header().Set("Content-Type", "application/json")
 			_ = json.NewEncoder(w).Encode(out)
 			return
-		case http.MethodPut:
+		case http.MethodPut, http.MethodPatch:
 			var p struct {
-				Name    string `json:"name"`
-				Persona string `json:"persona"`
+				Name            *string                `json:"name"`
+				Persona         *string                `json:"persona"`
+				BehaviorProfile map[string]interface{} `json:"behavior_profile"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
 				http.Error(w, "invalid body", http.StatusBadRequest)
 				return
 			}
-			if ok := store.UpdateAgent(id, p.Name, p.Persona); !ok {
+			if _, err := store.UpdateAgent(r.Context(), id, persistence.AgentPatch{Name: p.Name, Persona: p.Persona, BehaviorProfile: p.BehaviorProfile}); err != nil {
 				http.Error(w, "not found", http.StatusNotFound)
 				return
 			}
 			w.WriteHeader(http.StatusNoContent)
 			return
 		case http.MethodDelete:
-			if ok := store.DeleteAgent(id); !ok {
+			if err := store.DeleteAgent(r.Context(), id); err != nil {
 				http.Error(w, "not found", http.StatusNotFound)
 				return
 			}
@@ -236,19 +131,18 @@ func main() {
 
 	mux.HandleFunc("/api/v1/conversations", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			id := store.CreateConversation("Conversation (dev)")
+			id, err := orch.CreateConversation(r.Context(), "Conversation (dev)", nil, nil)
+			if err != nil {
+				http.Error(w, "failed to create conversation", http.StatusInternalServerError)
+				return
+			}
 			w.WriteHeader(http.StatusCreated)
 			_, _ = w.Write([]byte(id))
 			return
 		}
 		if r.Method == http.MethodGet {
 			// list convs
-			store.mu.RLock()
-			out := make([]map[string]string, 0, len(store.conversations))
-			for id, title := range store.conversations {
-				out = append(out, map[string]string{"id": id, "title": title})
-			}
-			store.mu.RUnlock()
+			out, _ := store.ListConversations(r.Context())
 			w.Header().Set("Content-Type", "application/json")
 			_ = json.NewEncoder(w).Encode(out)
 			return
@@ -277,34 +171,15 @@ func main() {
 				http.Error(w, "empty body", http.StatusBadRequest)
 				return
 			}
-			store.InsertMessage(convID, "user", content)
-			// publish SSE event for subscribers
-			publishEvent(convID, fmt.Sprintf(`{"sender":"user","content":%q}`, content))
-			// simple dev orchestrator: pick up to 2 agents and reply
-			agents := store.ListAgents()
-			limit := 2
-			if len(agents) < limit {
-				limit = len(agents)
-			}
-			for i := 0; i < limit; i++ {
-				a := agents[i]
-				dec := &agent.SimpleDecider{}
-				act, err := dec.DecideAction(r.Context(), &a, &agent.ConversationState{
-					ConversationID: convID,
-					Messages:       store.GetConversationMessages(convID),
-				})
-				if err != nil || act == nil {
-					continue
-				}
-				store.InsertMessage(convID, a.Name, act.Payload)
-				// publish SSE event for agent reply
-				publishEvent(convID, fmt.Sprintf(`{"sender":%q,"content":%q}`, a.Name, act.Payload))
+			if _, err := orch.HandleUserMessage(r.Context(), convID, "dev-user", content); err != nil {
+				http.Error(w, "conversation not found", http.StatusNotFound)
+				return
 			}
 			w.WriteHeader(http.StatusAccepted)
 			return
 		}
 		if r.Method == http.MethodGet {
-			msgs := store.GetConversationMessages(convID)
+			msgs, _ := store.GetConversationMessages(r.Context(), convID)
 			w.Header().Set("Content-Type", "application/json")
 			_ = json.NewEncoder(w).Encode(msgs)
 			return
@@ -328,30 +203,56 @@ func main() {
 			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
 			return
 		}
+		since, err := ws.ResumePoint(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sub, err := broker.Subscribe(r.Context(), events.Channel(id))
+		if err != nil {
+			http.Error(w, "subscribe failed", http.StatusInternalServerError)
+			return
+		}
+		defer sub.Close()
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
 		w.WriteHeader(http.StatusOK)
-		ch := subscribeEvents(id)
-		notify := r.Context().Done()
-		for {
-			select {
-			case <-notify:
-				return
-			case m := <-ch:
-				_, _ = fmt.Fprintf(w, "data: %s\n\n", m)
-				flusher.Flush()
+		// the sequence number is the SSE id, so EventSource resumes via Last-Event-ID on reconnect
+		_ = ws.ForwardEvents(r.Context(), store, sub, id, since, func(evt *events.Event) error {
+			b, err := json.Marshal(evt)
+			if err != nil {
+				return err
 			}
-		}
+			if evt.Seq > 0 {
+				_, _ = fmt.Fprintf(w, "id: %d\n", evt.Seq)
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		})
 	})
 
 	// wrap with simple CORS middleware
 	handler := allowCORS(mux)
 
-	addr := ":8080"
-	log.Printf("dev server starting on %s (DEV_MODE)", addr)
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		log.Fatalf("server: %v", err)
+	srv := &http.Server{Addr: ":8080", Handler: handler}
+	go func() {
+		log.Printf("dev server starting on %s (DEV_MODE)", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	_ = srv.Shutdown(shutdownCtx)
+	if err := orch.Close(shutdownCtx); err != nil {
+		log.Printf("embeddings and memories not drained: %v", err)
 	}
+	fmt.Println("dev server stopped")
 }
 
 // allowCORS sets permissive CORS headers for development.
@@ -359,7 +260,7 @@ func allowCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
 			return
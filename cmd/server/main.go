@@ -3,18 +3,26 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"time"
 
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/memory"
+	"github.com/yourname/multiagent-social/internal/migrate"
 	"github.com/yourname/multiagent-social/internal/orchestrator"
 	"github.com/yourname/multiagent-social/internal/persistence"
 	"github.com/yourname/multiagent-social/internal/pubsub"
 	api "github.com/yourname/multiagent-social/internal/api"
 	"github.com/yourname/multiagent-social/internal/ws"
+	"github.com/yourname/multiagent-social/migrations"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"strings"
 )
@@ -38,18 +46,59 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to connect to db: %v", err)
 	}
+	// AUTO_MIGRATE=true applies pending migrations before serving; replicas take turns on a lock
+	if v, _ := strconv.ParseBool(os.Getenv("AUTO_MIGRATE")); v {
+		m, err := migrate.New(store.Pool(), migrations.FS)
+		if err != nil {
+			log.Fatalf("failed to load migrations: %v", err)
+		}
+		ran, err := m.Up(ctx)
+		if err != nil {
+			log.Fatalf("failed to migrate: %v", err)
+		}
+		log.Printf("applied %d migrations", len(ran))
+	}
 
-	// start pubsub (redis)
-	redisAddr := os.Getenv("REDIS_ADDR")
-	if redisAddr == "" {
-		redisAddr = "localhost:6379"
+	// similarity search: pgvector with an ANN index when installed, brute force otherwise
+	vectorCfg, err := persistence.VectorConfigFromEnv()
+	if err != nil {
+		log.Fatalf("failed to configure vector search: %v", err)
 	}
-	ps, err := pubsub.NewRedisPubSub(redisAddr)
+	if err := store.ConfigureVectors(ctx, vectorCfg); err != nil {
+		log.Printf("vector search setup: %v", err)
+	}
+	log.Printf("similarity search: %s", store.VectorSearch())
+
+	// start pubsub: redis by default, PUBSUB_BACKEND=memory for a single node without redis
+	var ps pubsub.Broker
+	if os.Getenv("PUBSUB_BACKEND") == "memory" {
+		ps = pubsub.NewMemoryBroker()
+	} else {
+		redisAddr := os.Getenv("REDIS_ADDR")
+		if redisAddr == "" {
+			redisAddr = "localhost:6379"
+		}
+		rps, err := pubsub.NewRedisPubSub(redisAddr)
+		if err != nil {
+			log.Fatalf("failed to start redis pubsub: %v", err)
+		}
+		ps = rps
+	}
+	defer ps.Close()
+
+	// embeddings: OpenAI when configured, otherwise the offline hash embedder
+	embedder, err := embeddings.NewEmbedderFromEnv()
 	if err != nil {
-		log.Fatalf("failed to start redis pubsub: %v", err)
+		log.Fatalf("failed to configure embeddings: %v", err)
 	}
 
-	orch := orchestrator.NewOrchestrator(store, ps)
+	orch := orchestrator.NewOrchestrator(store, ps, embedder)
+	// agent memories: heuristic extraction unless MEMORY_EXTRACTOR=llm
+	extractor, err := memory.NewExtractorFromEnv()
+	if err != nil {
+		log.Fatalf("failed to configure memory extractor: %v", err)
+	}
+	orch.SetMemoryExtractor(extractor)
 
 	mux := http.NewServeMux()
 	// health
@@ -60,7 +109,7 @@ func main() {
 	// metrics
 	mux.Handle("/metrics", promhttp.Handler())
 
-	apiHandler := orchestrationAPI{store: store, orchestrator: orch}
+	apiHandler := orchestrationAPI{store: store, orchestrator: orch, embedder: embedder}
 	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", apiHandler.Router()))
 
 	// websocket simple path (we use path prefix and let ws handler parse id)
@@ -88,13 +137,17 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 	_ = srv.Shutdown(shutdownCtx)
+	if err := orch.Close(shutdownCtx); err != nil {
+		log.Printf("embeddings and memories not drained: %v", err)
+	}
 	fmt.Println("server stopped")
 }
 
 // orchestrationAPI wires a minimal set of handlers for MVP.
 type orchestrationAPI struct {
-	store        *persistence.PostgresStore
+	store        persistence.Store
 	orchestrator *orchestrator.Orchestrator
+	embedder     embeddings.Embedder
 }
 
 func (a orchestrationAPI) Router() http.Handler {
@@ -112,6 +165,143 @@ func (a orchestrationAPI) Router() http.Handler {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	})
 
+	// single agent and nested agent routes: /agents/{id}, /agents/{id}/memories
+	mux.HandleFunc("/agents/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/agents/"), "/"), "/")
+		if len(parts) == 1 && parts[0] != "" {
+			r = r.WithContext(context.WithValue(r.Context(), "agentID", parts[0]))
+			switch r.Method {
+			case http.MethodGet:
+				a.getAgent(w, r)
+			case http.MethodPut, http.MethodPatch:
+				api.RequireAdmin(http.HandlerFunc(a.updateAgent)).ServeHTTP(w, r)
+			case http.MethodDelete:
+				api.RequireAdmin(http.HandlerFunc(a.deleteAgent)).ServeHTTP(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if len(parts) == 2 && parts[0] != "" && parts[1] == "memories" {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), "agentID", parts[0]))
+			api.RequireAdmin(http.HandlerFunc(a.agentMemories)).ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+
+	// debate sessions: /debates/{id}, /debates/{id}/result, /debates/{id}/pause|resume|cancel
+	mux.HandleFunc("/debates/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/debates/"), "/"), "/")
+		if parts[0] == "" || len(parts) > 2 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "debateID", parts[0]))
+		if len(parts) == 1 {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			a.debate(w, r)
+			return
+		}
+		switch parts[1] {
+		case "result":
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			a.debateResult(w, r)
+		case "pause", "resume", "cancel":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), "debateAction", parts[1]))
+			// debates and autonomous runs are steered by signed-in users, over REST as over the socket
+			api.RequireUser(http.HandlerFunc(a.controlDebate)).ServeHTTP(w, r)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+
+	// polls: /polls/{id}, /polls/{id}/votes, /polls/{id}/close
+	mux.HandleFunc("/polls/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/polls/"), "/"), "/")
+		if parts[0] == "" || len(parts) > 2 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "pollID", parts[0]))
+		if len(parts) == 1 {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			a.poll(w, r)
+			return
+		}
+		switch parts[1] {
+		case "votes":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			// every vote counts for the caller's own token, so voters cannot stuff the poll
+			api.RequireUser(http.HandlerFunc(a.vote)).ServeHTTP(w, r)
+		case "close":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			// only the poll's creator, or an admin, may end it early
+			api.RequireUser(http.HandlerFunc(a.closePoll)).ServeHTTP(w, r)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+
+	// autonomous runs: /autonomous/{id}, /autonomous/{id}/pause|resume|cancel
+	mux.HandleFunc("/autonomous/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/autonomous/"), "/"), "/")
+		if parts[0] == "" || len(parts) > 2 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "runID", parts[0]))
+		if len(parts) == 1 {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			a.autonomousRun(w, r)
+			return
+		}
+		switch parts[1] {
+		case "pause", "resume", "cancel":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), "runAction", parts[1]))
+			api.RequireUser(http.HandlerFunc(a.controlAutonomous)).ServeHTTP(w, r)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			a.search(w, r)
+			return
+		}
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	})
 	mux.HandleFunc("/conversations", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			a.listConversations(w, r)
@@ -134,20 +324,79 @@ func (a orchestrationAPI) Router() http.Handler {
 			return
 		}
 		id := parts[0]
+		if len(parts) == 1 {
+			r = r.WithContext(context.WithValue(r.Context(), "convID", id))
+			switch r.Method {
+			case http.MethodGet:
+				a.getConversation(w, r)
+			case http.MethodPatch:
+				// closing and archiving are final, so only admins change a conversation
+				api.RequireAdmin(http.HandlerFunc(a.updateConversation)).ServeHTTP(w, r)
+			case http.MethodDelete:
+				api.RequireAdmin(http.HandlerFunc(a.deleteConversation)).ServeHTTP(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
 		if len(parts) >= 2 && parts[1] == "messages" {
-			if r.Method == http.MethodPost {
-				// attach id to URL for handler compatibility
-				r = r.WithContext(context.WithValue(r.Context(), "convID", id))
+			// attach id to URL for handler compatibility
+			r = r.WithContext(context.WithValue(r.Context(), "convID", id))
+			switch r.Method {
+			case http.MethodGet:
+				a.listMessages(w, r)
+			case http.MethodPost:
 				a.postMessage(w, r)
-				return
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if len(parts) >= 2 && parts[1] == "participants" {
+			r = r.WithContext(context.WithValue(r.Context(), "convID", id))
+			switch r.Method {
+			case http.MethodGet:
+				a.listParticipants(w, r)
+			case http.MethodPost:
+				a.addParticipants(w, r)
+			case http.MethodDelete:
+				// agent id either as trailing path segment or in the JSON body
+				if len(parts) >= 3 {
+					r = r.WithContext(context.WithValue(r.Context(), "agentID", parts[2]))
+				}
+				a.removeParticipants(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			}
+			return
+		}
+		if len(parts) == 2 && parts[1] == "polls" {
+			r = r.WithContext(context.WithValue(r.Context(), "convID", id))
+			switch r.Method {
+			case http.MethodGet:
+				a.listPolls(w, r)
+			case http.MethodPost:
+				api.RequireUser(http.HandlerFunc(a.createPoll)).ServeHTTP(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
 		}
 		if len(parts) >= 2 && parts[1] == "debate" {
 			if r.Method == http.MethodPost {
 				r = r.WithContext(context.WithValue(r.Context(), "convID", id))
-				a.startDebate(w, r)
+				api.RequireUser(http.HandlerFunc(a.startDebate)).ServeHTTP(w, r)
+				return
+			}
+		}
+		if len(parts) == 2 && parts[1] == "autonomous" {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 				return
 			}
+			r = r.WithContext(context.WithValue(r.Context(), "convID", id))
+			api.RequireUser(http.HandlerFunc(a.startAutonomous)).ServeHTTP(w, r)
+			return
 		}
 		http.Error(w, "not found", http.StatusNotFound)
 	})
@@ -187,9 +436,103 @@ func (a orchestrationAPI) createAgent(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string]string{"id": id})
 }
 
+// agentJSON is the single-agent representation used by the admin agent editor.
+func agentJSON(ag *agent.Agent) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               ag.ID,
+		"name":             ag.Name,
+		"persona":          ag.Persona,
+		"behavior_profile": ag.BehaviorProfile,
+		"created_at":       ag.CreatedAt,
+	}
+}
+
+func (a orchestrationAPI) getAgent(w http.ResponseWriter, r *http.Request) {
+	agentID, _ := r.Context().Value("agentID").(string)
+	ag, err := a.store.GetAgent(r.Context(), agentID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "agent not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to get agent", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(agentJSON(ag))
+}
+
+// updateAgent changes the fields present in the body; behavior_profile is
+// merged into the current profile, and keys set to null are removed.
+func (a orchestrationAPI) updateAgent(w http.ResponseWriter, r *http.Request) {
+	agentID, _ := r.Context().Value("agentID").(string)
+	var payload struct {
+		Name            *string                `json:"name"`
+		Persona         *string                `json:"persona"`
+		BehaviorProfile map[string]interface{} `json:"behavior_profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if payload.Name != nil && strings.TrimSpace(*payload.Name) == "" {
+		http.Error(w, "name must not be empty", http.StatusBadRequest)
+		return
+	}
+	ag, err := a.store.UpdateAgent(r.Context(), agentID, persistence.AgentPatch{
+		Name:            payload.Name,
+		Persona:         payload.Persona,
+		BehaviorProfile: payload.BehaviorProfile,
+	})
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "agent not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to update agent", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(agentJSON(ag))
+}
+
+// deleteAgent soft-deletes an agent; its past messages keep their attribution.
+func (a orchestrationAPI) deleteAgent(w http.ResponseWriter, r *http.Request) {
+	agentID, _ := r.Context().Value("agentID").(string)
+	if err := a.store.DeleteAgent(r.Context(), agentID); err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "agent not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to delete agent", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (a orchestrationAPI) createConversation(w http.ResponseWriter, r *http.Request) {
-	// stub: create conversation with no participants
-	id, err := a.orchestrator.CreateConversation(r.Context(), "Conversation (MVP)", nil)
+	// body is optional: {"title": "...", "participants": ["agent-id", ...], "metadata": {"turn_policy": {...}}}
+	var payload struct {
+		Title        string                 `json:"title"`
+		Participants []string               `json:"participants"`
+		Metadata     map[string]interface{} `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if payload.Title == "" {
+		payload.Title = "Conversation (MVP)"
+	}
+	id, err := a.orchestrator.CreateConversation(r.Context(), payload.Title, payload.Participants, payload.Metadata)
+	if errors.Is(err, persistence.ErrNotFound) {
+		http.Error(w, "unknown participant", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, orchestrator.ErrInvalidTurnPolicy) || errors.Is(err, orchestrator.ErrInvalidContextConfig) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to create conversation", http.StatusInternalServerError)
 		return
@@ -208,6 +551,330 @@ func (a orchestrationAPI) listConversations(w http.ResponseWriter, r *http.Reque
 	_ = json.NewEncoder(w).Encode(list)
 }
 
+// getConversation returns a conversation with its metadata and participants.
+func (a orchestrationAPI) getConversation(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	c, err := a.store.GetConversation(r.Context(), convID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to get conversation", http.StatusInternalServerError)
+		return
+	}
+	md, err := a.store.GetConversationMetadata(r.Context(), convID)
+	if err != nil {
+		http.Error(w, "failed to get conversation", http.StatusInternalServerError)
+		return
+	}
+	participants, err := a.store.ListParticipants(r.Context(), convID)
+	if err != nil {
+		http.Error(w, "failed to get conversation", http.StatusInternalServerError)
+		return
+	}
+	if participants == nil {
+		participants = []agent.Agent{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		*persistence.Conversation
+		Metadata     map[string]interface{} `json:"metadata,omitempty"`
+		Participants []agent.Agent          `json:"participants"`
+	}{c, md, participants})
+}
+
+// updateConversation renames a conversation and/or changes its status
+// (active, paused, closed, archived).
+func (a orchestrationAPI) updateConversation(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	var payload struct {
+		Title  *string `json:"title"`
+		Status *string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	c, err := a.orchestrator.UpdateConversation(r.Context(), convID, persistence.ConversationPatch{Title: payload.Title, Status: payload.Status})
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		http.Error(w, "conversation not found", http.StatusNotFound)
+		return
+	case errors.Is(err, orchestrator.ErrInvalidConversationUpdate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, orchestrator.ErrStatusTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to update conversation", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// deleteConversation deletes a conversation with its messages and event log.
+func (a orchestrationAPI) deleteConversation(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	if err := a.orchestrator.DeleteConversation(r.Context(), convID); err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to delete conversation", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxSearchResults caps k on /search.
+const maxSearchResults = 100
+
+// search embeds q and returns the most similar stored messages with their scores.
+// Filters: conversation_id, agent_id (agent senders) or sender_id, since/until (RFC 3339), k.
+func (a orchestrationAPI) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	text := strings.TrimSpace(q.Get("q"))
+	if text == "" {
+		http.Error(w, "missing q", http.StatusBadRequest)
+		return
+	}
+	opts := persistence.SearchOptions{ConversationID: q.Get("conversation_id"), SenderID: q.Get("sender_id"), K: 10}
+	if id := q.Get("agent_id"); id != "" {
+		opts.SenderType, opts.SenderID = agent.SenderAgent, id
+	}
+	if v := q.Get("k"); v != "" {
+		k, err := strconv.Atoi(v)
+		if err != nil || k <= 0 || k > maxSearchResults {
+			http.Error(w, "invalid k", http.StatusBadRequest)
+			return
+		}
+		opts.K = k
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &opts.Since}, {"until", &opts.Until}} {
+		if v := q.Get(p.name); v != "" {
+			ts, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "invalid "+p.name, http.StatusBadRequest)
+				return
+			}
+			*p.dst = ts
+		}
+	}
+	vec, err := embeddings.EmbedOne(r.Context(), a.embedder, text)
+	if err != nil {
+		http.Error(w, "failed to embed query", http.StatusBadGateway)
+		return
+	}
+	hits, err := a.store.SearchMessages(r.Context(), vec, opts)
+	if err != nil {
+		http.Error(w, "failed to search messages", http.StatusInternalServerError)
+		return
+	}
+	if hits == nil {
+		hits = []persistence.ScoredMessage{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"query": text, "results": hits})
+}
+
+// agentMemories lists an agent's memories, newest first, or with ?q= the ones most similar to q.
+func (a orchestrationAPI) agentMemories(w http.ResponseWriter, r *http.Request) {
+	agentID, _ := r.Context().Value("agentID").(string)
+	q := r.URL.Query()
+	k := 20
+	if v := q.Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxSearchResults {
+			http.Error(w, "invalid k", http.StatusBadRequest)
+			return
+		}
+		k = n
+	}
+	var out interface{}
+	if text := strings.TrimSpace(q.Get("q")); text != "" {
+		vec, err := embeddings.EmbedOne(r.Context(), a.embedder, text)
+		if err != nil {
+			http.Error(w, "failed to embed query", http.StatusBadGateway)
+			return
+		}
+		hits, err := a.store.SearchMemories(r.Context(), agentID, vec, k)
+		if err != nil {
+			http.Error(w, "failed to search memories", http.StatusInternalServerError)
+			return
+		}
+		if hits == nil {
+			hits = []persistence.ScoredMemory{}
+		}
+		out = hits
+	} else {
+		mems, err := a.store.ListMemories(r.Context(), agentID, k)
+		if err != nil {
+			http.Error(w, "failed to list memories", http.StatusInternalServerError)
+			return
+		}
+		if mems == nil {
+			mems = []agent.Memory{}
+		}
+		out = mems
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"memories": out})
+}
+
+func (a orchestrationAPI) listParticipants(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	agents, err := a.store.ListParticipants(r.Context(), convID)
+	if err != nil {
+		http.Error(w, "failed to list participants", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(agents)
+}
+
+func (a orchestrationAPI) addParticipants(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	var payload struct {
+		AgentIDs []string `json:"agent_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || len(payload.AgentIDs) == 0 {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := a.orchestrator.AddParticipants(r.Context(), convID, payload.AgentIDs); err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "unknown conversation or agent", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to add participants", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a orchestrationAPI) removeParticipants(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	var agentIDs []string
+	if id, _ := r.Context().Value("agentID").(string); id != "" {
+		agentIDs = []string{id}
+	} else {
+		var payload struct {
+			AgentIDs []string `json:"agent_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		agentIDs = payload.AgentIDs
+	}
+	if len(agentIDs) == 0 {
+		http.Error(w, "missing agent id", http.StatusBadRequest)
+		return
+	}
+	for _, id := range agentIDs {
+		if err := a.orchestrator.RemoveParticipant(r.Context(), convID, id); err != nil {
+			if errors.Is(err, persistence.ErrNotFound) {
+				http.Error(w, "participant not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "failed to remove participant", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	defaultMessagePage = 50
+	maxMessagePage     = 200
+)
+
+// messageCursor sets the id or timestamp cursor for one side of a message page.
+func messageCursor(raw string, id *string, at *time.Time) {
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		*at = t
+		return
+	}
+	*id = raw
+}
+
+// listMessages returns a page of a conversation's messages. Query parameters:
+// before / after (message id or RFC 3339 timestamp), sender_id, limit (default 50,
+// max 200) and order (desc, the default, for newest first, or asc). next_cursor
+// continues in the same order: pass it as before for desc and as after for asc.
+func (a orchestrationAPI) listMessages(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	q := r.URL.Query()
+	query := persistence.MessageQuery{
+		SenderID:  q.Get("sender_id"),
+		ThreadID:  q.Get("thread_id"),
+		MentionID: q.Get("mentions"),
+		Limit:     defaultMessagePage,
+		Desc:      true,
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxMessagePage {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		query.Limit = n
+	}
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Desc = false
+	default:
+		http.Error(w, "invalid order", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("before"); v != "" {
+		messageCursor(v, &query.BeforeID, &query.Before)
+	}
+	if v := q.Get("after"); v != "" {
+		messageCursor(v, &query.AfterID, &query.After)
+	}
+	if _, err := a.store.GetConversation(r.Context(), convID); err != nil {
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to list messages", http.StatusInternalServerError)
+		return
+	}
+	// one extra message tells whether another page follows
+	limit := query.Limit
+	query.Limit++
+	msgs, err := a.store.ListMessages(r.Context(), convID, query)
+	if errors.Is(err, persistence.ErrNotFound) {
+		http.Error(w, "unknown cursor message", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to list messages", http.StatusInternalServerError)
+		return
+	}
+	hasMore := len(msgs) > limit
+	if hasMore {
+		msgs = msgs[:limit]
+	}
+	out := map[string]interface{}{"messages": msgs, "has_more": hasMore}
+	if msgs == nil {
+		out["messages"] = []agent.Message{}
+	}
+	if hasMore {
+		out["next_cursor"] = msgs[len(msgs)-1].ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
 func (a orchestrationAPI) postMessage(w http.ResponseWriter, r *http.Request) {
 	// convID may be provided in context by the Router helper
 	convID, _ := r.Context().Value("convID").(string)
@@ -215,16 +882,42 @@ func (a orchestrationAPI) postMessage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing conversation id", http.StatusBadRequest)
 		return
 	}
-	// In MVP we accept raw body as message content
+	// In MVP we accept raw body as message content; a JSON body may also set reply_to
 	defer r.Body.Close()
-	buf := make([]byte, 4096)
-	n, _ := r.Body.Read(buf)
-	content := string(buf[:n])
-	if err := a.orchestrator.HandleUserMessage(r.Context(), convID, "user-mvp", content); err != nil {
+	var payload struct {
+		Content string `json:"content"`
+		ReplyTo string `json:"reply_to"`
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		buf := make([]byte, 4096)
+		n, _ := r.Body.Read(buf)
+		payload.Content = string(buf[:n])
+	}
+	id, err := a.orchestrator.HandleUserReply(r.Context(), convID, "user-mvp", payload.Content, payload.ReplyTo)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrInvalidReply) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, orchestrator.ErrConversationClosed) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "failed to handle message", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message_id": id})
 }
 
 func (a orchestrationAPI) startDebate(w http.ResponseWriter, r *http.Request) {
@@ -233,18 +926,256 @@ func (a orchestrationAPI) startDebate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing conversation id", http.StatusBadRequest)
 		return
 	}
-	var payload struct {
-		Participants []string `json:"participants"`
-		Rounds       int      `json:"rounds"`
-	}
+	var payload orchestrator.DebateOptions
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
-	if err := a.orchestrator.StartDebate(r.Context(), convID, payload.Participants, payload.Rounds); err != nil {
+	// the debate runs in the background; follow it at /debates/{id}
+	d, err := a.orchestrator.StartDebateAsync(r.Context(), convID, payload)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrInvalidDebate) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, orchestrator.ErrConversationInactive) || errors.Is(err, orchestrator.ErrDebateRunning) || errors.Is(err, orchestrator.ErrAutonomousRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "failed to start debate:"+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(d)
+}
+
+// debate returns a debate's state, round and current speaker.
+func (a orchestrationAPI) debate(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("debateID").(string)
+	d, err := a.orchestrator.Debate(r.Context(), id)
+	writeDebate(w, d, err)
+}
+
+// debateResult returns the judge's scores and verdict for a debate.
+func (a orchestrationAPI) debateResult(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("debateID").(string)
+	res, err := a.orchestrator.DebateResult(r.Context(), id)
+	if errors.Is(err, persistence.ErrNotFound) {
+		http.Error(w, "debate result not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load debate result", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// controlDebate pauses, resumes or cancels a debate.
+func (a orchestrationAPI) controlDebate(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("debateID").(string)
+	var (
+		d   *persistence.Debate
+		err error
+	)
+	switch action, _ := r.Context().Value("debateAction").(string); action {
+	case "pause":
+		d, err = a.orchestrator.PauseDebate(r.Context(), id)
+	case "resume":
+		d, err = a.orchestrator.ResumeDebate(r.Context(), id)
+	case "cancel":
+		d, err = a.orchestrator.CancelDebate(r.Context(), id)
+	}
+	writeDebate(w, d, err)
+}
+
+// startAutonomous lets a conversation's agents talk among themselves.
+func (a orchestrationAPI) startAutonomous(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	var payload orchestrator.AutonomousOptions
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	// the run goes on in the background; follow it at /autonomous/{id}
+	run, err := a.orchestrator.StartAutonomous(r.Context(), convID, payload)
+	if err != nil {
+		if errors.Is(err, orchestrator.ErrInvalidAutonomous) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, orchestrator.ErrConversationInactive) || errors.Is(err, orchestrator.ErrDebateRunning) || errors.Is(err, orchestrator.ErrAutonomousRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, persistence.ErrNotFound) {
+			http.Error(w, "conversation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to start autonomous run", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(run)
+}
+
+// autonomousRun returns an autonomous run's state, turns and current speaker.
+func (a orchestrationAPI) autonomousRun(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("runID").(string)
+	run, err := a.orchestrator.AutonomousRun(r.Context(), id)
+	writeAutonomousRun(w, run, err)
+}
+
+// controlAutonomous pauses, resumes or cancels an autonomous run.
+func (a orchestrationAPI) controlAutonomous(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("runID").(string)
+	var (
+		run *persistence.AutonomousRun
+		err error
+	)
+	switch action, _ := r.Context().Value("runAction").(string); action {
+	case "pause":
+		run, err = a.orchestrator.PauseAutonomous(r.Context(), id)
+	case "resume":
+		run, err = a.orchestrator.ResumeAutonomous(r.Context(), id)
+	case "cancel":
+		run, err = a.orchestrator.CancelAutonomous(r.Context(), id)
+	}
+	writeAutonomousRun(w, run, err)
+}
+
+// createPoll opens a poll in a conversation on behalf of the caller.
+func (a orchestrationAPI) createPoll(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	var payload orchestrator.PollOptions
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	p, err := a.orchestrator.CreatePoll(r.Context(), convID, api.Subject(r), payload)
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// listPolls returns a conversation's polls with their tallies, newest first.
+func (a orchestrationAPI) listPolls(w http.ResponseWriter, r *http.Request) {
+	convID, _ := r.Context().Value("convID").(string)
+	if _, err := a.store.GetConversation(r.Context(), convID); err != nil {
+		writePollError(w, err)
+		return
+	}
+	polls, err := a.orchestrator.Polls(r.Context(), convID)
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(polls)
+}
+
+// poll returns a poll with its tally.
+func (a orchestrationAPI) poll(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("pollID").(string)
+	p, err := a.orchestrator.Poll(r.Context(), id)
+	writePoll(w, p, err)
+}
+
+// vote casts, or changes, a vote in a poll.
+func (a orchestrationAPI) vote(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("pollID").(string)
+	var payload struct {
+		Option *int `json:"option"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Option == nil {
+		http.Error(w, "invalid body: option is required", http.StatusBadRequest)
+		return
+	}
+	p, err := a.orchestrator.Vote(r.Context(), id, api.Subject(r), *payload.Option)
+	writePoll(w, p, err)
+}
+
+// closePoll closes a poll before its deadline, if the caller created it or is an admin.
+func (a orchestrationAPI) closePoll(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value("pollID").(string)
+	p, err := a.orchestrator.Poll(r.Context(), id)
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	if p.CreatedBy != api.Subject(r) && !api.IsAdmin(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	p, err = a.orchestrator.ClosePoll(r.Context(), id)
+	writePoll(w, p, err)
+}
+
+// writePoll answers with p, or maps err onto a status.
+func writePoll(w http.ResponseWriter, p *persistence.Poll, err error) {
+	if err != nil {
+		writePollError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+func writePollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, orchestrator.ErrInvalidPoll), errors.Is(err, orchestrator.ErrInvalidVote):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, persistence.ErrPollClosed), errors.Is(err, orchestrator.ErrConversationClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, persistence.ErrNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		http.Error(w, "failed to handle poll", http.StatusInternalServerError)
+	}
+}
+
+// writeAutonomousRun answers with run, or maps err onto a status.
+func writeAutonomousRun(w http.ResponseWriter, run *persistence.AutonomousRun, err error) {
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		http.Error(w, "autonomous run not found", http.StatusNotFound)
+		return
+	case errors.Is(err, orchestrator.ErrAutonomousEnded), errors.Is(err, orchestrator.ErrNoAutonomous), errors.Is(err, orchestrator.ErrConversationInactive):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to update autonomous run", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(run)
+}
+
+// writeDebate answers with d, or maps err onto a status.
+func writeDebate(w http.ResponseWriter, d *persistence.Debate, err error) {
+	switch {
+	case errors.Is(err, persistence.ErrNotFound):
+		http.Error(w, "debate not found", http.StatusNotFound)
+		return
+	case errors.Is(err, orchestrator.ErrDebateEnded), errors.Is(err, orchestrator.ErrNoDebate), errors.Is(err, orchestrator.ErrConversationInactive):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to update debate", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(d)
 }
 
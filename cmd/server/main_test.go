@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	api "github.com/yourname/multiagent-social/internal/api"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/orchestrator"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+)
+
+// newTestAPI serves the API on in-memory storage and pubsub.
+func newTestAPI(t *testing.T) (http.Handler, *orchestrator.Orchestrator, *persistence.MemoryStore) {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "")
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	t.Cleanup(func() { _ = broker.Close() })
+	embedder := embeddings.NewHashEmbedder(64)
+	orch := orchestrator.NewOrchestrator(store, broker, embedder)
+	t.Cleanup(func() { _ = orch.Close(context.Background()) })
+	a := orchestrationAPI{store: store, orchestrator: orch, embedder: embedder}
+	return a.Router(), orch, store
+}
+
+// token signs a token for subject with role.
+func token(t *testing.T, subject, role string) string {
+	t.Helper()
+	tok, err := api.GenerateToken(subject, role, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+// do serves a request with an optional bearer token and returns the status.
+func do(h http.Handler, method, path, bearer string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestClosePollRequiresCreatorOrAdmin(t *testing.T) {
+	h, orch, _ := newTestAPI(t)
+	ctx := context.Background()
+	convID, err := orch.CreateConversation(ctx, "polls", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := orch.CreatePoll(ctx, convID, "u1", orchestrator.PollOptions{Question: "tea or coffee?", Options: []string{"tea", "coffee"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := orch.CreatePoll(ctx, convID, "u1", orchestrator.PollOptions{Question: "now?", Options: []string{"yes", "no"}})
+
+	if code := do(h, http.MethodPost, "/polls/"+first.ID+"/close", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 closing anonymously, got %d", code)
+	}
+	if code := do(h, http.MethodPost, "/polls/"+first.ID+"/close", token(t, "u2", "user")); code != http.StatusForbidden {
+		t.Fatalf("expected 403 closing someone else's poll, got %d", code)
+	}
+	if p, _ := orch.Poll(ctx, first.ID); p.ClosedAt != nil {
+		t.Fatalf("poll closed by a refused request: %+v", p)
+	}
+	if code := do(h, http.MethodPost, "/polls/"+first.ID+"/close", token(t, "u1", "user")); code != http.StatusOK {
+		t.Fatalf("expected the creator to close the poll, got %d", code)
+	}
+	if code := do(h, http.MethodPost, "/polls/"+second.ID+"/close", token(t, "root", "admin")); code != http.StatusOK {
+		t.Fatalf("expected an admin to close the poll, got %d", code)
+	}
+}
+
+func TestDebateAndAutonomousControlRequireUser(t *testing.T) {
+	h, orch, _ := newTestAPI(t)
+	convID, err := orch.CreateConversation(context.Background(), "control", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{
+		"/conversations/" + convID + "/debate",
+		"/conversations/" + convID + "/autonomous",
+		"/debates/" + convID + "/cancel",
+		"/autonomous/" + convID + "/cancel",
+	} {
+		if code := do(h, http.MethodPost, path, ""); code != http.StatusUnauthorized {
+			t.Errorf("POST %s: expected 401 without a token, got %d", path, code)
+		}
+	}
+}
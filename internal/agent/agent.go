@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -19,17 +20,38 @@ type Agent struct {
 }
 
 // ConversationState is a lightweight snapshot of the conversation for decision making.
+// Messages is the recent window; Related and Own recall older messages from
+// outside it, so long conversations stay coherent without sending everything,
+// and Memories carries what the agent remembers from any conversation.
 type ConversationState struct {
 	ConversationID string
-	Messages       []string
+	Messages       []Message // most recent messages, oldest first
+	Related        []Message // older messages similar to the latest one, oldest first
+	Own            []Message // the agent's own older statements, oldest first
+	Memories       []Memory  // long-term memories relevant to the latest message, most relevant first
+	// Directive, when set, is what the agent is asked to do this turn, such as
+	// a debate phase; deciders that cannot follow it may ignore it.
+	Directive string
+	// Mention, when set, is the message that mentioned the agent by @Name and
+	// which it now answers.
+	Mention *Message
+	// Topic, when set, is what the agents are discussing among themselves.
+	Topic string
+	// MayLeave allows the agent to answer with an ActionLeave once it has
+	// nothing more to add.
+	MayLeave bool
 }
 
 // Action represents what an Agent wants to do.
 type Action struct {
-	Type    string // "speak", "ask", "challenge"
+	Type    string // "speak", "ask", "challenge", or ActionLeave
 	Payload string
 }
 
+// ActionLeave is the action type of an agent leaving a discussion; its
+// payload, if any, is a parting message.
+const ActionLeave = "leave"
+
 // Decider returns an action for an agent given conversation state.
 type Decider interface {
 	DecideAction(ctx context.Context, a *Agent, state *ConversationState) (*Action, error)
@@ -42,12 +64,18 @@ func (s *SimpleDecider) DecideAction(ctx context.Context, a *Agent, state *Conve
 	if a == nil {
 		return nil, errors.New("nil agent")
 	}
+	// A directive gets a templated answer in character.
+	if state.Directive != "" {
+		return &Action{
+			Type:    "speak",
+			Payload: a.Name + "（" + state.Directive + "）: 基于我的身份[" + a.Persona + "]，我认为...",
+		}, nil
+	}
 	// If there are messages, reply by reflecting last one; otherwise introduce self.
-	if len(state.Messages) > 0 {
-		last := state.Messages[len(state.Messages)-1]
+	if last := state.LastMessage(); last != nil {
 		return &Action{
 			Type:    "speak",
-			Payload: a.Name + "回应: " + last,
+			Payload: a.Name + "回应: " + last.Content,
 		}, nil
 	}
 	return &Action{
@@ -56,3 +84,37 @@ func (s *SimpleDecider) DecideAction(ctx context.Context, a *Agent, state *Conve
 	}, nil
 }
 
+// Bidder is implemented by deciders that can report how much an agent wants
+// to speak next, as a score in [0, 1]. Bid-based turn policies use it.
+type Bidder interface {
+	Bid(ctx context.Context, a *Agent, state *ConversationState) (float64, error)
+}
+
+// DefaultBid scores an agent from its "talkativeness" knob (default 0.5),
+// boosted when the agent is addressed by name in the last message.
+func DefaultBid(a *Agent, state *ConversationState) float64 {
+	score := 0.5
+	if a.BehaviorProfile != nil {
+		if t, ok := a.BehaviorProfile["talkativeness"].(float64); ok {
+			score = t
+		}
+	}
+	if len(state.Messages) > 0 && a.Name != "" {
+		last := strings.ToLower(state.lastContent())
+		if strings.Contains(last, strings.ToLower(a.Name)) {
+			score += 0.5
+		}
+	}
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// Bid implements Bidder using DefaultBid.
+func (s *SimpleDecider) Bid(ctx context.Context, a *Agent, state *ConversationState) (float64, error) {
+	return DefaultBid(a, state), nil
+}
@@ -10,7 +10,7 @@ func TestSimpleDeciderReplies(t *testing.T) {
 	a := &Agent{ID: "a1", Name: "TestAgent", Persona: "music, literature"}
 	state := &ConversationState{
 		ConversationID: "conv1",
-		Messages:       []string{"hello"},
+		Messages:       []Message{{Content: "hello"}},
 	}
 	act, err := dec.DecideAction(context.Background(), a, state)
 	if err != nil {
@@ -20,4 +20,3 @@ func TestSimpleDeciderReplies(t *testing.T) {
 		t.Fatalf("expected payload, got %+v", act)
 	}
 }
-
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// MaxScore is the top of the scale debate judges score on; scores are in [0, MaxScore].
+const MaxScore = 10
+
+// Scorer is implemented by deciders that can judge a debate round: given the
+// round's messages in state, it scores each speaker on the 0..MaxScore scale.
+// Debate judges use it.
+type Scorer interface {
+	Score(ctx context.Context, judge *Agent, state *ConversationState, speakers []Agent) (map[AgentID]float64, error)
+}
+
+// DefaultScores scores each speaker by how much they brought to the round:
+// nothing said scores 0, a short remark 5, and every further 15 distinct
+// words add a point up to MaxScore.
+func DefaultScores(state *ConversationState, speakers []Agent) map[AgentID]float64 {
+	words := make(map[AgentID]map[string]bool, len(speakers))
+	for _, m := range state.Messages {
+		id := AgentID(m.SenderID)
+		if words[id] == nil {
+			words[id] = make(map[string]bool)
+		}
+		for _, w := range strings.Fields(strings.ToLower(m.Content)) {
+			words[id][w] = true
+		}
+	}
+	scores := make(map[AgentID]float64, len(speakers))
+	for _, s := range speakers {
+		n := len(words[s.ID])
+		if n == 0 {
+			scores[s.ID] = 0
+			continue
+		}
+		scores[s.ID] = min(MaxScore, 5+float64(n/15))
+	}
+	return scores
+}
+
+// Score implements Scorer using DefaultScores.
+func (s *SimpleDecider) Score(ctx context.Context, judge *Agent, state *ConversationState, speakers []Agent) (map[AgentID]float64, error) {
+	return DefaultScores(state, speakers), nil
+}
+
+// Score asks the provider to score the round. Errors or unusable output fall
+// back to the Fallback's scores, or DefaultScores.
+func (d *LLMDecider) Score(ctx context.Context, judge *Agent, state *ConversationState, speakers []Agent) (map[AgentID]float64, error) {
+	if d.Provider != nil {
+		if out, err := d.Provider.Complete(ctx, buildScorePrompt(judge, state, speakers)); err == nil {
+			if scores, err := ParseScores(out, speakers); err == nil {
+				return scores, nil
+			}
+		}
+	}
+	if s, ok := d.Fallback.(Scorer); ok {
+		return s.Score(ctx, judge, state, speakers)
+	}
+	return DefaultScores(state, speakers), nil
+}
+
+// buildScorePrompt asks the judge to score each speaker of the round in state.
+func buildScorePrompt(judge *Agent, state *ConversationState, speakers []Agent) []ChatMessage {
+	var sys strings.Builder
+	fmt.Fprintf(&sys, "You are %s, the judge of a debate.\n", judge.Name)
+	if judge.Persona != "" {
+		fmt.Fprintf(&sys, "Persona: %s\n", judge.Persona)
+	}
+	sys.WriteString("Judge fairly on the strength of arguments, use of evidence and engagement with the other side.")
+
+	names := make([]string, len(speakers))
+	for i, s := range speakers {
+		names[i] = s.Name
+	}
+	var b strings.Builder
+	writeMessages(&b, "The round:\n", state.Messages)
+	if state.Directive != "" {
+		b.WriteString(state.Directive + "\n")
+	}
+	fmt.Fprintf(&b, "Score each of %s from 0 to %d. Reply with only a JSON object mapping each name to a score, e.g. {%q: 7}.",
+		strings.Join(names, ", "), MaxScore, names[0])
+	return []ChatMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: sys.String()},
+		{Role: openai.ChatMessageRoleUser, Content: b.String()},
+	}
+}
+
+// ParseScores maps model output of the form {"<speaker name>": score} onto
+// speaker ids. Every speaker must be scored; scores are clamped to the scale.
+func ParseScores(out string, speakers []Agent) (map[AgentID]float64, error) {
+	out = strings.TrimSpace(out)
+	out = strings.TrimPrefix(out, "```json")
+	out = strings.TrimPrefix(out, "```")
+	out = strings.TrimSuffix(out, "```")
+	var byName map[string]float64
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &byName); err != nil {
+		return nil, fmt.Errorf("parse scores: %w", err)
+	}
+	lower := make(map[string]float64, len(byName))
+	for name, score := range byName {
+		lower[strings.ToLower(strings.TrimSpace(name))] = score
+	}
+	scores := make(map[AgentID]float64, len(speakers))
+	for _, s := range speakers {
+		score, ok := lower[strings.ToLower(s.Name)]
+		if !ok {
+			return nil, fmt.Errorf("no score for %s", s.Name)
+		}
+		scores[s.ID] = max(0, min(MaxScore, score))
+	}
+	return scores, nil
+}
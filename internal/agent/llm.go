@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ChatMessage is a single role/content pair sent to a chat-completion provider.
+type ChatMessage struct {
+	Role    string // "system", "user" or "assistant"
+	Content string
+}
+
+// ChatProvider produces a completion for a list of chat messages.
+type ChatProvider interface {
+	Complete(ctx context.Context, messages []ChatMessage) (string, error)
+}
+
+// OpenAIConfig configures an OpenAI-compatible chat-completion provider.
+type OpenAIConfig struct {
+	APIKey      string
+	BaseURL     string // empty uses the public OpenAI endpoint
+	Model       string // empty uses gpt-3.5-turbo
+	Temperature float32
+	MaxTokens   int
+}
+
+// OpenAIProvider calls the chat completions API of any OpenAI-compatible server.
+type OpenAIProvider struct {
+	client *openai.Client
+	cfg    OpenAIConfig
+}
+
+// NewOpenAIProvider builds a provider from cfg.
+func NewOpenAIProvider(cfg OpenAIConfig) *OpenAIProvider {
+	clientCfg := openai.DefaultConfig(cfg.APIKey)
+	if cfg.BaseURL != "" {
+		clientCfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	}
+	if cfg.Model == "" {
+		cfg.Model = openai.GPT3Dot5Turbo
+	}
+	return &OpenAIProvider{client: openai.NewClientWithConfig(clientCfg), cfg: cfg}
+}
+
+// Complete sends messages to the chat completions endpoint and returns the first choice.
+func (p *OpenAIProvider) Complete(ctx context.Context, messages []ChatMessage) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model:       p.cfg.Model,
+		Temperature: p.cfg.Temperature,
+		MaxTokens:   p.cfg.MaxTokens,
+	}
+	for _, m := range messages {
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: m.Role, Content: m.Content})
+	}
+	resp, err := p.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("no completion choices returned")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// maxPromptMessages bounds how much history is sent to the provider per turn.
+const maxPromptMessages = 20
+
+// LLMDecider asks a chat-completion provider what the agent should do next.
+// Provider errors and unusable output fall back to Fallback (SimpleDecider when nil).
+type LLMDecider struct {
+	Provider ChatProvider
+	Fallback Decider
+}
+
+// NewLLMDecider returns an LLMDecider using p and the SimpleDecider fallback.
+func NewLLMDecider(p ChatProvider) *LLMDecider {
+	return &LLMDecider{Provider: p, Fallback: &SimpleDecider{}}
+}
+
+func (d *LLMDecider) DecideAction(ctx context.Context, a *Agent, state *ConversationState) (*Action, error) {
+	if a == nil {
+		return nil, errors.New("nil agent")
+	}
+	if d.Provider == nil {
+		return d.fallback(ctx, a, state)
+	}
+	out, err := d.Provider.Complete(ctx, BuildPrompt(a, state))
+	if err != nil {
+		return d.fallback(ctx, a, state)
+	}
+	act, err := ParseAction(out)
+	if err != nil {
+		return d.fallback(ctx, a, state)
+	}
+	return act, nil
+}
+
+func (d *LLMDecider) fallback(ctx context.Context, a *Agent, state *ConversationState) (*Action, error) {
+	if d.Fallback != nil {
+		return d.Fallback.DecideAction(ctx, a, state)
+	}
+	return (&SimpleDecider{}).DecideAction(ctx, a, state)
+}
+
+// BuildPrompt renders the agent persona, behavior profile and conversation history as chat messages.
+func BuildPrompt(a *Agent, state *ConversationState) []ChatMessage {
+	var sys strings.Builder
+	fmt.Fprintf(&sys, "You are %s, a participant in a multi-party online conversation.\n", a.Name)
+	if a.Persona != "" {
+		fmt.Fprintf(&sys, "Persona: %s\n", a.Persona)
+	}
+	if len(a.BehaviorProfile) > 0 {
+		keys := make([]string, 0, len(a.BehaviorProfile))
+		for k := range a.BehaviorProfile {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sys.WriteString("Behavior profile:\n")
+		for _, k := range keys {
+			fmt.Fprintf(&sys, "- %s: %v\n", k, a.BehaviorProfile[k])
+		}
+	}
+	if state.Topic != "" {
+		fmt.Fprintf(&sys, "You are discussing %q with the other participants; address one of them with @Name.\n", state.Topic)
+	}
+	sys.WriteString("Stay in character and reply in the language of the conversation.\n")
+	sys.WriteString(`Respond with a single JSON object: {"type": "speak" | "ask" | "challenge", "content": "<your message>"}. ` +
+		`Use "ask" to pose a question, "challenge" to dispute a previous claim, and "speak" otherwise.`)
+	if state.MayLeave {
+		sys.WriteString(` Use "leave", with a parting word as content, once you have nothing more to add.`)
+	}
+
+	conv := transcript(state)
+	switch {
+	case state.Directive != "":
+		conv += "Your turn: " + state.Directive
+	case state.Mention != nil:
+		conv += fmt.Sprintf("%s mentioned you in %q. Answer them.", state.Mention.Speaker(), state.Mention.Content)
+	case len(state.Messages) == 0:
+		conv += "Introduce yourself."
+	default:
+		conv += "What do you say next?"
+	}
+	return []ChatMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: sys.String()},
+		{Role: openai.ChatMessageRoleUser, Content: conv},
+	}
+}
+
+// transcript renders the agent's memories, the recalled context and the most recent messages of state for a prompt.
+func transcript(state *ConversationState) string {
+	msgs := state.Messages
+	if len(msgs) > maxPromptMessages {
+		msgs = msgs[len(msgs)-maxPromptMessages:]
+	}
+	var b strings.Builder
+	if len(state.Memories) > 0 {
+		b.WriteString("Things you remember:\n")
+		for _, m := range state.Memories {
+			fmt.Fprintf(&b, "- (%s) %s\n", m.Kind, m.Content)
+		}
+	}
+	writeMessages(&b, "Earlier messages related to the latest one:\n", state.Related)
+	writeMessages(&b, "Things you said earlier:\n", state.Own)
+	if len(msgs) == 0 {
+		b.WriteString("The conversation has not started yet. ")
+		return b.String()
+	}
+	writeMessages(&b, "Conversation so far:\n", msgs)
+	return b.String()
+}
+
+// writeMessages writes a headed list of msgs, or nothing when msgs is empty.
+func writeMessages(b *strings.Builder, heading string, msgs []Message) {
+	if len(msgs) == 0 {
+		return
+	}
+	b.WriteString(heading)
+	for _, m := range msgs {
+		fmt.Fprintf(b, "- %s: %s\n", m.Speaker(), m.Content)
+	}
+}
+
+// Bid asks the provider how much the agent wants to speak next. Errors or
+// unparsable scores fall back to the Fallback's bid, or DefaultBid.
+func (d *LLMDecider) Bid(ctx context.Context, a *Agent, state *ConversationState) (float64, error) {
+	if d.Provider != nil {
+		msgs := BuildPrompt(a, state)
+		msgs[len(msgs)-1].Content = transcript(state) +
+			"On a scale from 0 to 1, how much do you want to speak next? Reply with only the number."
+		if out, err := d.Provider.Complete(ctx, msgs); err == nil {
+			if score, err := strconv.ParseFloat(strings.TrimSpace(out), 64); err == nil && score >= 0 && score <= 1 {
+				return score, nil
+			}
+		}
+	}
+	if b, ok := d.Fallback.(Bidder); ok {
+		return b.Bid(ctx, a, state)
+	}
+	return DefaultBid(a, state), nil
+}
+
+// ParseAction maps raw model output onto an Action. JSON output is preferred;
+// plain text is accepted as a "speak" action.
+func ParseAction(out string) (*Action, error) {
+	out = strings.TrimSpace(out)
+	out = strings.TrimPrefix(out, "```json")
+	out = strings.TrimPrefix(out, "```")
+	out = strings.TrimSuffix(out, "```")
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return nil, errors.New("empty model output")
+	}
+	var parsed struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	if strings.HasPrefix(out, "{") && json.Unmarshal([]byte(out), &parsed) == nil {
+		content, typ := strings.TrimSpace(parsed.Content), normalizeActionType(parsed.Type)
+		// an agent may leave without a word
+		if content == "" && typ != ActionLeave {
+			return nil, errors.New("empty content in model output")
+		}
+		return &Action{Type: typ, Payload: content}, nil
+	}
+	return &Action{Type: "speak", Payload: out}, nil
+}
+
+func normalizeActionType(t string) string {
+	switch strings.ToLower(strings.TrimSpace(t)) {
+	case "ask":
+		return "ask"
+	case "challenge":
+		return "challenge"
+	case ActionLeave:
+		return ActionLeave
+	default:
+		return "speak"
+	}
+}
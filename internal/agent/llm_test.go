@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newStubChatServer(t *testing.T, status int, content string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			http.NotFound(w, r)
+			return
+		}
+		if status != http.StatusOK {
+			http.Error(w, `{"error":{"message":"boom"}}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLLMDeciderMapsActionType(t *testing.T) {
+	srv := newStubChatServer(t, http.StatusOK, `{"type":"challenge","content":"I disagree."}`)
+	dec := NewLLMDecider(NewOpenAIProvider(OpenAIConfig{APIKey: "test", BaseURL: srv.URL + "/v1"}))
+	a := &Agent{ID: "a1", Name: "Critic", Persona: "skeptic"}
+	act, err := dec.DecideAction(context.Background(), a, &ConversationState{Messages: []Message{{Content: "the earth is flat"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Type != "challenge" || act.Payload != "I disagree." {
+		t.Fatalf("unexpected action %+v", act)
+	}
+}
+
+func TestLLMDeciderFallsBackOnProviderError(t *testing.T) {
+	srv := newStubChatServer(t, http.StatusInternalServerError, "")
+	dec := NewLLMDecider(NewOpenAIProvider(OpenAIConfig{APIKey: "test", BaseURL: srv.URL + "/v1"}))
+	a := &Agent{ID: "a1", Name: "TestAgent", Persona: "music"}
+	act, err := dec.DecideAction(context.Background(), a, &ConversationState{Messages: []Message{{Content: "hello"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Payload != "TestAgent回应: hello" {
+		t.Fatalf("expected SimpleDecider fallback, got %+v", act)
+	}
+}
+
+func TestParseActionPlainText(t *testing.T) {
+	act, err := ParseAction("just words")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Type != "speak" || act.Payload != "just words" {
+		t.Fatalf("unexpected action %+v", act)
+	}
+	if _, err := ParseAction("  "); err == nil {
+		t.Fatal("expected error for empty output")
+	}
+}
+
+func TestBuildPromptAttributesSpeakers(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Critic", Persona: "skeptic"}
+	msgs := BuildPrompt(a, &ConversationState{Messages: []Message{
+		{SenderType: SenderUser, SenderID: "u1", Content: "cats are best"},
+		{SenderType: SenderAgent, SenderID: "a2", SenderName: "Bob", Content: "dogs are"},
+	}})
+	user := msgs[len(msgs)-1].Content
+	if !strings.Contains(user, "- u1: cats are best") || !strings.Contains(user, "- Bob: dogs are") {
+		t.Fatalf("expected attributed transcript, got %q", user)
+	}
+}
+
+func TestBuildPromptIncludesRecalledContext(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Critic", Persona: "skeptic"}
+	msgs := BuildPrompt(a, &ConversationState{
+		Messages: []Message{{SenderType: SenderUser, SenderID: "u1", Content: "back to cats"}},
+		Related:  []Message{{SenderType: SenderUser, SenderID: "u2", Content: "cats sleep all day"}},
+		Own:      []Message{{SenderType: SenderAgent, SenderID: "a1", SenderName: "Critic", Content: "I prefer dogs"}},
+		Memories: []Memory{{Kind: MemoryFact, Content: "u1 owns three cats"}},
+	})
+	user := msgs[len(msgs)-1].Content
+	if !strings.Contains(user, "- (fact) u1 owns three cats") {
+		t.Fatalf("expected remembered fact, got %q", user)
+	}
+	related := strings.Index(user, "- u2: cats sleep all day")
+	own := strings.Index(user, "- Critic: I prefer dogs")
+	recent := strings.Index(user, "- u1: back to cats")
+	if related < 0 || own < 0 || recent < 0 || !(related < own && own < recent) {
+		t.Fatalf("expected related, own and recent messages in order, got %q", user)
+	}
+}
+
+func TestBuildPromptRecallsBeforeFirstMessage(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Critic", Persona: "skeptic"}
+	msgs := BuildPrompt(a, &ConversationState{
+		Related:  []Message{{SenderType: SenderUser, SenderID: "u2", Content: "cats sleep all day"}},
+		Memories: []Memory{{Kind: MemoryFact, Content: "u1 owns three cats"}},
+	})
+	user := msgs[len(msgs)-1].Content
+	if !strings.Contains(user, "- (fact) u1 owns three cats") || !strings.Contains(user, "- u2: cats sleep all day") {
+		t.Fatalf("expected memories and related messages, got %q", user)
+	}
+	if !strings.HasSuffix(user, "The conversation has not started yet. Introduce yourself.") {
+		t.Fatalf("expected the empty conversation to close the prompt, got %q", user)
+	}
+}
+
+func TestBuildPromptIncludesDirective(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Critic", Persona: "skeptic"}
+	msgs := BuildPrompt(a, &ConversationState{
+		Messages:  []Message{{SenderType: SenderUser, SenderID: "u1", Content: "cats or dogs?"}},
+		Directive: "Opening statement against the motion",
+	})
+	if user := msgs[len(msgs)-1].Content; !strings.HasSuffix(user, "Your turn: Opening statement against the motion") {
+		t.Fatalf("expected the directive to close the prompt, got %q", user)
+	}
+}
+
+func TestBuildPromptAnswersMention(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Critic"}
+	mention := Message{SenderType: SenderUser, SenderID: "u1", Content: "@Critic thoughts?"}
+	msgs := BuildPrompt(a, &ConversationState{Messages: []Message{mention}, Mention: &mention})
+	if user := msgs[len(msgs)-1].Content; !strings.HasSuffix(user, `u1 mentioned you in "@Critic thoughts?". Answer them.`) {
+		t.Fatalf("expected the prompt to ask for an answer to the mention, got %q", user)
+	}
+}
+
+func TestBuildPromptOffersLeaving(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Critic"}
+	sys := BuildPrompt(a, &ConversationState{Topic: "tea or coffee"})[0].Content
+	if !strings.Contains(sys, `You are discussing "tea or coffee"`) || strings.Contains(sys, `"leave"`) {
+		t.Fatalf("expected the topic without the leave action, got %q", sys)
+	}
+	sys = BuildPrompt(a, &ConversationState{Topic: "tea or coffee", MayLeave: true})[0].Content
+	if !strings.Contains(sys, `Use "leave"`) {
+		t.Fatalf("expected the prompt to offer leaving, got %q", sys)
+	}
+
+	// an agent may leave without a parting word, but not speak without one
+	act, err := ParseAction(`{"type": "leave", "content": ""}`)
+	if err != nil || act.Type != ActionLeave {
+		t.Fatalf("expected a leave action, got %+v (%v)", act, err)
+	}
+	if _, err := ParseAction(`{"type": "speak", "content": ""}`); err == nil {
+		t.Fatal("expected error for an empty speak action")
+	}
+}
+
+func TestLLMDeciderScoresRound(t *testing.T) {
+	speakers := []Agent{{ID: "a1", Name: "Alice"}, {ID: "a2", Name: "Bob"}}
+	state := &ConversationState{Messages: []Message{
+		{SenderType: SenderAgent, SenderID: "a1", SenderName: "Alice", Content: "cats are independent"},
+	}}
+	srv := newStubChatServer(t, http.StatusOK, `{"Alice": 8, "bob": 12}`)
+	dec := NewLLMDecider(NewOpenAIProvider(OpenAIConfig{APIKey: "test", BaseURL: srv.URL + "/v1"}))
+	judge := &Agent{ID: "j", Name: "Judge"}
+	scores, err := dec.Score(context.Background(), judge, state, speakers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scores["a1"] != 8 || scores["a2"] != MaxScore {
+		t.Fatalf("expected model scores clamped to the scale, got %v", scores)
+	}
+
+	// a speaker left unscored falls back to the default scores
+	srv = newStubChatServer(t, http.StatusOK, `{"Alice": 8}`)
+	dec = NewLLMDecider(NewOpenAIProvider(OpenAIConfig{APIKey: "test", BaseURL: srv.URL + "/v1"}))
+	if scores, _ = dec.Score(context.Background(), judge, state, speakers); scores["a1"] != 5 || scores["a2"] != 0 {
+		t.Fatalf("expected default scores, got %v", scores)
+	}
+}
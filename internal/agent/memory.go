@@ -0,0 +1,23 @@
+package agent
+
+import "time"
+
+// Kinds of Memory.
+const (
+	MemoryFact         = "fact"         // something true about the world or a person
+	MemoryOpinion      = "opinion"      // a view the agent expressed
+	MemoryRelationship = "relationship" // how the agent relates to someone
+	MemoryEpisode      = "episode"      // a summary of something that happened
+)
+
+// Memory is something an agent remembers across conversations.
+type Memory struct {
+	ID              string    `json:"id"`
+	AgentID         string    `json:"agent_id"`
+	Kind            string    `json:"kind"`
+	Subject         string    `json:"subject,omitempty"` // who or what it is about, e.g. a user id
+	Content         string    `json:"content"`
+	ConversationID  string    `json:"conversation_id,omitempty"`
+	SourceMessageID string    `json:"source_message_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mention is an @Name reference to an agent inside a message. Offset and Length
+// count characters (runes) of the content and cover the leading "@".
+type Mention struct {
+	AgentID string `json:"agent_id"`
+	Name    string `json:"name"`
+	Offset  int    `json:"offset"`
+	Length  int    `json:"length"`
+}
+
+// ParseMentions finds @Name references to agents in content. Names match case
+// insensitively, the longest name wins ("@Ann Lee" over "@Ann"), and an "@"
+// preceded or a name followed by a letter or digit (as in e-mail addresses) is
+// not a mention.
+func ParseMentions(content string, agents []Agent) []Mention {
+	if !strings.Contains(content, "@") {
+		return nil
+	}
+	candidates := make([]Agent, 0, len(agents))
+	for _, a := range agents {
+		if a.Name != "" {
+			candidates = append(candidates, a)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return utf8.RuneCountInString(candidates[i].Name) > utf8.RuneCountInString(candidates[j].Name)
+	})
+	text := []rune(content)
+	var out []Mention
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' || (i > 0 && isNameRune(text[i-1])) {
+			continue
+		}
+		for _, a := range candidates {
+			end := i + 1 + utf8.RuneCountInString(a.Name)
+			if end > len(text) || !strings.EqualFold(string(text[i+1:end]), a.Name) {
+				continue
+			}
+			if end < len(text) && isNameRune(text[end]) {
+				continue
+			}
+			out = append(out, Mention{AgentID: string(a.ID), Name: a.Name, Offset: i, Length: end - i})
+			i = end - 1
+			break
+		}
+	}
+	return out
+}
+
+// isNameRune reports whether r continues a latin word. Other scripts are not
+// separated by spaces, so "@李明你好" still mentions 李明.
+func isNameRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// Mentioned returns the ids of the agents m mentions, in order of first mention.
+func (m *Message) Mentioned() []string {
+	var ids []string
+	seen := make(map[string]bool, len(m.Mentions))
+	for _, mention := range m.Mentions {
+		if !seen[mention.AgentID] {
+			seen[mention.AgentID] = true
+			ids = append(ids, mention.AgentID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,34 @@
+package agent
+
+import "testing"
+
+func TestParseMentions(t *testing.T) {
+	agents := []Agent{{ID: "a1", Name: "Ann"}, {ID: "a2", Name: "Ann Lee"}, {ID: "a3", Name: "Bob"}, {ID: "a4", Name: "李明"}}
+	tests := []struct {
+		content string
+		want    []Mention
+	}{
+		{"hello everyone", nil},
+		{"@bob what do you think?", []Mention{{AgentID: "a3", Name: "Bob", Offset: 0, Length: 4}}},
+		{"ask @Ann Lee, not @Ann", []Mention{{AgentID: "a2", Name: "Ann Lee", Offset: 4, Length: 8}, {AgentID: "a1", Name: "Ann", Offset: 18, Length: 4}}},
+		{"mail bob@ann.com or @Bobby", nil},
+		{"你好@李明你觉得呢", []Mention{{AgentID: "a4", Name: "李明", Offset: 2, Length: 3}}},
+	}
+	for _, tt := range tests {
+		got := ParseMentions(tt.content, agents)
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.content, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: expected %v, got %v", tt.content, tt.want, got)
+			}
+		}
+	}
+
+	m := Message{Mentions: ParseMentions("@Bob @Ann @bob", agents)}
+	if ids := m.Mentioned(); len(ids) != 2 || ids[0] != "a3" || ids[1] != "a1" {
+		t.Fatalf("expected Bob then Ann, got %v", ids)
+	}
+}
@@ -0,0 +1,52 @@
+package agent
+
+import "time"
+
+// Sender types of a Message.
+const (
+	SenderUser   = "user"
+	SenderAgent  = "agent"
+	SenderSystem = "system"
+)
+
+// Message is one message in a conversation, attributed to its sender.
+type Message struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	SenderType     string                 `json:"sender_type"`
+	SenderID       string                 `json:"sender_id"`
+	SenderName     string                 `json:"sender_name,omitempty"`
+	Content        string                 `json:"content"`
+	CreatedAt      time.Time              `json:"created_at"`
+	ReplyTo        string                 `json:"reply_to,omitempty"`  // id of the message this one answers
+	ThreadID       string                 `json:"thread_id,omitempty"` // id of the first message of the reply chain; empty outside threads
+	Mentions       []Mention              `json:"mentions,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Speaker returns how the sender is shown in transcripts: its name, falling back to its id.
+func (m *Message) Speaker() string {
+	if m.SenderName != "" {
+		return m.SenderName
+	}
+	if m.SenderID != "" {
+		return m.SenderID
+	}
+	return m.SenderType
+}
+
+// LastMessage returns the most recent message of the conversation, or nil when it is empty.
+func (s *ConversationState) LastMessage() *Message {
+	if len(s.Messages) == 0 {
+		return nil
+	}
+	return &s.Messages[len(s.Messages)-1]
+}
+
+// lastContent returns the content of the most recent message, or "".
+func (s *ConversationState) lastContent() string {
+	if m := s.LastMessage(); m != nil {
+		return m.Content
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BehaviorProfile keys used to select and configure an agent's decider.
+const (
+	ProfileDeciderKey       = "decider"
+	ProfileDeciderConfigKey = "decider_config"
+)
+
+// DefaultDeciderName is used when an agent's profile does not name a decider.
+const DefaultDeciderName = "simple"
+
+// DeciderFactory builds a Decider from per-agent configuration.
+type DeciderFactory func(cfg map[string]interface{}) (Decider, error)
+
+// Registry maps decider names to factories and caches the built deciders
+// so agents sharing a configuration share an instance.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]DeciderFactory
+	cache     map[string]Decider
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]DeciderFactory),
+		cache:     make(map[string]Decider),
+	}
+}
+
+// Register adds or replaces the factory for name.
+func (r *Registry) Register(name string, f DeciderFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = f
+	// drop cached instances built by a previous factory of the same name
+	for k := range r.cache {
+		if strings.HasPrefix(k, name+"|") {
+			delete(r.cache, k)
+		}
+	}
+}
+
+// Names returns the registered decider names in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.factories))
+	for n := range r.factories {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// New builds (or returns the cached) decider registered as name for cfg.
+func (r *Registry) New(name string, cfg map[string]interface{}) (Decider, error) {
+	cfgBytes, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("decider %q: invalid config: %w", name, err)
+	}
+	key := name + "|" + string(cfgBytes)
+	r.mu.RLock()
+	d, ok := r.cache[key]
+	f, known := r.factories[name]
+	r.mu.RUnlock()
+	if ok {
+		return d, nil
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown decider %q", name)
+	}
+	d, err = f(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("decider %q: %w", name, err)
+	}
+	r.mu.Lock()
+	r.cache[key] = d
+	r.mu.Unlock()
+	return d, nil
+}
+
+// ForAgent resolves the decider named by the agent's BehaviorProfile.
+func (r *Registry) ForAgent(a *Agent) (Decider, error) {
+	name := DefaultDeciderName
+	var cfg map[string]interface{}
+	if a != nil && a.BehaviorProfile != nil {
+		if n, ok := a.BehaviorProfile[ProfileDeciderKey].(string); ok && n != "" {
+			name = n
+		}
+		if c, ok := a.BehaviorProfile[ProfileDeciderConfigKey].(map[string]interface{}); ok {
+			cfg = c
+		}
+	}
+	return r.New(name, cfg)
+}
+
+// DefaultRegistry holds the built-in deciders: "simple", "llm", "scripted" and "rule".
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.Register("simple", func(map[string]interface{}) (Decider, error) {
+		return &SimpleDecider{}, nil
+	})
+	DefaultRegistry.Register("llm", newLLMDeciderFromConfig)
+	DefaultRegistry.Register("scripted", newScriptedDeciderFromConfig)
+	DefaultRegistry.Register("rule", newRuleDeciderFromConfig)
+}
+
+// Register adds a decider factory to the DefaultRegistry.
+func Register(name string, f DeciderFactory) {
+	DefaultRegistry.Register(name, f)
+}
+
+// DeciderFor resolves an agent's decider from the DefaultRegistry.
+func DeciderFor(a *Agent) (Decider, error) {
+	return DefaultRegistry.ForAgent(a)
+}
+
+// decodeConfig converts a loosely typed config map into out via JSON.
+func decodeConfig(cfg map[string]interface{}, out interface{}) error {
+	if cfg == nil {
+		return nil
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// newLLMDeciderFromConfig reads model, base_url, temperature, max_tokens and
+// api_key_env (default OPENAI_API_KEY). base_url defaults to OPENAI_BASE_URL.
+func newLLMDeciderFromConfig(cfg map[string]interface{}) (Decider, error) {
+	var c struct {
+		Model       string  `json:"model"`
+		BaseURL     string  `json:"base_url"`
+		Temperature float32 `json:"temperature"`
+		MaxTokens   int     `json:"max_tokens"`
+		APIKeyEnv   string  `json:"api_key_env"`
+	}
+	if err := decodeConfig(cfg, &c); err != nil {
+		return nil, err
+	}
+	if c.APIKeyEnv == "" {
+		c.APIKeyEnv = "OPENAI_API_KEY"
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if c.Model == "" {
+		c.Model = os.Getenv("OPENAI_CHAT_MODEL")
+	}
+	return NewLLMDecider(NewOpenAIProvider(OpenAIConfig{
+		APIKey:      os.Getenv(c.APIKeyEnv),
+		BaseURL:     c.BaseURL,
+		Model:       c.Model,
+		Temperature: c.Temperature,
+		MaxTokens:   c.MaxTokens,
+	})), nil
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegistryResolvesDeciderFromProfile(t *testing.T) {
+	a := &Agent{ID: "a1", Name: "Bot", BehaviorProfile: map[string]interface{}{
+		"decider": "scripted",
+		"decider_config": map[string]interface{}{
+			"lines": []interface{}{"first", "second from {name}"},
+		},
+	}}
+	dec, err := DeciderFor(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &ConversationState{ConversationID: "c1"}
+	for _, want := range []string{"first", "second from Bot"} {
+		act, err := dec.DecideAction(context.Background(), a, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act.Payload != want {
+			t.Fatalf("expected %q, got %q", want, act.Payload)
+		}
+	}
+	if _, err := dec.DecideAction(context.Background(), a, state); err == nil {
+		t.Fatal("expected exhausted script error")
+	}
+}
+
+func TestRegistryDefaultsAndUnknown(t *testing.T) {
+	dec, err := DeciderFor(&Agent{ID: "a1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dec.(*SimpleDecider); !ok {
+		t.Fatalf("expected SimpleDecider, got %T", dec)
+	}
+	if _, err := DeciderFor(&Agent{BehaviorProfile: map[string]interface{}{"decider": "nope"}}); err == nil {
+		t.Fatal("expected error for unknown decider")
+	}
+}
+
+func TestRuleDeciderMatchesKeywords(t *testing.T) {
+	r := NewRegistry()
+	r.Register("rule", newRuleDeciderFromConfig)
+	dec, err := r.New("rule", map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"keywords": []interface{}{"why"}, "type": "challenge", "reply": "Why not?"},
+		},
+		"fallback": "none",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{ID: "a1", Name: "Bot"}
+	act, err := dec.DecideAction(context.Background(), a, &ConversationState{Messages: []Message{{Content: "But WHY?"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Type != "challenge" || act.Payload != "Why not?" {
+		t.Fatalf("unexpected action %+v", act)
+	}
+	if _, err := dec.DecideAction(context.Background(), a, &ConversationState{Messages: []Message{{Content: "ok"}}}); err == nil {
+		t.Fatal("expected no-match error without fallback")
+	}
+}
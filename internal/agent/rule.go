@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Rule replies with Reply when the last message contains any of Keywords.
+type Rule struct {
+	Keywords []string `json:"keywords"`
+	Type     string   `json:"type"`
+	Reply    string   `json:"reply"`
+}
+
+// RuleDecider matches the last message against keyword rules in order.
+// Messages no rule matches are handled by Fallback, or skipped when it is nil.
+type RuleDecider struct {
+	Rules    []Rule
+	Fallback Decider
+}
+
+func (r *RuleDecider) DecideAction(ctx context.Context, a *Agent, state *ConversationState) (*Action, error) {
+	if a == nil {
+		return nil, errors.New("nil agent")
+	}
+	if rule := r.match(state); rule != nil {
+		return &Action{
+			Type:    normalizeActionType(rule.Type),
+			Payload: expandTemplate(rule.Reply, a, state),
+		}, nil
+	}
+	if r.Fallback != nil {
+		return r.Fallback.DecideAction(ctx, a, state)
+	}
+	return nil, errors.New("no rule matched")
+}
+
+// match returns the first rule with a keyword in the last message, or nil.
+func (r *RuleDecider) match(state *ConversationState) *Rule {
+	if len(state.Messages) == 0 {
+		return nil
+	}
+	last := strings.ToLower(state.lastContent())
+	for i, rule := range r.Rules {
+		for _, kw := range rule.Keywords {
+			if kw != "" && strings.Contains(last, strings.ToLower(kw)) {
+				return &r.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// newRuleDeciderFromConfig reads "rules" and an optional "fallback" decider
+// name ("simple" unless set to "none").
+func newRuleDeciderFromConfig(cfg map[string]interface{}) (Decider, error) {
+	var c struct {
+		Rules    []Rule `json:"rules"`
+		Fallback string `json:"fallback"`
+	}
+	if err := decodeConfig(cfg, &c); err != nil {
+		return nil, err
+	}
+	if len(c.Rules) == 0 {
+		return nil, errors.New("rules is required")
+	}
+	d := &RuleDecider{Rules: c.Rules}
+	switch c.Fallback {
+	case "none":
+	case "", DefaultDeciderName:
+		d.Fallback = &SimpleDecider{}
+	default:
+		fb, err := DefaultRegistry.New(c.Fallback, nil)
+		if err != nil {
+			return nil, err
+		}
+		d.Fallback = fb
+	}
+	return d, nil
+}
+
+// Bid is 1 when a rule matches the last message; otherwise the fallback's bid, or 0.
+func (r *RuleDecider) Bid(ctx context.Context, a *Agent, state *ConversationState) (float64, error) {
+	if r.match(state) != nil {
+		return 1, nil
+	}
+	if b, ok := r.Fallback.(Bidder); ok {
+		return b.Bid(ctx, a, state)
+	}
+	return 0, nil
+}
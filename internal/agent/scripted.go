@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ScriptedDecider speaks a fixed list of lines in order, one per turn,
+// tracking progress per agent and conversation.
+type ScriptedDecider struct {
+	Lines []string
+	Type  string // action type for every line, defaults to "speak"
+	Loop  bool   // restart from the first line when the script is exhausted
+
+	mu  sync.Mutex
+	pos map[string]int
+}
+
+func (s *ScriptedDecider) DecideAction(ctx context.Context, a *Agent, state *ConversationState) (*Action, error) {
+	if a == nil {
+		return nil, errors.New("nil agent")
+	}
+	if len(s.Lines) == 0 {
+		return nil, errors.New("scripted decider has no lines")
+	}
+	key := string(a.ID) + "|" + state.ConversationID
+	s.mu.Lock()
+	if s.pos == nil {
+		s.pos = make(map[string]int)
+	}
+	i := s.pos[key]
+	if i >= len(s.Lines) {
+		if !s.Loop {
+			s.mu.Unlock()
+			return nil, errors.New("script exhausted")
+		}
+		i = 0
+	}
+	s.pos[key] = i + 1
+	s.mu.Unlock()
+
+	typ := s.Type
+	if typ == "" {
+		typ = "speak"
+	}
+	return &Action{Type: typ, Payload: expandTemplate(s.Lines[i], a, state)}, nil
+}
+
+func newScriptedDeciderFromConfig(cfg map[string]interface{}) (Decider, error) {
+	var c struct {
+		Lines []string `json:"lines"`
+		Type  string   `json:"type"`
+		Loop  bool     `json:"loop"`
+	}
+	if err := decodeConfig(cfg, &c); err != nil {
+		return nil, err
+	}
+	if len(c.Lines) == 0 {
+		return nil, errors.New("lines is required")
+	}
+	return &ScriptedDecider{Lines: c.Lines, Type: normalizeActionType(c.Type), Loop: c.Loop}, nil
+}
+
+// expandTemplate substitutes {name}, {persona} and {last} in a canned line.
+func expandTemplate(tmpl string, a *Agent, state *ConversationState) string {
+	return strings.NewReplacer("{name}", a.Name, "{persona}", a.Persona, "{last}", state.lastContent()).Replace(tmpl)
+}
+
+// Bid is 1 while the script has lines left for this agent and conversation, 0 after.
+func (s *ScriptedDecider) Bid(ctx context.Context, a *Agent, state *ConversationState) (float64, error) {
+	if s.Loop {
+		return 1, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pos[string(a.ID)+"|"+state.ConversationID] < len(s.Lines) {
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -64,6 +64,24 @@ func parseAndValidate(tokenStr string) (map[string]interface{}, error) {
 
 // RequireAdmin is a middleware that enforces the token has role == "admin".
 func RequireAdmin(next http.Handler) http.Handler {
+	return requireToken(next, func(claims map[string]interface{}) bool {
+		role, _ := claims["role"].(string)
+		return role == "admin"
+	})
+}
+
+// RequireUser is a middleware that admits any valid token naming a subject,
+// whatever its role. Handlers read who is calling with Subject.
+func RequireUser(next http.Handler) http.Handler {
+	return requireToken(next, func(claims map[string]interface{}) bool {
+		sub, _ := claims["sub"].(string)
+		return sub != ""
+	})
+}
+
+// requireToken checks the bearer token of a request, lets it through when
+// allowed accepts its claims and attaches the claims to the request context.
+func requireToken(next http.Handler, allowed func(claims map[string]interface{}) bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
 		if auth == "" {
@@ -80,8 +98,7 @@ func RequireAdmin(next http.Handler) http.Handler {
 			http.Error(w, "invalid token", http.StatusUnauthorized)
 			return
 		}
-		role, _ := claims["role"].(string)
-		if role != "admin" {
+		if !allowed(claims) {
 			http.Error(w, "forbidden", http.StatusForbidden)
 			return
 		}
@@ -91,6 +108,41 @@ func RequireAdmin(next http.Handler) http.Handler {
 	})
 }
 
+// VerifyToken validates a signed token and returns its subject. Tokens
+// without a subject are rejected.
+func VerifyToken(token string) (string, error) {
+	claims, err := parseAndValidate(token)
+	if err != nil {
+		return "", err
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return "", jwt.ErrTokenInvalidClaims
+	}
+	return sub, nil
+}
+
+// Subject returns the subject of the token that authenticated r, or "" when
+// r did not pass RequireUser or RequireAdmin.
+func Subject(r *http.Request) string {
+	claims, ok := ExtractPrincipal(r)
+	if !ok {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// IsAdmin reports whether the token that authenticated r has role == "admin".
+func IsAdmin(r *http.Request) bool {
+	claims, ok := ExtractPrincipal(r)
+	if !ok {
+		return false
+	}
+	role, _ := claims["role"].(string)
+	return role == "admin"
+}
+
 // ExtractPrincipal returns token claims from request context if present.
 func ExtractPrincipal(r *http.Request) (map[string]interface{}, bool) {
 	v := r.Context().Value(ContextPrincipal)
@@ -80,3 +80,40 @@ func TestRequireAdmin_MissingOrInvalidToken(t *testing.T) {
 	}
 }
 
+
+func TestRequireUser_AttachesSubject(t *testing.T) {
+	os.Setenv("AUTH_JWT_SECRET", "test-secret")
+	defer os.Unsetenv("AUTH_JWT_SECRET")
+	token, err := GenerateToken("carol", "user", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken error: %v", err)
+	}
+	var subject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = Subject(r)
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	RequireUser(next).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || subject != "carol" {
+		t.Fatalf("expected 200 for carol, got %d and subject %q", rr.Code, subject)
+	}
+
+	// a token without a subject names nobody
+	anonymous, _ := GenerateToken("", "user", time.Hour)
+	req = httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+anonymous)
+	rr = httptest.NewRecorder()
+	RequireUser(next).ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a subject, got %d", rr.Code)
+	}
+	if _, err := VerifyToken(anonymous); err == nil {
+		t.Fatal("expected VerifyToken to reject a token without a subject")
+	}
+	if sub, err := VerifyToken(token); err != nil || sub != "carol" {
+		t.Fatalf("VerifyToken: %q, %v", sub, err)
+	}
+}
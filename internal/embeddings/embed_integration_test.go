@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/yourname/multiagent-social/internal/agent"
 	"github.com/yourname/multiagent-social/internal/persistence"
 )
 
@@ -25,10 +26,11 @@ func TestEmbeddingsIntegration(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create conv: %v", err)
 	}
-	msgID, err := store.InsertMessage(context.Background(), convID, "user", "it", "hello")
-	if err != nil {
+	msg := &agent.Message{ConversationID: convID, SenderType: agent.SenderUser, SenderID: "it", Content: "hello"}
+	if err := store.InsertMessage(context.Background(), msg); err != nil {
 		t.Fatalf("insert msg: %v", err)
 	}
+	msgID := msg.ID
 	vec := Vector{0.1, 0.2, 0.3, 0.4}
 	if err := SaveEmbedding(context.Background(), store, convID, msgID, vec); err != nil {
 		t.Fatalf("save embed: %v", err)
@@ -0,0 +1,167 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDimensions matches OpenAI's text-embedding-ada-002 and the vectors stored so far.
+const DefaultDimensions = 1536
+
+// Embedder turns texts into embedding vectors, one per input and in the same order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([]Vector, error)
+	// Dimensions is the length of the vectors Embed returns.
+	Dimensions() int
+}
+
+// EmbedOne embeds a single text with e.
+func EmbedOne(ctx context.Context, e Embedder, text string) (Vector, error) {
+	vecs, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(vecs))
+	}
+	return vecs[0], nil
+}
+
+// OpenAIConfig configures an OpenAIEmbedder. Dimensions is sent to models that
+// support shortened embeddings (text-embedding-3-*); leave it 0 for the model's default.
+type OpenAIConfig struct {
+	APIKey     string
+	BaseURL    string // default https://api.openai.com/v1
+	Model      string // default text-embedding-ada-002
+	Dimensions int
+}
+
+// OpenAIEmbedder calls an OpenAI-compatible /embeddings endpoint. It speaks HTTP
+// directly because the pinned go-openai client only accepts its built-in model enum.
+type OpenAIEmbedder struct {
+	cfg    OpenAIConfig
+	client *http.Client
+}
+
+// NewOpenAIEmbedder builds an embedder from cfg, filling in defaults.
+func NewOpenAIEmbedder(cfg OpenAIConfig) *OpenAIEmbedder {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.openai.com/v1"
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Model == "" {
+		cfg.Model = "text-embedding-ada-002"
+	}
+	return &OpenAIEmbedder{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Dimensions returns the configured size, or the model's known default.
+func (e *OpenAIEmbedder) Dimensions() int {
+	if e.cfg.Dimensions > 0 {
+		return e.cfg.Dimensions
+	}
+	if e.cfg.Model == "text-embedding-3-large" {
+		return 3072
+	}
+	return DefaultDimensions
+}
+
+// Embed requests embeddings for texts in one API call.
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	if e.cfg.APIKey == "" {
+		return nil, errors.New("OPENAI_API_KEY not set")
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body := map[string]interface{}{"model": e.cfg.Model, "input": texts}
+	if e.cfg.Dimensions > 0 {
+		body["dimensions"] = e.cfg.Dimensions
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.BaseURL+"/embeddings", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.cfg.APIKey)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("embeddings: decode response: %w", err)
+	}
+	if len(out.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings: expected %d embeddings, got %d", len(texts), len(out.Data))
+	}
+	vecs := make([]Vector, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vecs) {
+			return nil, fmt.Errorf("embeddings: index %d out of range", d.Index)
+		}
+		vec := make(Vector, len(d.Embedding))
+		for i, v := range d.Embedding {
+			vec[i] = float32(v)
+		}
+		vecs[d.Index] = vec
+	}
+	return vecs, nil
+}
+
+// NewEmbedderFromEnv picks the embedder from EMBEDDINGS_PROVIDER: "openai", "hash",
+// or empty to use OpenAI when OPENAI_API_KEY is set and the offline hash embedder
+// otherwise. OPENAI_EMBEDDING_MODEL, OPENAI_BASE_URL and EMBEDDING_DIMENSIONS
+// configure either provider where they apply.
+func NewEmbedderFromEnv() (Embedder, error) {
+	dims := 0
+	if v := os.Getenv("EMBEDDING_DIMENSIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid EMBEDDING_DIMENSIONS %q", v)
+		}
+		dims = n
+	}
+	provider := os.Getenv("EMBEDDINGS_PROVIDER")
+	if provider == "" {
+		provider = "hash"
+		if os.Getenv("OPENAI_API_KEY") != "" {
+			provider = "openai"
+		}
+	}
+	switch provider {
+	case "openai":
+		return NewOpenAIEmbedder(OpenAIConfig{
+			APIKey:     os.Getenv("OPENAI_API_KEY"),
+			BaseURL:    os.Getenv("OPENAI_BASE_URL"),
+			Model:      os.Getenv("OPENAI_EMBEDDING_MODEL"),
+			Dimensions: dims,
+		}), nil
+	case "hash":
+		return NewHashEmbedder(dims), nil
+	}
+	return nil, fmt.Errorf("unknown EMBEDDINGS_PROVIDER %q", provider)
+}
@@ -0,0 +1,96 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func cosine(a, b Vector) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestHashEmbedderDeterministicAndSimilar(t *testing.T) {
+	e := NewHashEmbedder(256)
+	ctx := context.Background()
+	vecs, err := e.Embed(ctx, []string{"I love jazz music", "jazz music is lovely", "quarterly tax filing deadlines"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vecs) != 3 || len(vecs[0]) != 256 || e.Dimensions() != 256 {
+		t.Fatalf("unexpected shape: %d vectors of %d", len(vecs), len(vecs[0]))
+	}
+	again, _ := EmbedOne(ctx, e, "I love jazz music")
+	for i := range again {
+		if again[i] != vecs[0][i] {
+			t.Fatal("expected identical vectors for identical text")
+		}
+	}
+	if related, unrelated := cosine(vecs[0], vecs[1]), cosine(vecs[0], vecs[2]); related <= unrelated {
+		t.Fatalf("expected related texts to be closer: %.3f <= %.3f", related, unrelated)
+	}
+	if NewHashEmbedder(0).Dimensions() != DefaultDimensions {
+		t.Fatal("expected default dimensions")
+	}
+}
+
+func TestOpenAIEmbedderRequest(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer k" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		// answer out of order; the embedder must place vectors by index
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": []map[string]interface{}{
+			{"index": 1, "embedding": []float64{0, 1}},
+			{"index": 0, "embedding": []float64{1, 0}},
+		}})
+	}))
+	defer srv.Close()
+
+	e := NewOpenAIEmbedder(OpenAIConfig{APIKey: "k", BaseURL: srv.URL + "/v1/", Model: "text-embedding-3-small", Dimensions: 2})
+	vecs, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vecs[0][0] != 1 || vecs[1][1] != 1 {
+		t.Fatalf("vectors out of order: %v", vecs)
+	}
+	if got["model"] != "text-embedding-3-small" || got["dimensions"] != float64(2) || e.Dimensions() != 2 {
+		t.Fatalf("unexpected request %v", got)
+	}
+}
+
+func TestNewEmbedderFromEnv(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("EMBEDDINGS_PROVIDER", "")
+	t.Setenv("EMBEDDING_DIMENSIONS", "128")
+	e, err := NewEmbedderFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.(*HashEmbedder); !ok || e.Dimensions() != 128 {
+		t.Fatalf("expected 128-dim hash embedder without an API key, got %T", e)
+	}
+	t.Setenv("OPENAI_API_KEY", "k")
+	if e, _ := NewEmbedderFromEnv(); e == nil {
+		t.Fatal("expected an embedder")
+	} else if _, ok := e.(*OpenAIEmbedder); !ok {
+		t.Fatalf("expected OpenAI embedder with an API key, got %T", e)
+	}
+	t.Setenv("EMBEDDINGS_PROVIDER", "nope")
+	if _, err := NewEmbedderFromEnv(); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
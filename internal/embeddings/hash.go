@@ -0,0 +1,76 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// HashEmbedder is a deterministic, offline Embedder for development and CI. It
+// hashes word unigrams and character trigrams into a fixed number of buckets
+// (the "hashing trick") and L2-normalizes the result, so texts sharing words or
+// word fragments get a high cosine similarity. It needs no network or API key.
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder returns a hash embedder producing dims-long vectors
+// (DefaultDimensions when dims <= 0).
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = DefaultDimensions
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+func (e *HashEmbedder) Dimensions() int {
+	return e.dims
+}
+
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	out := make([]Vector, len(texts))
+	for i, t := range texts {
+		out[i] = e.embed(t)
+	}
+	return out, nil
+}
+
+func (e *HashEmbedder) embed(text string) Vector {
+	vec := make(Vector, e.dims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, w := range words {
+		e.add(vec, "w:"+w, 1)
+		// trigrams over the padded word also cover scripts written without spaces
+		runes := []rune("^" + w + "$")
+		for i := 0; i+3 <= len(runes); i++ {
+			e.add(vec, "c:"+string(runes[i:i+3]), 0.5)
+		}
+	}
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// add hashes feature into a bucket; a second hash bit picks the sign so
+// collisions tend to cancel out rather than accumulate.
+func (e *HashEmbedder) add(vec Vector, feature string, weight float32) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vec[sum%uint64(e.dims)] += weight
+}
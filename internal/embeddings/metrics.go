@@ -0,0 +1,43 @@
+package embeddings
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	jobsQueued = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "embedding_jobs_queued_total",
+		Help: "Messages accepted into the embedding queue.",
+	})
+	jobsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "embedding_jobs_dropped_total",
+		Help: "Messages not embedded because the queue was full or closed.",
+	})
+	jobsSucceeded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "embedding_jobs_succeeded_total",
+		Help: "Messages embedded and stored.",
+	})
+	jobsFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "embedding_jobs_failed_total",
+		Help: "Messages whose embedding failed after all retries or could not be stored.",
+	})
+	requestRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "embedding_request_retries_total",
+		Help: "Embedding provider requests retried after an error.",
+	})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "embedding_request_duration_seconds",
+		Help:    "Latency of embedding provider requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"result"})
+	batchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "embedding_batch_size",
+		Help:    "Messages per embedding provider request.",
+		Buckets: []float64{1, 2, 4, 8, 16, 32, 64},
+	})
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "embedding_queue_depth",
+		Help: "Messages waiting in the embedding queue.",
+	})
+)
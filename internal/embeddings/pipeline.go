@@ -0,0 +1,237 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var errShortBatch = errors.New("embedder returned fewer vectors than texts")
+
+// Job asks the pipeline to embed and store one message.
+type Job struct {
+	ConversationID string
+	MessageID      string
+	Text           string
+}
+
+// Saver stores computed embeddings; persistence.Store satisfies it.
+type Saver interface {
+	SaveEmbedding(ctx context.Context, conversationID, messageID string, vec []float32) error
+}
+
+// PipelineConfig tunes a Pipeline; zero fields take the defaults noted.
+type PipelineConfig struct {
+	QueueSize    int           // jobs buffered before Enqueue drops (1024)
+	Workers      int           // concurrent provider requests (2)
+	BatchSize    int           // jobs per provider request (16)
+	BatchWait    time.Duration // how long a worker waits to fill a batch (50ms)
+	MaxRetries   int           // retries per batch after the first attempt (3; negative disables)
+	RetryBackoff time.Duration // first retry delay, doubled per retry (200ms)
+	MaxBackoff   time.Duration // cap on the retry delay (5s)
+}
+
+func (c *PipelineConfig) withDefaults() PipelineConfig {
+	out := *c
+	if out.QueueSize <= 0 {
+		out.QueueSize = 1024
+	}
+	if out.Workers <= 0 {
+		out.Workers = 2
+	}
+	if out.BatchSize <= 0 {
+		out.BatchSize = 16
+	}
+	if out.BatchWait <= 0 {
+		out.BatchWait = 50 * time.Millisecond
+	}
+	if out.MaxRetries < 0 {
+		out.MaxRetries = 0
+	} else if out.MaxRetries == 0 {
+		out.MaxRetries = 3
+	}
+	if out.RetryBackoff <= 0 {
+		out.RetryBackoff = 200 * time.Millisecond
+	}
+	if out.MaxBackoff <= 0 {
+		out.MaxBackoff = 5 * time.Second
+	}
+	return out
+}
+
+// Pipeline embeds messages in the background with a fixed pool of workers fed
+// by a bounded queue. Workers batch queued jobs into one provider request and
+// retry failed requests with exponential backoff. Progress is exported as
+// Prometheus metrics.
+type Pipeline struct {
+	embedder Embedder
+	saver    Saver
+	cfg      PipelineConfig
+
+	mu     sync.RWMutex // guards closed against sends on queue
+	closed bool
+	queue  chan Job
+
+	ctx    context.Context // cancelled when Close gives up waiting
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPipeline starts a pipeline embedding with e and storing into s.
+func NewPipeline(e Embedder, s Saver, cfg PipelineConfig) *Pipeline {
+	cfg = cfg.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pipeline{
+		embedder: e,
+		saver:    s,
+		cfg:      cfg,
+		queue:    make(chan Job, cfg.QueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Enqueue queues j without blocking. It reports false, and counts the job as
+// dropped, when the queue is full or the pipeline is closed.
+func (p *Pipeline) Enqueue(j Job) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		jobsDropped.Inc()
+		return false
+	}
+	select {
+	case p.queue <- j:
+		jobsQueued.Inc()
+		queueDepth.Inc()
+		return true
+	default:
+		jobsDropped.Inc()
+		log.Printf("embeddings: queue full; dropping message %s", j.MessageID)
+		return false
+	}
+}
+
+// Close stops accepting jobs and waits for queued ones to finish. If ctx ends
+// first, in-flight requests are cancelled and ctx's error is returned.
+func (p *Pipeline) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// work processes batches until the queue is closed and drained.
+func (p *Pipeline) work() {
+	defer p.wg.Done()
+	for {
+		batch, ok := p.nextBatch()
+		if len(batch) > 0 {
+			p.process(batch)
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// nextBatch blocks for one job, then collects more for up to BatchWait.
+// ok is false once the queue is closed.
+func (p *Pipeline) nextBatch() (batch []Job, ok bool) {
+	j, ok := <-p.queue
+	if !ok {
+		return nil, false
+	}
+	queueDepth.Dec()
+	batch = append(batch, j)
+	timer := time.NewTimer(p.cfg.BatchWait)
+	defer timer.Stop()
+	for len(batch) < p.cfg.BatchSize {
+		select {
+		case j, ok := <-p.queue:
+			if !ok {
+				return batch, false
+			}
+			queueDepth.Dec()
+			batch = append(batch, j)
+		case <-timer.C:
+			return batch, true
+		}
+	}
+	return batch, true
+}
+
+// process embeds a batch with retries and stores the vectors.
+func (p *Pipeline) process(batch []Job) {
+	texts := make([]string, len(batch))
+	for i, j := range batch {
+		texts[i] = j.Text
+	}
+	batchSize.Observe(float64(len(batch)))
+	vecs, err := p.embedWithRetry(texts)
+	if err != nil {
+		jobsFailed.Add(float64(len(batch)))
+		log.Printf("embeddings: giving up on %d messages: %v", len(batch), err)
+		return
+	}
+	for i, j := range batch {
+		if err := p.saver.SaveEmbedding(p.ctx, j.ConversationID, j.MessageID, vecs[i]); err != nil {
+			jobsFailed.Inc()
+			log.Printf("embeddings: save message %s: %v", j.MessageID, err)
+			continue
+		}
+		jobsSucceeded.Inc()
+	}
+}
+
+func (p *Pipeline) embedWithRetry(texts []string) ([]Vector, error) {
+	backoff := p.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		vecs, err := p.embedder.Embed(p.ctx, texts)
+		if err == nil && len(vecs) != len(texts) {
+			err = errShortBatch
+		}
+		if err == nil {
+			requestDuration.WithLabelValues("ok").Observe(time.Since(start).Seconds())
+			return vecs, nil
+		}
+		requestDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+		if attempt >= p.cfg.MaxRetries {
+			return nil, err
+		}
+		requestRetries.Inc()
+		select {
+		case <-p.ctx.Done():
+			return nil, p.ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > p.cfg.MaxBackoff {
+			backoff = p.cfg.MaxBackoff
+		}
+	}
+}
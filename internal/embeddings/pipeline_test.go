@@ -0,0 +1,100 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyEmbedder fails its first failures calls and records batch sizes.
+type flakyEmbedder struct {
+	mu       sync.Mutex
+	failures int
+	batches  []int
+	block    chan struct{} // when set, Embed waits on it
+}
+
+func (e *flakyEmbedder) Dimensions() int { return 2 }
+
+func (e *flakyEmbedder) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	if e.block != nil {
+		<-e.block
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, len(texts))
+	if e.failures > 0 {
+		e.failures--
+		return nil, errors.New("provider unavailable")
+	}
+	out := make([]Vector, len(texts))
+	for i := range out {
+		out[i] = Vector{1, 0}
+	}
+	return out, nil
+}
+
+type memorySaver struct {
+	mu    sync.Mutex
+	saved map[string]Vector
+}
+
+func (s *memorySaver) SaveEmbedding(ctx context.Context, conversationID, messageID string, vec []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.saved == nil {
+		s.saved = make(map[string]Vector)
+	}
+	s.saved[messageID] = vec
+	return nil
+}
+
+func TestPipelineBatchesAndRetries(t *testing.T) {
+	e := &flakyEmbedder{failures: 2}
+	s := &memorySaver{}
+	p := NewPipeline(e, s, PipelineConfig{Workers: 1, BatchSize: 4, BatchWait: time.Second, RetryBackoff: time.Millisecond})
+	for _, id := range []string{"m1", "m2", "m3", "m4"} {
+		if !p.Enqueue(Job{ConversationID: "c", MessageID: id, Text: id}) {
+			t.Fatalf("enqueue %s rejected", id)
+		}
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.saved) != 4 {
+		t.Fatalf("expected 4 stored embeddings, got %d", len(s.saved))
+	}
+	// two failed attempts and one success, each carrying the whole batch
+	if len(e.batches) != 3 || e.batches[0] != 4 || e.batches[2] != 4 {
+		t.Fatalf("expected 3 requests of 4 texts, got %v", e.batches)
+	}
+	if p.Enqueue(Job{MessageID: "late"}) {
+		t.Fatal("expected enqueue after Close to be rejected")
+	}
+}
+
+func TestPipelineGivesUpAndDropsWhenFull(t *testing.T) {
+	e := &flakyEmbedder{failures: 100, block: make(chan struct{})}
+	s := &memorySaver{}
+	p := NewPipeline(e, s, PipelineConfig{Workers: 1, QueueSize: 1, BatchSize: 1, MaxRetries: -1})
+	p.Enqueue(Job{MessageID: "m1"}) // taken by the blocked worker
+	deadline := time.Now().Add(time.Second)
+	for !p.Enqueue(Job{MessageID: "m2"}) { // wait until m1 has left the queue
+		if time.Now().After(deadline) {
+			t.Fatal("worker never picked up the first job")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if p.Enqueue(Job{MessageID: "m3"}) {
+		t.Fatal("expected enqueue to fail while the queue is full")
+	}
+	close(e.block)
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.saved) != 0 || len(e.batches) != 2 {
+		t.Fatalf("expected one unretried attempt per job and nothing stored, got %v and %d saved", e.batches, len(s.saved))
+	}
+}
@@ -5,77 +5,30 @@ import (
 	"errors"
 	"os"
 
-	openai "github.com/sashabaranov/go-openai"
-
 	"github.com/yourname/multiagent-social/internal/persistence"
 )
 
 // Vector is a slice of float32 representing an embedding.
 type Vector = []float32
 
-// GenerateEmbedding calls OpenAI embeddings API and returns a vector.
+// GenerateEmbedding embeds text with OpenAI's default embedding model using
+// OPENAI_API_KEY. Prefer an Embedder, which is configurable and batches.
 func GenerateEmbedding(ctx context.Context, text string) (Vector, error) {
-	key := os.Getenv("OPENAI_API_KEY")
-	if key == "" {
-		return nil, errors.New("OPENAI_API_KEY not set")
-	}
-	client := openai.NewClient(key)
-	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-		Model: openai.AdaEmbeddingV2,
-		Input: []string{text},
-	})
-	if err != nil {
-		return nil, err
-	}
-	if len(resp.Data) == 0 {
-		return nil, errors.New("no embedding returned")
-	}
-	vec := make(Vector, len(resp.Data[0].Embedding))
-	for i, v := range resp.Data[0].Embedding {
-		vec[i] = float32(v)
-	}
-	return vec, nil
+	return EmbedOne(ctx, NewOpenAIEmbedder(OpenAIConfig{APIKey: os.Getenv("OPENAI_API_KEY")}), text)
 }
 
-// SaveEmbedding stores the vector into embeddings table using pgvector.
-func SaveEmbedding(ctx context.Context, store *persistence.PostgresStore, conversationID, messageID string, v Vector) error {
+// SaveEmbedding stores the vector for a message in the store.
+func SaveEmbedding(ctx context.Context, store persistence.Store, conversationID, messageID string, v Vector) error {
 	if store == nil {
 		return errors.New("nil store")
 	}
-	// store vector as native float64 array to avoid depending on pgvector types here
-	// convert []float32 -> []float64 for database driver
-	vec := make([]float64, len(v))
-	for i, f := range v {
-		vec[i] = float64(f)
-	}
-	_, err := store.Pool().Exec(ctx, "INSERT INTO embeddings (conversation_id, message_id, vector) VALUES ($1, $2, $3)", conversationID, messageID, vec)
-	return err
+	return store.SaveEmbedding(ctx, conversationID, messageID, v)
 }
 
-// QuerySimilarMessages returns up to k message IDs similar to the provided vector using pgvector distance operator.
-func QuerySimilarMessages(ctx context.Context, store *persistence.PostgresStore, vector Vector, k int) ([]string, error) {
+// QuerySimilarMessages returns up to k message IDs similar to the provided vector.
+func QuerySimilarMessages(ctx context.Context, store persistence.Store, vector Vector, k int) ([]string, error) {
 	if store == nil {
 		return nil, errors.New("nil store")
 	}
-	// convert to []float64 for query parameter
-	vec := make([]float64, len(vector))
-	for i, f := range vector {
-		vec[i] = float64(f)
-	}
-	// use pgvector '<->' distance operator if column is pgvector, otherwise works with double precision[]
-	rows, err := store.Pool().Query(ctx, "SELECT message_id FROM embeddings ORDER BY vector <-> $1 LIMIT $2", vec, k)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var out []string
-	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, err
-		}
-		out = append(out, id)
-	}
-	return out, nil
+	return store.QuerySimilarMessages(ctx, vector, k)
 }
-
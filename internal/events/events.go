@@ -0,0 +1,175 @@
+// Package events defines the typed envelope published on conversation channels
+// and delivered to WebSocket and SSE clients.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+)
+
+// SchemaVersion is bumped whenever the envelope or a payload changes incompatibly.
+const SchemaVersion = 1
+
+// Event types.
+const (
+	TypeConversationCreated = "conversation.created"
+	TypeConversationUpdated = "conversation.updated"
+	TypeConversationDeleted = "conversation.deleted"
+	TypeMessageCreated      = "message.created"
+	TypeParticipantJoined   = "participant.joined"
+	TypeParticipantLeft     = "participant.left"
+	TypeDebateStarted       = "debate.started"
+	TypeDebateRoundStarted  = "debate.round_started"
+	TypeDebateRoundEnded    = "debate.round_ended"
+	TypeDebatePaused        = "debate.paused"
+	TypeDebateResumed       = "debate.resumed"
+	TypeDebateEnded         = "debate.ended"
+	TypeDebateScored        = "debate.scored"
+	TypeDebateJudged        = "debate.judged"
+	TypePollCreated         = "poll.created"
+	TypePollVoted           = "poll.voted"
+	TypePollClosed          = "poll.closed"
+	TypeAutonomousStarted   = "autonomous.started"
+	TypeAutonomousPaused    = "autonomous.paused"
+	TypeAutonomousResumed   = "autonomous.resumed"
+	TypeAutonomousLeft      = "autonomous.left"
+	TypeAutonomousEnded     = "autonomous.ended"
+	TypePing                = "ping"
+	TypeTyping              = "typing"
+)
+
+// Sender types.
+const (
+	SenderUser   = "user"
+	SenderAgent  = "agent"
+	SenderSystem = "system"
+)
+
+// Sender identifies who caused an event.
+type Sender struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Event is the envelope for everything published on a conversation channel.
+// Seq is the event's position in the conversation's durable log, so clients can
+// order events, drop duplicates and resume after it; ephemeral events have Seq 0.
+type Event struct {
+	Type           string          `json:"event"`
+	Version        int             `json:"version"`
+	ConversationID string          `json:"conversation_id"`
+	MessageID      string          `json:"message_id,omitempty"`
+	Sender         *Sender         `json:"sender,omitempty"`
+	Timestamp      time.Time       `json:"ts"`
+	Seq            int64           `json:"seq,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+}
+
+// MessagePayload is the payload of message.created events.
+type MessagePayload struct {
+	Content  string          `json:"content"`
+	ReplyTo  string          `json:"reply_to,omitempty"`
+	ThreadID string          `json:"thread_id,omitempty"`
+	Mentions []agent.Mention `json:"mentions,omitempty"`
+}
+
+// ConversationPayload is the payload of conversation.created, conversation.updated
+// and conversation.deleted events.
+type ConversationPayload struct {
+	Title        string   `json:"title"`
+	Status       string   `json:"status,omitempty"`
+	Participants []string `json:"participants,omitempty"`
+}
+
+// ParticipantPayload is the payload of participant.joined and participant.left events.
+type ParticipantPayload struct {
+	AgentID string `json:"agent_id"`
+}
+
+// DebatePayload is the payload of debate.* events: the debate's state and
+// round when the event was raised.
+type DebatePayload struct {
+	DebateID     string            `json:"debate_id"`
+	Format       string            `json:"format,omitempty"`
+	State        string            `json:"state"`
+	Round        int               `json:"round"`
+	Rounds       int               `json:"rounds"`
+	Phase        string            `json:"phase,omitempty"`
+	Participants []string          `json:"participants,omitempty"` // debate.started only
+	Sides        map[string]string `json:"sides,omitempty"`        // debate.started only
+	Error        string            `json:"error,omitempty"`        // failed debates only
+	// Scores holds the round's scores on debate.scored and the totals on
+	// debate.judged, by debater id.
+	Scores      map[string]float64 `json:"scores,omitempty"`
+	WinnerID    string             `json:"winner_id,omitempty"`    // debate.judged only; empty on a draw
+	WinningSide string             `json:"winning_side,omitempty"` // debate.judged only
+}
+
+// PollPayload is the payload of poll.* events: the poll with its tally when
+// the event was raised. poll.voted is sent by the voter.
+type PollPayload struct {
+	PollID   string     `json:"poll_id"`
+	Question string     `json:"question"`
+	Options  []string   `json:"options"`
+	Counts   []int      `json:"counts"` // votes per option
+	Total    int        `json:"total"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	Closed   bool       `json:"closed"`
+	DebateID string     `json:"debate_id,omitempty"`
+	Stage    string     `json:"stage,omitempty"` // "pre" or "post" for a debate's audience votes
+}
+
+// AutonomousPayload is the payload of autonomous.* events.
+type AutonomousPayload struct {
+	RunID        string   `json:"run_id"`
+	State        string   `json:"state"`
+	Topic        string   `json:"topic,omitempty"`
+	Turns        int      `json:"turns"`
+	MaxTurns     int      `json:"max_turns"`
+	Participants []string `json:"participants,omitempty"` // autonomous.started only
+	AgentID      string   `json:"agent_id,omitempty"`     // autonomous.left only: who left
+	StopReason   string   `json:"stop_reason,omitempty"`  // autonomous.ended only
+	Error        string   `json:"error,omitempty"`        // failed runs only
+}
+
+// TypingPayload is the payload of typing events.
+type TypingPayload struct {
+	Typing bool `json:"typing"`
+}
+
+// New builds an event of type typ with a JSON-encoded payload, stamped with the
+// current schema version and time. Seq is assigned when the event is published.
+func New(typ, conversationID string, sender *Sender, payload interface{}) (*Event, error) {
+	e := &Event{
+		Type:           typ,
+		Version:        SchemaVersion,
+		ConversationID: conversationID,
+		Sender:         sender,
+		Timestamp:      time.Now().UTC(),
+	}
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s payload: %w", typ, err)
+		}
+		e.Payload = b
+	}
+	return e, nil
+}
+
+// Decode unmarshals the event payload into v.
+func (e *Event) Decode(v interface{}) error {
+	if len(e.Payload) == 0 {
+		return fmt.Errorf("%s event has no payload", e.Type)
+	}
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Channel returns the pubsub channel carrying a conversation's events.
+func Channel(conversationID string) string {
+	return "conversation:" + conversationID
+}
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventRoundTrip(t *testing.T) {
+	e, err := New(TypeMessageCreated, "c1", &Sender{Type: SenderAgent, ID: "a1", Name: "Alice"}, MessagePayload{Content: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Seq = 7
+	b, _ := json.Marshal(e)
+	var got Event
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != TypeMessageCreated || got.Version != SchemaVersion || got.Seq != 7 || got.Sender.Name != "Alice" {
+		t.Fatalf("unexpected event %s", b)
+	}
+	var p MessagePayload
+	if err := got.Decode(&p); err != nil || p.Content != "hi" {
+		t.Fatalf("unexpected payload %s: %v", got.Payload, err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package memory gives agents long-term memory: after each turn an Extractor
+// distills facts, opinions, relationships and episodes, which a Manager embeds,
+// stores per agent and recalls by similarity in later conversations.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+)
+
+// Turn is what an agent just did: the message it posted in reply to the
+// conversation in State (which ends with the message it answered).
+type Turn struct {
+	Agent *agent.Agent
+	State *agent.ConversationState
+	Said  *agent.Message
+}
+
+// trigger returns the message the agent answered, or nil.
+func (t *Turn) trigger() *agent.Message {
+	if t.State == nil {
+		return nil
+	}
+	return t.State.LastMessage()
+}
+
+// Extractor distills what an agent should remember from a turn. It fills Kind,
+// Subject and Content; the Manager sets the agent, conversation and source message.
+type Extractor interface {
+	Extract(ctx context.Context, turn *Turn) ([]agent.Memory, error)
+}
+
+// selfDisclosures mark a user message that states something about the user.
+var selfDisclosures = []string{"i am ", "i'm ", "my ", "i like ", "i love ", "i hate ", "i live ", "i work ", "我是", "我的", "我喜欢", "我住", "我在"}
+
+// HeuristicExtractor extracts memories without a model: the agent's own
+// statement becomes an opinion, and the message it answered becomes a fact
+// about its sender when the sender talks about themselves, or a relationship
+// note otherwise.
+type HeuristicExtractor struct{}
+
+func (HeuristicExtractor) Extract(ctx context.Context, turn *Turn) ([]agent.Memory, error) {
+	var out []agent.Memory
+	if turn.Said != nil && strings.TrimSpace(turn.Said.Content) != "" {
+		subject := ""
+		if trig := turn.trigger(); trig != nil {
+			subject = trig.Speaker()
+		}
+		out = append(out, agent.Memory{Kind: agent.MemoryOpinion, Subject: subject, Content: "I said: " + turn.Said.Content})
+	}
+	trig := turn.trigger()
+	if trig == nil || trig.SenderType == agent.SenderSystem || trig.SenderID == string(turn.Agent.ID) {
+		return out, nil
+	}
+	lower := strings.ToLower(trig.Content)
+	for _, marker := range selfDisclosures {
+		if strings.Contains(lower, marker) {
+			return append(out, agent.Memory{Kind: agent.MemoryFact, Subject: trig.Speaker(), Content: trig.Speaker() + " said: " + trig.Content}), nil
+		}
+	}
+	return append(out, agent.Memory{Kind: agent.MemoryRelationship, Subject: trig.Speaker(), Content: "Talked with " + trig.Speaker() + " about: " + trig.Content}), nil
+}
+
+// maxExtractedMemories bounds how many memories one turn can produce.
+const maxExtractedMemories = 5
+
+// LLMExtractor asks a chat-completion provider what the agent should remember.
+// Provider errors and unusable output fall back to Fallback (HeuristicExtractor when nil).
+type LLMExtractor struct {
+	Provider agent.ChatProvider
+	Fallback Extractor
+}
+
+func (e *LLMExtractor) Extract(ctx context.Context, turn *Turn) ([]agent.Memory, error) {
+	if e.Provider == nil {
+		return e.fallback(ctx, turn)
+	}
+	out, err := e.Provider.Complete(ctx, extractionPrompt(turn))
+	if err != nil {
+		return e.fallback(ctx, turn)
+	}
+	mems, err := parseMemories(out)
+	if err != nil {
+		return e.fallback(ctx, turn)
+	}
+	return mems, nil
+}
+
+func (e *LLMExtractor) fallback(ctx context.Context, turn *Turn) ([]agent.Memory, error) {
+	if e.Fallback != nil {
+		return e.Fallback.Extract(ctx, turn)
+	}
+	return HeuristicExtractor{}.Extract(ctx, turn)
+}
+
+// extractionPrompt asks for the memories worth keeping from turn.
+func extractionPrompt(turn *Turn) []agent.ChatMessage {
+	sys := fmt.Sprintf("You maintain the long-term memory of %s, a participant in online conversations. "+
+		"From the exchange below, list what %s should remember in future conversations: facts about people or the world, "+
+		"opinions %s expressed, and how %s relates to the people involved. Skip small talk.\n"+
+		`Respond with a JSON array of at most %d objects: [{"kind": "fact" | "opinion" | "relationship", "subject": "<who or what it is about>", "content": "<one sentence>"}]. `+
+		"Respond with [] when nothing is worth remembering.",
+		turn.Agent.Name, turn.Agent.Name, turn.Agent.Name, turn.Agent.Name, maxExtractedMemories)
+	var b strings.Builder
+	if trig := turn.trigger(); trig != nil {
+		fmt.Fprintf(&b, "%s: %s\n", trig.Speaker(), trig.Content)
+	}
+	if turn.Said != nil {
+		fmt.Fprintf(&b, "%s: %s\n", turn.Agent.Name, turn.Said.Content)
+	}
+	return []agent.ChatMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: sys},
+		{Role: openai.ChatMessageRoleUser, Content: b.String()},
+	}
+}
+
+// parseMemories maps raw model output onto memories, dropping unknown kinds and empty entries.
+func parseMemories(out string) ([]agent.Memory, error) {
+	out = strings.TrimSpace(out)
+	out = strings.TrimPrefix(out, "```json")
+	out = strings.TrimPrefix(out, "```")
+	out = strings.TrimSuffix(out, "```")
+	out = strings.TrimSpace(out)
+	var parsed []struct {
+		Kind    string `json:"kind"`
+		Subject string `json:"subject"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return nil, fmt.Errorf("parse memories: %w", err)
+	}
+	var mems []agent.Memory
+	for _, p := range parsed {
+		content := strings.TrimSpace(p.Content)
+		switch {
+		case content == "", len(mems) == maxExtractedMemories:
+			continue
+		case p.Kind != agent.MemoryFact && p.Kind != agent.MemoryOpinion && p.Kind != agent.MemoryRelationship:
+			continue
+		}
+		mems = append(mems, agent.Memory{Kind: p.Kind, Subject: strings.TrimSpace(p.Subject), Content: content})
+	}
+	return mems, nil
+}
+
+// NewExtractorFromEnv picks the extractor named by MEMORY_EXTRACTOR: "heuristic"
+// (the default) or "llm", which uses OPENAI_API_KEY, OPENAI_BASE_URL and OPENAI_CHAT_MODEL.
+func NewExtractorFromEnv() (Extractor, error) {
+	switch name := os.Getenv("MEMORY_EXTRACTOR"); name {
+	case "", "heuristic":
+		return HeuristicExtractor{}, nil
+	case "llm":
+		if os.Getenv("OPENAI_API_KEY") == "" {
+			return nil, errors.New("MEMORY_EXTRACTOR=llm requires OPENAI_API_KEY")
+		}
+		return &LLMExtractor{
+			Provider: agent.NewOpenAIProvider(agent.OpenAIConfig{
+				APIKey:  os.Getenv("OPENAI_API_KEY"),
+				BaseURL: os.Getenv("OPENAI_BASE_URL"),
+				Model:   os.Getenv("OPENAI_CHAT_MODEL"),
+			}),
+			Fallback: HeuristicExtractor{},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown MEMORY_EXTRACTOR %q", name)
+	}
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+// Store persists agent memories; persistence.Store satisfies it.
+type Store interface {
+	SaveMemory(ctx context.Context, m *agent.Memory, vec []float32) error
+	SearchMemories(ctx context.Context, agentID string, vec []float32, k int) ([]persistence.ScoredMemory, error)
+}
+
+// duplicateScore is the similarity above which a new memory is considered
+// already known and is not stored again.
+const duplicateScore = 0.97
+
+// Manager writes and recalls agent memories.
+type Manager struct {
+	store     Store
+	embedder  embeddings.Embedder
+	extractor Extractor
+}
+
+// NewManager returns a Manager storing memories embedded with embedder in store.
+// A nil extractor uses HeuristicExtractor.
+func NewManager(store Store, embedder embeddings.Embedder, extractor Extractor) *Manager {
+	if extractor == nil {
+		extractor = HeuristicExtractor{}
+	}
+	return &Manager{store: store, embedder: embedder, extractor: extractor}
+}
+
+// Record extracts what turn's agent should remember and stores it.
+func (m *Manager) Record(ctx context.Context, turn *Turn) error {
+	mems, err := m.extractor.Extract(ctx, turn)
+	if err != nil {
+		return fmt.Errorf("extract memories: %w", err)
+	}
+	for i := range mems {
+		mems[i].AgentID = string(turn.Agent.ID)
+		if turn.Said != nil {
+			mems[i].ConversationID = turn.Said.ConversationID
+			mems[i].SourceMessageID = turn.Said.ID
+		}
+	}
+	return m.Save(ctx, mems)
+}
+
+// Save embeds and stores mems, skipping any that repeat something the agent
+// already remembers. Each memory must have AgentID set.
+func (m *Manager) Save(ctx context.Context, mems []agent.Memory) error {
+	if len(mems) == 0 {
+		return nil
+	}
+	texts := make([]string, len(mems))
+	for i, mem := range mems {
+		texts[i] = mem.Content
+	}
+	vecs, err := m.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed memories: %w", err)
+	}
+	if len(vecs) != len(mems) {
+		return fmt.Errorf("embed memories: got %d vectors for %d texts", len(vecs), len(mems))
+	}
+	for i := range mems {
+		known, err := m.store.SearchMemories(ctx, mems[i].AgentID, vecs[i], 1)
+		if err != nil {
+			return err
+		}
+		if len(known) > 0 && known[0].Score >= duplicateScore {
+			continue
+		}
+		if err := m.store.SaveMemory(ctx, &mems[i], vecs[i]); err != nil {
+			return fmt.Errorf("save memory: %w", err)
+		}
+	}
+	return nil
+}
+
+// Recall returns up to k memories of an agent most similar to vec, most similar first.
+func (m *Manager) Recall(ctx context.Context, agentID string, vec []float32, k int) ([]agent.Memory, error) {
+	hits, err := m.store.SearchMemories(ctx, agentID, vec, k)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]agent.Memory, len(hits))
+	for i, h := range hits {
+		out[i] = h.Memory
+	}
+	return out, nil
+}
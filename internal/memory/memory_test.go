@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+type stubProvider struct {
+	out string
+	err error
+}
+
+func (p stubProvider) Complete(ctx context.Context, msgs []agent.ChatMessage) (string, error) {
+	return p.out, p.err
+}
+
+func testTurn(trigger string) *Turn {
+	return &Turn{
+		Agent: &agent.Agent{ID: "a1", Name: "Alice"},
+		State: &agent.ConversationState{Messages: []agent.Message{
+			{SenderType: agent.SenderUser, SenderID: "u1", Content: trigger},
+		}},
+		Said: &agent.Message{ConversationID: "c1", SenderType: agent.SenderAgent, SenderID: "a1", Content: "cats are great"},
+	}
+}
+
+func TestHeuristicExtractorKinds(t *testing.T) {
+	mems, _ := HeuristicExtractor{}.Extract(context.Background(), testTurn("I have a cat and my cat is old"))
+	if len(mems) != 2 || mems[0].Kind != agent.MemoryOpinion || mems[1].Kind != agent.MemoryFact || mems[1].Subject != "u1" {
+		t.Fatalf("expected opinion and fact about u1, got %+v", mems)
+	}
+	mems, _ = HeuristicExtractor{}.Extract(context.Background(), testTurn("what about dogs?"))
+	if len(mems) != 2 || mems[1].Kind != agent.MemoryRelationship {
+		t.Fatalf("expected opinion and relationship, got %+v", mems)
+	}
+}
+
+func TestLLMExtractorParsesAndFallsBack(t *testing.T) {
+	e := &LLMExtractor{Provider: stubProvider{out: "```json\n" + `[{"kind":"fact","subject":"u1","content":"u1 owns a cat"},{"kind":"gossip","content":"x"},{"kind":"opinion","content":" "}]` + "\n```"}}
+	mems, err := e.Extract(context.Background(), testTurn("I have a cat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mems) != 1 || mems[0].Content != "u1 owns a cat" || mems[0].Subject != "u1" {
+		t.Fatalf("expected one parsed fact, got %+v", mems)
+	}
+	e = &LLMExtractor{Provider: stubProvider{err: errors.New("boom")}}
+	if mems, _ := e.Extract(context.Background(), testTurn("hi")); len(mems) != 2 {
+		t.Fatalf("expected heuristic fallback, got %+v", mems)
+	}
+}
+
+func TestManagerRecordsAndRecalls(t *testing.T) {
+	ctx := context.Background()
+	store := persistence.NewMemoryStore()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	conv, _ := store.CreateConversation(ctx, "c")
+	said := &agent.Message{ConversationID: conv, SenderType: agent.SenderAgent, SenderID: alice, Content: "jazz is underrated"}
+	if err := store.InsertMessage(ctx, said); err != nil {
+		t.Fatal(err)
+	}
+	embedder := embeddings.NewHashEmbedder(64)
+	m := NewManager(store, embedder, nil)
+	turn := &Turn{Agent: &agent.Agent{ID: agent.AgentID(alice), Name: "Alice"}, State: &agent.ConversationState{}, Said: said}
+	for i := 0; i < 2; i++ {
+		if err := m.Record(ctx, turn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mems, _ := store.ListMemories(ctx, alice, 0)
+	if len(mems) != 1 || mems[0].ConversationID != conv || mems[0].SourceMessageID != said.ID {
+		t.Fatalf("expected one deduplicated memory linked to its message, got %+v", mems)
+	}
+	vec, _ := embeddings.EmbedOne(ctx, embedder, "jazz")
+	got, err := m.Recall(ctx, alice, vec, 3)
+	if err != nil || len(got) != 1 || got[0].Kind != agent.MemoryOpinion {
+		t.Fatalf("expected the jazz opinion, got %+v (%v)", got, err)
+	}
+}
@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/yourname/multiagent-social/migrations"
+)
+
+func TestLoadPairsAndOrders(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_b.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"001_a.up.sql":   {Data: []byte("CREATE TABLE a ();")},
+		"001_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"README.md":      {Data: []byte("ignored")},
+	}
+	ms, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 2 || ms[0].Version != 1 || ms[0].Name != "a" || ms[0].Down != "DROP TABLE a;" || ms[1].Version != 2 || ms[1].Down != "" {
+		t.Fatalf("unexpected migrations %+v", ms)
+	}
+}
+
+func TestLoadRejectsBadNames(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"no direction": {"001_a.sql": {Data: []byte("x")}},
+		"no version":   {"a.up.sql": {Data: []byte("x")}},
+		"down only":    {"001_a.down.sql": {Data: []byte("x")}},
+		"two names":    {"001_a.up.sql": {Data: []byte("x")}, "001_b.down.sql": {Data: []byte("y")}},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	ms, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range ms {
+		if m.Version != i+1 {
+			t.Fatalf("expected contiguous versions, got %d at position %d", m.Version, i)
+		}
+		if m.Down == "" {
+			t.Fatalf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
+
+// This integration test requires a running Postgres and PG_DSN env set; it
+// migrates the database up, reverts the latest migration and reapplies it.
+func TestMigrateUpDown(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set; skipping integration test")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Skipf("db not available: %v", err)
+	}
+	defer pool.Close()
+	m, err := New(pool, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if ran, err := m.Up(ctx); err != nil || len(ran) != 0 {
+		t.Fatalf("expected second up to do nothing, ran %v: %v", ran, err)
+	}
+	reverted, err := m.Down(ctx, 1)
+	if err != nil || len(reverted) != 1 {
+		t.Fatalf("down: reverted %v: %v", reverted, err)
+	}
+	if ran, err := m.Up(ctx); err != nil || len(ran) != 1 || ran[0].Version != reverted[0].Version {
+		t.Fatalf("expected to reapply %d, ran %v: %v", reverted[0].Version, ran, err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range status {
+		if st.AppliedAt == nil || st.Modified {
+			t.Fatalf("expected %d_%s applied and unmodified, got %+v", st.Version, st.Name, st)
+		}
+	}
+}
@@ -0,0 +1,656 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/memory"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+var (
+	// ErrAutonomousRunning is returned when a conversation already has a live
+	// autonomous run, or a debate is started during one.
+	ErrAutonomousRunning = errors.New("autonomous run already in progress")
+	// ErrNoAutonomous is returned when the autonomous run is not live in this process.
+	ErrNoAutonomous = errors.New("no autonomous run in progress")
+	// ErrAutonomousEnded is returned when pausing, resuming or cancelling an autonomous run that has ended.
+	ErrAutonomousEnded = errors.New("autonomous run has ended")
+	// ErrInvalidAutonomous is returned for autonomous options out of range.
+	ErrInvalidAutonomous = errors.New("invalid autonomous run")
+)
+
+// Stopping conditions of autonomous runs, in AutonomousOptions.StopOn.
+const (
+	StopOnConsensus      = persistence.StopConsensus
+	StopOnTopicExhausted = persistence.StopTopicExhausted
+	StopOnLeave          = persistence.StopLeave
+)
+
+// Limits of autonomous runs.
+const (
+	defaultAutonomousTurns   = 20
+	maxAutonomousTurns       = 1000
+	defaultAutonomousSeconds = 600
+	maxAutonomousSeconds     = 24 * 60 * 60
+	// repeatSimilarity is how similar to an earlier message of the run a
+	// message must be to add nothing new.
+	repeatSimilarity = 0.9
+)
+
+// AutonomousOptions configures an autonomous run. Participants default to
+// every participant of the conversation and Topic to the latest message. A
+// nil StopOn applies every stopping condition; an empty one only the limits.
+type AutonomousOptions struct {
+	Topic              string   `json:"topic"`
+	Participants       []string `json:"participants"`
+	MaxTurns           int      `json:"max_turns"`
+	MaxDurationSeconds int      `json:"max_duration_seconds"`
+	StopOn             []string `json:"stop_on"`
+}
+
+// checkAutonomousOptions validates opts and fills in the defaults.
+func checkAutonomousOptions(opts *AutonomousOptions) error {
+	switch {
+	case opts.MaxTurns < 0 || opts.MaxTurns > maxAutonomousTurns:
+		return fmt.Errorf("%w: max_turns must be from 1 to %d", ErrInvalidAutonomous, maxAutonomousTurns)
+	case opts.MaxDurationSeconds < 0 || opts.MaxDurationSeconds > maxAutonomousSeconds:
+		return fmt.Errorf("%w: max_duration_seconds must be from 1 to %d", ErrInvalidAutonomous, maxAutonomousSeconds)
+	}
+	if opts.MaxTurns == 0 {
+		opts.MaxTurns = defaultAutonomousTurns
+	}
+	if opts.MaxDurationSeconds == 0 {
+		opts.MaxDurationSeconds = defaultAutonomousSeconds
+	}
+	if opts.StopOn == nil {
+		opts.StopOn = []string{StopOnConsensus, StopOnTopicExhausted, StopOnLeave}
+	}
+	for _, c := range opts.StopOn {
+		if c != StopOnConsensus && c != StopOnTopicExhausted && c != StopOnLeave {
+			return fmt.Errorf("%w: unknown stopping condition %q", ErrInvalidAutonomous, c)
+		}
+	}
+	return nil
+}
+
+// autonomousSession is an autonomous run in this process. run mirrors the
+// stored row; resumed is closed when a paused run resumes and nil otherwise,
+// and inbox holds the user messages posted since the last turn.
+type autonomousSession struct {
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the run has ended and been saved
+	agents []agent.Agent // participants in speaking order
+
+	mu      sync.Mutex
+	run     persistence.AutonomousRun
+	resumed chan struct{}
+	inbox   []agent.Message
+}
+
+// snapshot returns a copy of the session's run.
+func (s *autonomousSession) snapshot() *persistence.AutonomousRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.run
+	r.Participants = slices.Clone(s.run.Participants)
+	r.StopOn = slices.Clone(s.run.StopOn)
+	r.Left = slices.Clone(s.run.Left)
+	return &r
+}
+
+// update changes the run under the lock and returns a copy of the result.
+func (s *autonomousSession) update(fn func(r *persistence.AutonomousRun)) *persistence.AutonomousRun {
+	s.mu.Lock()
+	fn(&s.run)
+	s.mu.Unlock()
+	return s.snapshot()
+}
+
+// waitResumed blocks while the run is paused.
+func (s *autonomousSession) waitResumed(ctx context.Context) error {
+	s.mu.Lock()
+	ch := s.resumed
+	s.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ch:
+		return nil
+	}
+}
+
+// interject queues a user message for the run to take up before its next turn.
+func (s *autonomousSession) interject(m agent.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inbox = append(s.inbox, m)
+}
+
+// drain returns and clears the queued user messages.
+func (s *autonomousSession) drain() []agent.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := s.inbox
+	s.inbox = nil
+	return msgs
+}
+
+// stopsOn reports whether the run stops on condition.
+func (s *autonomousSession) stopsOn(condition string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.run.StopOn, condition)
+}
+
+// StartAutonomous lets the participants converse among themselves on a topic
+// in the background and returns the run at once. The run stops after MaxTurns
+// agent messages or MaxDurationSeconds (pauses included), or earlier on the
+// stopping conditions in StopOn. User messages posted meanwhile are merged in.
+// Follow it with AutonomousRun, and control it with PauseAutonomous,
+// ResumeAutonomous, CancelAutonomous or StopAutonomous.
+func (o *Orchestrator) StartAutonomous(ctx context.Context, conversationID string, opts AutonomousOptions) (*persistence.AutonomousRun, error) {
+	if err := checkAutonomousOptions(&opts); err != nil {
+		return nil, err
+	}
+	c, err := o.store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != persistence.ConversationActive {
+		return nil, ErrConversationInactive
+	}
+	participants, err := o.store.ListParticipants(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	sess := &autonomousSession{done: make(chan struct{})}
+	if len(opts.Participants) == 0 {
+		sess.agents = participants
+	}
+	for _, id := range opts.Participants {
+		i := slices.IndexFunc(participants, func(a agent.Agent) bool { return string(a.ID) == id })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s is not a participant", ErrInvalidAutonomous, id)
+		}
+		sess.agents = append(sess.agents, participants[i])
+	}
+	if len(sess.agents) < 2 {
+		return nil, fmt.Errorf("%w: need at least 2 participants", ErrInvalidAutonomous)
+	}
+	topic := strings.TrimSpace(opts.Topic)
+	if topic == "" {
+		if recent, err := o.loadRecent(ctx, conversationID, 1); err == nil && recent.last() != nil {
+			topic = recent.last().Content
+		}
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("%w: topic is required in an empty conversation", ErrInvalidAutonomous)
+	}
+	ids := make([]string, len(sess.agents))
+	for i, a := range sess.agents {
+		ids[i] = string(a.ID)
+	}
+	sess.run = persistence.AutonomousRun{
+		ConversationID:     conversationID,
+		Topic:              topic,
+		Participants:       ids,
+		MaxTurns:           opts.MaxTurns,
+		MaxDurationSeconds: opts.MaxDurationSeconds,
+		StopOn:             opts.StopOn,
+		State:              persistence.AutonomousRunning,
+	}
+
+	o.debateMu.Lock()
+	switch {
+	case o.conversationAutonomous(conversationID) != nil:
+		err = ErrAutonomousRunning
+	case o.conversationDebate(conversationID) != nil:
+		err = ErrDebateRunning
+	default:
+		if err = o.store.CreateAutonomousRun(ctx, &sess.run); err == nil {
+			o.autonomous[sess.run.ID] = sess
+		}
+	}
+	o.debateMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.MaxDurationSeconds)*time.Second)
+	sess.cancel = cancel
+	go func() {
+		defer cancel()
+		reason, err := o.runAutonomous(runCtx, sess)
+		o.endAutonomous(sess, reason, err)
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrConversationInactive) {
+			log.Printf("orchestrator: conversation %s: autonomous run %s: %v", conversationID, sess.run.ID, err)
+		}
+	}()
+	return sess.snapshot(), nil
+}
+
+// conversationAutonomous returns the live autonomous run of a conversation. Callers hold debateMu.
+func (o *Orchestrator) conversationAutonomous(conversationID string) *autonomousSession {
+	for _, sess := range o.autonomous {
+		if sess.run.ConversationID == conversationID {
+			return sess
+		}
+	}
+	return nil
+}
+
+// liveAutonomousIn returns the session of the conversation's live autonomous run, or nil.
+func (o *Orchestrator) liveAutonomousIn(conversationID string) *autonomousSession {
+	o.debateMu.Lock()
+	defer o.debateMu.Unlock()
+	return o.conversationAutonomous(conversationID)
+}
+
+// AutonomousRun returns an autonomous run's progress, live when it runs in this process.
+func (o *Orchestrator) AutonomousRun(ctx context.Context, id string) (*persistence.AutonomousRun, error) {
+	o.debateMu.Lock()
+	sess := o.autonomous[id]
+	o.debateMu.Unlock()
+	if sess != nil {
+		return sess.snapshot(), nil
+	}
+	return o.store.GetAutonomousRun(ctx, id)
+}
+
+// liveAutonomous returns the session of an autonomous run in this process, or
+// the reason there is none: ErrNotFound, ErrAutonomousEnded or ErrNoAutonomous.
+func (o *Orchestrator) liveAutonomous(ctx context.Context, id string) (*autonomousSession, error) {
+	o.debateMu.Lock()
+	sess := o.autonomous[id]
+	o.debateMu.Unlock()
+	if sess != nil {
+		return sess, nil
+	}
+	r, err := o.store.GetAutonomousRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Ended() {
+		return nil, ErrAutonomousEnded
+	}
+	return nil, ErrNoAutonomous
+}
+
+// PauseAutonomous pauses an autonomous run before its next turn. Pausing a paused run is a no-op.
+func (o *Orchestrator) PauseAutonomous(ctx context.Context, id string) (*persistence.AutonomousRun, error) {
+	sess, err := o.liveAutonomous(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	r := sess.update(func(r *persistence.AutonomousRun) {
+		if r.State == persistence.AutonomousRunning {
+			r.State = persistence.AutonomousPaused
+			sess.resumed = make(chan struct{})
+			changed = true
+		}
+	})
+	if r.Ended() {
+		return r, ErrAutonomousEnded
+	}
+	if changed {
+		o.saveAutonomous(ctx, r)
+		o.emitAutonomous(ctx, events.TypeAutonomousPaused, r, "")
+	}
+	return r, nil
+}
+
+// ResumeAutonomous continues a paused autonomous run; the conversation must be
+// active. Resuming a running run is a no-op.
+func (o *Orchestrator) ResumeAutonomous(ctx context.Context, id string) (*persistence.AutonomousRun, error) {
+	sess, err := o.liveAutonomous(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !o.takesTurns(ctx, sess.snapshot().ConversationID) {
+		return nil, ErrConversationInactive
+	}
+	changed := false
+	r := sess.update(func(r *persistence.AutonomousRun) {
+		if r.State == persistence.AutonomousPaused {
+			r.State = persistence.AutonomousRunning
+			close(sess.resumed)
+			sess.resumed = nil
+			changed = true
+		}
+	})
+	if r.Ended() {
+		return r, ErrAutonomousEnded
+	}
+	if changed {
+		o.saveAutonomous(ctx, r)
+		o.emitAutonomous(ctx, events.TypeAutonomousResumed, r, "")
+	}
+	return r, nil
+}
+
+// CancelAutonomous stops an autonomous run and returns it once it has ended.
+func (o *Orchestrator) CancelAutonomous(ctx context.Context, id string) (*persistence.AutonomousRun, error) {
+	sess, err := o.liveAutonomous(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sess.cancel()
+	select {
+	case <-sess.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return sess.snapshot(), nil
+}
+
+// StopAutonomous cancels the conversation's live autonomous run without waiting for it to end.
+func (o *Orchestrator) StopAutonomous(conversationID string) error {
+	o.debateMu.Lock()
+	defer o.debateMu.Unlock()
+	sess := o.conversationAutonomous(conversationID)
+	if sess == nil {
+		return ErrNoAutonomous
+	}
+	sess.cancel()
+	delete(o.autonomous, sess.run.ID)
+	return nil
+}
+
+// pauseConversationAutonomous pauses the conversation's live autonomous run, if any.
+func (o *Orchestrator) pauseConversationAutonomous(ctx context.Context, conversationID string) {
+	if sess := o.liveAutonomousIn(conversationID); sess != nil {
+		_, _ = o.PauseAutonomous(ctx, sess.snapshot().ID)
+	}
+}
+
+// autonomousTurn is what the run remembers of one agent message for its
+// stopping conditions.
+type autonomousTurn struct {
+	agrees bool // agreed with the others and challenged nothing
+	repeat bool // said nothing the run had not heard
+}
+
+// runAutonomous lets the session's agents take turns until a limit or a
+// stopping condition ends the run, and returns why it stopped. Before every
+// turn it merges the user messages posted meanwhile: the next speakers answer
+// the latest of them, agents it mentions first. It stops early when ctx ends
+// or the conversation stops taking turns, and waits while the run is paused.
+func (o *Orchestrator) runAutonomous(ctx context.Context, sess *autonomousSession) (string, error) {
+	r := sess.snapshot()
+	conversationID := r.ConversationID
+	o.emitAutonomous(ctx, events.TypeAutonomousStarted, r, "")
+	window := o.contextConfigFor(ctx, conversationID)
+	recent, err := o.loadRecent(ctx, conversationID, window.Recent)
+	if err != nil {
+		return "", err
+	}
+	mayLeave := sess.stopsOn(StopOnLeave)
+	active := slices.Clone(sess.agents)
+	var (
+		trigger *agent.Message // the latest user message, until it has been answered
+		queue   []agent.Agent  // agents it mentions, waiting for their turn
+		turns   []autonomousTurn
+		said    []embeddings.Vector // this run's messages, for repeats
+		last    agent.AgentID       // the previous speaker
+		passes  int                 // agents in a row with nothing to say
+	)
+	for {
+		if err := sess.waitResumed(ctx); err != nil {
+			return "", err
+		}
+		if !o.takesTurns(ctx, conversationID) {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			return "", ErrConversationInactive
+		}
+		if r.Turns >= r.MaxTurns {
+			return persistence.StopMaxTurns, nil
+		}
+		for _, m := range sess.drain() {
+			recent.add(m, window.Recent)
+			trigger, queue, turns, passes = &m, nil, nil, 0
+			for _, id := range m.Mentioned() {
+				if i := slices.IndexFunc(active, func(a agent.Agent) bool { return string(a.ID) == id }); i >= 0 {
+					queue = append(queue, active[i])
+				}
+			}
+		}
+		var speaker agent.Agent
+		if len(queue) > 0 {
+			speaker, queue = queue[0], queue[1:]
+		} else {
+			speaker = o.nextAutonomousSpeaker(ctx, conversationID, active, recent, last)
+		}
+		r = sess.update(func(r *persistence.AutonomousRun) { r.SpeakerID = string(speaker.ID) })
+		o.saveAutonomous(ctx, r)
+
+		state := o.decisionState(ctx, conversationID, &speaker, recent, window)
+		state.Topic, state.MayLeave = r.Topic, mayLeave
+		if trigger != nil && slices.Contains(trigger.Mentioned(), string(speaker.ID)) {
+			state.Mention = trigger
+		}
+		action, err := o.deciderFor(&speaker).DecideAction(ctx, &speaker, state)
+		last = speaker.ID
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err != nil {
+			// a script run out or no rule matching means nothing to say: a pass, as in regular turns
+			action = nil
+		}
+		leaving := action != nil && action.Type == agent.ActionLeave && mayLeave
+		if !leaving && (action == nil || strings.TrimSpace(action.Payload) == "") {
+			// everyone passing in a row leaves nothing more to say
+			if passes++; passes >= len(active) && sess.stopsOn(StopOnTopicExhausted) {
+				return persistence.StopTopicExhausted, nil
+			}
+			if err := o.autonomousPause(ctx); err != nil {
+				return "", err
+			}
+			continue
+		}
+		passes = 0
+
+		if leaving {
+			if strings.TrimSpace(action.Payload) != "" {
+				o.postAutonomous(ctx, r, &speaker, action, trigger, active)
+			}
+			active = slices.DeleteFunc(active, func(a agent.Agent) bool { return a.ID == speaker.ID })
+			r = sess.update(func(r *persistence.AutonomousRun) { r.Left, r.SpeakerID = append(r.Left, string(speaker.ID)), "" })
+			o.saveAutonomous(ctx, r)
+			o.emitAutonomous(ctx, events.TypeAutonomousLeft, r, string(speaker.ID))
+			if len(active) < 2 {
+				return persistence.StopLeave, nil
+			}
+			continue
+		}
+		if action.Type == agent.ActionLeave {
+			// leaving is off in this run: the parting word is just a message
+			action = &agent.Action{Type: "speak", Payload: action.Payload}
+		}
+
+		m := o.postAutonomous(ctx, r, &speaker, action, trigger, active)
+		if m == nil {
+			continue
+		}
+		recent.add(*m, window.Recent)
+		if len(queue) == 0 {
+			trigger = nil
+		}
+		o.remember(conversationID, func(ctx context.Context) error {
+			return o.memory.Record(ctx, &memory.Turn{Agent: &speaker, State: state, Said: m})
+		})
+		r = sess.update(func(r *persistence.AutonomousRun) { r.Turns++ })
+		o.saveAutonomous(ctx, r)
+
+		turn := autonomousTurn{agrees: action.Type != "challenge" && agrees(m.Content)}
+		if vec, err := o.embed(ctx, m.Content); err == nil {
+			for _, v := range said {
+				if cosine(vec, v) >= repeatSimilarity {
+					turn.repeat = true
+					break
+				}
+			}
+			said = append(said, vec)
+		}
+		turns = append(turns, turn)
+		// a stopping condition holds once everyone still taking part has spoken to it
+		if k := len(active); len(turns) >= k {
+			recent := turns[len(turns)-k:]
+			if sess.stopsOn(StopOnConsensus) && !slices.ContainsFunc(recent, func(t autonomousTurn) bool { return !t.agrees }) {
+				return persistence.StopConsensus, nil
+			}
+			if sess.stopsOn(StopOnTopicExhausted) && !slices.ContainsFunc(recent, func(t autonomousTurn) bool { return !t.repeat }) {
+				return persistence.StopTopicExhausted, nil
+			}
+		}
+		if err := o.autonomousPause(ctx); err != nil {
+			return "", err
+		}
+	}
+}
+
+// autonomousPause waits between turns.
+func (o *Orchestrator) autonomousPause(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(o.responseDelay):
+		return nil
+	}
+}
+
+// nextAutonomousSpeaker picks who speaks after last: a participant the latest
+// message mentions, else the first pick of the conversation's turn policy, else
+// the next participant in order. Nobody speaks twice in a row.
+func (o *Orchestrator) nextAutonomousSpeaker(ctx context.Context, conversationID string, active []agent.Agent, recent *recentMessages, last agent.AgentID) agent.Agent {
+	others := func(a agent.Agent) bool { return a.ID != last }
+	if latest := recent.last(); latest != nil {
+		for _, a := range mentionedFirst(nil, active, latest) {
+			if others(a) {
+				return a
+			}
+		}
+	}
+	policy, cfg := o.turnPolicyFor(ctx, conversationID)
+	picks, err := policy.Select(ctx, &TurnContext{
+		ConversationID: conversationID,
+		Participants:   active,
+		Messages:       recent.messages,
+		Decider:        o.deciderFor,
+	})
+	if err != nil {
+		log.Printf("orchestrator: conversation %s: turn policy %s: %v", conversationID, cfg.Name, err)
+	}
+	if i := slices.IndexFunc(picks, others); i >= 0 {
+		return picks[i]
+	}
+	i := slices.IndexFunc(active, func(a agent.Agent) bool { return a.ID == last })
+	return active[(i+1)%len(active)]
+}
+
+// postAutonomous posts an agent's message in an autonomous run, answering
+// trigger when set. It returns nil when the message cannot be stored.
+func (o *Orchestrator) postAutonomous(ctx context.Context, r *persistence.AutonomousRun, a *agent.Agent, action *agent.Action, trigger *agent.Message, active []agent.Agent) *agent.Message {
+	m := agentMessage(r.ConversationID, a, action)
+	m.Metadata["autonomous_run_id"] = r.ID
+	m.Mentions = agent.ParseMentions(m.Content, active)
+	if trigger != nil {
+		m.ReplyTo = trigger.ID
+	}
+	if err := o.postMessage(ctx, m); err != nil {
+		log.Printf("orchestrator: conversation %s: autonomous run %s: post: %v", r.ConversationID, r.ID, err)
+		return nil
+	}
+	return m
+}
+
+// agreementMarkers and disagreementMarkers are how agreement shows in a message.
+var (
+	agreementMarkers    = []string{"i agree", "agreed", "you're right", "you are right", "exactly", "absolutely", "good point", "fair point", "consensus", "同意", "赞同", "没错", "说得对", "有道理", "一致"}
+	disagreementMarkers = []string{"disagree", "don't agree", "do not agree", "not agree", "不同意", "不赞同"}
+)
+
+// agrees reports whether content expresses agreement.
+func agrees(content string) bool {
+	text := strings.ToLower(content)
+	has := func(markers []string) bool {
+		return slices.ContainsFunc(markers, func(m string) bool { return strings.Contains(text, m) })
+	}
+	return has(agreementMarkers) && !has(disagreementMarkers)
+}
+
+// endAutonomous records the final state of a run that stopped for reason or
+// with err, announces it and unregisters the session. Running out of time
+// finishes the run; a conversation that stops taking turns cancels it.
+func (o *Orchestrator) endAutonomous(sess *autonomousSession, reason string, err error) {
+	// the run context may be cancelled; the final state must still be saved
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	now := time.Now().UTC()
+	r := sess.update(func(r *persistence.AutonomousRun) {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			r.State, r.StopReason = persistence.AutonomousFinished, persistence.StopMaxDuration
+		case err == nil:
+			r.State, r.StopReason = persistence.AutonomousFinished, reason
+		case errors.Is(err, context.Canceled), errors.Is(err, ErrConversationInactive):
+			r.State = persistence.AutonomousCancelled
+		default:
+			r.State, r.Error = persistence.AutonomousFailed, err.Error()
+		}
+		r.SpeakerID, r.EndedAt = "", &now
+		if sess.resumed != nil {
+			close(sess.resumed)
+			sess.resumed = nil
+		}
+	})
+	o.saveAutonomous(ctx, r)
+	o.emitAutonomous(ctx, events.TypeAutonomousEnded, r, "")
+	o.debateMu.Lock()
+	if o.autonomous[r.ID] == sess {
+		delete(o.autonomous, r.ID)
+	}
+	o.debateMu.Unlock()
+	close(sess.done)
+}
+
+// saveAutonomous stores a run's progress; failures are logged, the run goes on.
+func (o *Orchestrator) saveAutonomous(ctx context.Context, r *persistence.AutonomousRun) {
+	if err := o.store.UpdateAutonomousRun(ctx, r); err != nil {
+		log.Printf("orchestrator: conversation %s: save autonomous run %s: %v", r.ConversationID, r.ID, err)
+	}
+}
+
+// emitAutonomous publishes an autonomous.* event describing r; agentID names
+// who left on autonomous.left.
+func (o *Orchestrator) emitAutonomous(ctx context.Context, typ string, r *persistence.AutonomousRun, agentID string) {
+	payload := events.AutonomousPayload{
+		RunID:      r.ID,
+		State:      r.State,
+		Turns:      r.Turns,
+		MaxTurns:   r.MaxTurns,
+		AgentID:    agentID,
+		StopReason: r.StopReason,
+		Error:      r.Error,
+	}
+	if typ == events.TypeAutonomousStarted {
+		payload.Topic, payload.Participants = r.Topic, r.Participants
+	}
+	o.emit(ctx, typ, r.ConversationID, "", systemSender, payload)
+}
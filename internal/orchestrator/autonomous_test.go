@@ -0,0 +1,271 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+// scriptedAgent creates an agent speaking lines with the scripted decider.
+func scriptedAgent(t *testing.T, store persistence.Store, name, typ string, loop bool, lines ...string) string {
+	t.Helper()
+	script := make([]interface{}, len(lines))
+	for i, l := range lines {
+		script[i] = l
+	}
+	id, err := store.CreateAgent(context.Background(), name, "talker", map[string]interface{}{
+		agent.ProfileDeciderKey:       "scripted",
+		agent.ProfileDeciderConfigKey: map[string]interface{}{"lines": script, "type": typ, "loop": loop},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// awaitAutonomous waits for an autonomous run to end.
+func awaitAutonomous(t *testing.T, o *Orchestrator, id string) *persistence.AutonomousRun {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r, err := o.AutonomousRun(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Ended() {
+			return r
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("autonomous run did not end: %+v", r)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAutonomousRunStopsAtMaxTurns(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	carol, _ := store.CreateAgent(ctx, "Carol", "chess", nil)
+	convID, _ := o.CreateConversation(ctx, "autonomous", []string{alice, bob, carol}, nil)
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+	defer sub.Close()
+
+	if _, err := o.StartAutonomous(ctx, convID, AutonomousOptions{Participants: []string{alice}}); !errors.Is(err, ErrInvalidAutonomous) {
+		t.Fatalf("expected ErrInvalidAutonomous for a single participant, got %v", err)
+	}
+	if _, err := o.StartAutonomous(ctx, convID, AutonomousOptions{Participants: []string{alice, bob}}); !errors.Is(err, ErrInvalidAutonomous) {
+		t.Fatalf("expected ErrInvalidAutonomous without a topic, got %v", err)
+	}
+	if _, err := o.StartAutonomous(ctx, convID, AutonomousOptions{Topic: "x", StopOn: []string{"boredom"}}); !errors.Is(err, ErrInvalidAutonomous) {
+		t.Fatalf("expected ErrInvalidAutonomous for an unknown stopping condition, got %v", err)
+	}
+
+	r, err := o.StartAutonomous(ctx, convID, AutonomousOptions{Topic: "hobbies", MaxTurns: 5, StopOn: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.State != persistence.AutonomousRunning || len(r.Participants) != 3 || r.MaxDurationSeconds != defaultAutonomousSeconds {
+		t.Fatalf("unexpected run %+v", r)
+	}
+	var p events.AutonomousPayload
+	if err := nextEventOf(t, sub, events.TypeAutonomousStarted).Decode(&p); err != nil || p.RunID != r.ID || p.Topic != "hobbies" {
+		t.Fatalf("unexpected autonomous.started %+v (%v)", p, err)
+	}
+	if err := nextEventOf(t, sub, events.TypeAutonomousEnded).Decode(&p); err != nil || p.StopReason != persistence.StopMaxTurns || p.Turns != 5 {
+		t.Fatalf("unexpected autonomous.ended %+v (%v)", p, err)
+	}
+	r = awaitAutonomous(t, o, r.ID)
+	if r.State != persistence.AutonomousFinished || r.StopReason != persistence.StopMaxTurns || r.EndedAt == nil {
+		t.Fatalf("expected the run to finish at max turns, got %+v", r)
+	}
+	msgs, _ := store.GetConversationMessages(ctx, convID)
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(msgs))
+	}
+	for i, m := range msgs {
+		if m.Metadata["autonomous_run_id"] != r.ID {
+			t.Fatalf("message %d is not marked with the run: %+v", i, m.Metadata)
+		}
+		if i > 0 && m.SenderID == msgs[i-1].SenderID {
+			t.Fatalf("%s spoke twice in a row", m.SenderName)
+		}
+	}
+}
+
+func TestAutonomousRunStoppingConditions(t *testing.T) {
+	o, store, _ := newTestOrchestrator(t)
+	ctx := context.Background()
+	run := func(opts AutonomousOptions, ids ...string) (*persistence.AutonomousRun, []agent.Message) {
+		t.Helper()
+		convID, _ := o.CreateConversation(ctx, "autonomous", ids, nil)
+		opts.Topic = "tea or coffee"
+		r, err := o.StartAutonomous(ctx, convID, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = awaitAutonomous(t, o, r.ID)
+		msgs, _ := store.GetConversationMessages(ctx, convID)
+		return r, msgs
+	}
+
+	t.Run("consensus", func(t *testing.T) {
+		alice := scriptedAgent(t, store, "Alice", "speak", true, "Tea is best.", "Good point, I agree.")
+		bob := scriptedAgent(t, store, "Bob", "speak", true, "Coffee wins.", "Agreed, you're right.")
+		r, _ := run(AutonomousOptions{StopOn: []string{StopOnConsensus}}, alice, bob)
+		if r.StopReason != persistence.StopConsensus || r.Turns != 4 {
+			t.Fatalf("expected consensus after 4 turns, got %+v", r)
+		}
+	})
+	t.Run("disagreement is no consensus", func(t *testing.T) {
+		alice := scriptedAgent(t, store, "Alice", "speak", true, "I disagree, tea.")
+		bob := scriptedAgent(t, store, "Bob", "challenge", true, "Agreed? No: coffee.")
+		r, _ := run(AutonomousOptions{MaxTurns: 4, StopOn: []string{StopOnConsensus}}, alice, bob)
+		if r.StopReason != persistence.StopMaxTurns {
+			t.Fatalf("expected no consensus, got %+v", r)
+		}
+	})
+	t.Run("topic exhausted", func(t *testing.T) {
+		alice := scriptedAgent(t, store, "Alice", "speak", true, "Tea is best because it calms.")
+		bob := scriptedAgent(t, store, "Bob", "speak", true, "Coffee is best because it wakes.")
+		r, _ := run(AutonomousOptions{StopOn: []string{StopOnTopicExhausted}}, alice, bob)
+		if r.StopReason != persistence.StopTopicExhausted || r.Turns != 4 {
+			t.Fatalf("expected the topic to be exhausted once both repeat, got %+v", r)
+		}
+	})
+	t.Run("nothing left to say", func(t *testing.T) {
+		alice := scriptedAgent(t, store, "Alice", "speak", false, "Tea.", "")
+		bob := scriptedAgent(t, store, "Bob", "speak", false, "Coffee.", "")
+		r, _ := run(AutonomousOptions{StopOn: []string{StopOnTopicExhausted}}, alice, bob)
+		if r.StopReason != persistence.StopTopicExhausted || r.Turns != 2 {
+			t.Fatalf("expected the run to stop once everyone passes, got %+v", r)
+		}
+	})
+	t.Run("decider runs dry", func(t *testing.T) {
+		// the script runs out after one line and the decider errors from then on
+		alice := scriptedAgent(t, store, "Alice", "speak", false, "Tea.")
+		bob := scriptedAgent(t, store, "Bob", "speak", false, "Coffee.")
+		r, _ := run(AutonomousOptions{}, alice, bob)
+		if r.State != persistence.AutonomousFinished || r.StopReason != persistence.StopTopicExhausted || r.Error != "" || r.Turns != 2 {
+			t.Fatalf("expected the decider errors to count as passes, got %+v", r)
+		}
+	})
+	t.Run("leave when leaving is off", func(t *testing.T) {
+		alice := scriptedAgent(t, store, "Alice", "leave", true, "", "Bye then.")
+		bob := scriptedAgent(t, store, "Bob", "speak", true, "Coffee is warm.", "Coffee is bitter.")
+		r, msgs := run(AutonomousOptions{MaxTurns: 4, StopOn: []string{}}, alice, bob)
+		if r.StopReason != persistence.StopMaxTurns || len(r.Left) != 0 {
+			t.Fatalf("expected nobody to leave, got %+v", r)
+		}
+		for _, m := range msgs {
+			if m.Content == "" || m.Metadata["action"] == agent.ActionLeave {
+				t.Fatalf("unexpected leave message %+v", m)
+			}
+		}
+	})
+	t.Run("leave", func(t *testing.T) {
+		alice := scriptedAgent(t, store, "Alice", "leave", false, "I have said my piece, bye.")
+		bob := scriptedAgent(t, store, "Bob", "leave", false, "")
+		carol, _ := store.CreateAgent(ctx, "Carol", "chess", nil)
+		r, msgs := run(AutonomousOptions{StopOn: []string{StopOnLeave}}, alice, bob, carol)
+		if r.StopReason != persistence.StopLeave || len(r.Left) != 2 {
+			t.Fatalf("expected the run to stop once two agents left, got %+v", r)
+		}
+		for _, m := range msgs {
+			if m.SenderID == bob {
+				t.Fatalf("Bob left without a word but posted %q", m.Content)
+			}
+		}
+	})
+}
+
+func TestAutonomousRunMergesUserMessages(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	o.responseDelay = 20 * time.Millisecond
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	carol, _ := store.CreateAgent(ctx, "Carol", "chess", nil)
+	convID, _ := o.CreateConversation(ctx, "autonomous", []string{alice, bob, carol}, nil)
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+	defer sub.Close()
+
+	r, err := o.StartAutonomous(ctx, convID, AutonomousOptions{Topic: "weekends", MaxTurns: 100, StopOn: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.StartAutonomous(ctx, convID, AutonomousOptions{Topic: "again"}); !errors.Is(err, ErrAutonomousRunning) {
+		t.Fatalf("expected ErrAutonomousRunning, got %v", err)
+	}
+	if _, err := o.StartDebateAsync(ctx, convID, DebateOptions{}); !errors.Is(err, ErrAutonomousRunning) {
+		t.Fatalf("expected ErrAutonomousRunning starting a debate, got %v", err)
+	}
+	nextEventOf(t, sub, events.TypeMessageCreated)
+
+	// the run answers the user: Carol, mentioned, speaks first
+	userID, err := o.HandleUserMessage(ctx, convID, "u1", "@Carol, what about chess?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var answer *events.Event
+	for answer == nil {
+		evt := nextEventOf(t, sub, events.TypeMessageCreated)
+		var p events.MessagePayload
+		if err := evt.Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		if p.ReplyTo == userID {
+			answer = evt
+		}
+	}
+	if answer.Sender.ID != carol {
+		t.Fatalf("expected Carol to answer the user first, got %s", answer.Sender.Name)
+	}
+	replies, _ := store.ListMessages(ctx, convID, persistence.MessageQuery{ThreadID: userID})
+	if len(replies) != 2 {
+		t.Fatalf("expected the run alone to answer the user, got %d messages in the thread", len(replies))
+	}
+
+	if r, err = o.PauseAutonomous(ctx, r.ID); err != nil || r.State != persistence.AutonomousPaused {
+		t.Fatalf("pause: %+v, %v", r, err)
+	}
+	time.Sleep(60 * time.Millisecond) // let a turn in flight finish
+	before, _ := store.GetConversationMessages(ctx, convID)
+	time.Sleep(100 * time.Millisecond)
+	if after, _ := store.GetConversationMessages(ctx, convID); len(after) != len(before) {
+		t.Fatalf("paused run kept talking: %d then %d messages", len(before), len(after))
+	}
+	if r, err = o.ResumeAutonomous(ctx, r.ID); err != nil || r.State != persistence.AutonomousRunning {
+		t.Fatalf("resume: %+v, %v", r, err)
+	}
+	if r, err = o.CancelAutonomous(ctx, r.ID); err != nil || r.State != persistence.AutonomousCancelled || r.EndedAt == nil {
+		t.Fatalf("cancel: %+v, %v", r, err)
+	}
+	if stored, _ := store.GetAutonomousRun(ctx, r.ID); stored.State != persistence.AutonomousCancelled {
+		t.Fatalf("cancel not stored: %+v", stored)
+	}
+	if _, err := o.PauseAutonomous(ctx, r.ID); !errors.Is(err, ErrAutonomousEnded) {
+		t.Fatalf("expected ErrAutonomousEnded pausing a cancelled run, got %v", err)
+	}
+	if _, err := o.AutonomousRun(ctx, "00000000-0000-4000-8000-000000000000"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown run, got %v", err)
+	}
+
+	// closing the conversation ends a run
+	if r, err = o.StartAutonomous(ctx, convID, AutonomousOptions{Topic: "weekends", MaxTurns: 100, StopOn: []string{}}); err != nil {
+		t.Fatal(err)
+	}
+	closed := persistence.ConversationClosed
+	if _, err := o.UpdateConversation(ctx, convID, persistence.ConversationPatch{Status: &closed}); err != nil {
+		t.Fatal(err)
+	}
+	if r = awaitAutonomous(t, o, r.ID); r.State != persistence.AutonomousCancelled {
+		t.Fatalf("expected closing the conversation to cancel the run, got %+v", r)
+	}
+}
@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+// ContextConfig is read from conversations.metadata["context"], e.g.
+// {"recent": 10, "related": 3, "own": 2, "memories": 5}. Zero fields take the
+// defaults; negative Related, Own or Memories disable that kind of recall.
+type ContextConfig struct {
+	Recent   int `json:"recent"`   // latest messages every decider sees, default 20
+	Related  int `json:"related"`  // older messages similar to the latest one, default 5
+	Own      int `json:"own"`      // the agent's own older statements similar to the latest one, default 3
+	Memories int `json:"memories"` // long-term memories of the agent similar to the latest message, default 5
+}
+
+// metadataContextKey is the conversations.metadata key holding a ContextConfig.
+const metadataContextKey = "context"
+
+const (
+	defaultRecentMessages  = 20
+	defaultRelatedMessages = 5
+	defaultOwnMessages     = 3
+	defaultMemories        = 5
+)
+
+// contextConfigFrom extracts the context window config from conversation metadata.
+func contextConfigFrom(md map[string]interface{}) (ContextConfig, error) {
+	var cfg ContextConfig
+	if raw, ok := md[metadataContextKey]; ok && raw != nil {
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.Recent < 0 {
+		return cfg, fmt.Errorf("recent must not be negative")
+	}
+	if cfg.Recent == 0 {
+		cfg.Recent = defaultRecentMessages
+	}
+	if cfg.Related == 0 {
+		cfg.Related = defaultRelatedMessages
+	}
+	if cfg.Own == 0 {
+		cfg.Own = defaultOwnMessages
+	}
+	if cfg.Memories == 0 {
+		cfg.Memories = defaultMemories
+	}
+	return cfg, nil
+}
+
+// ErrInvalidContextConfig is returned when conversation metadata holds an unusable context config.
+var ErrInvalidContextConfig = errors.New("invalid context config")
+
+// validateContextConfig checks the context config in conversation metadata, if any.
+func validateContextConfig(md map[string]interface{}) error {
+	if _, err := contextConfigFrom(md); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContextConfig, err)
+	}
+	return nil
+}
+
+// contextConfigFor returns the conversation's context config, falling back to
+// the defaults when the metadata is missing or invalid.
+func (o *Orchestrator) contextConfigFor(ctx context.Context, conversationID string) ContextConfig {
+	md, err := o.store.GetConversationMetadata(ctx, conversationID)
+	if err != nil {
+		md = nil
+	}
+	cfg, err := contextConfigFrom(md)
+	if err != nil {
+		log.Printf("orchestrator: conversation %s: invalid context config: %v", conversationID, err)
+		cfg, _ = contextConfigFrom(nil)
+	}
+	return cfg
+}
+
+// recentMessages is the tail of a conversation deciders see: its latest
+// messages, oldest first, and whether the conversation has older ones.
+type recentMessages struct {
+	messages []agent.Message
+	older    bool
+}
+
+// loadRecent reads the latest n messages of a conversation; however long the
+// conversation, nothing older is loaded.
+func (o *Orchestrator) loadRecent(ctx context.Context, conversationID string, n int) (*recentMessages, error) {
+	// one message beyond the window tells whether older ones exist
+	page, err := o.store.ListMessages(ctx, conversationID, persistence.MessageQuery{Limit: n + 1, Desc: true})
+	if err != nil {
+		return nil, err
+	}
+	r := &recentMessages{older: len(page) > n}
+	if r.older {
+		page = page[:n]
+	}
+	slices.Reverse(page)
+	r.messages = page
+	return r, nil
+}
+
+// add appends m, dropping the messages beyond the latest n.
+func (r *recentMessages) add(m agent.Message, n int) {
+	r.messages = append(r.messages, m)
+	if len(r.messages) > n {
+		r.messages = r.messages[len(r.messages)-n:]
+		r.older = true
+	}
+}
+
+// last returns the latest message, or nil when there is none.
+func (r *recentMessages) last() *agent.Message {
+	if len(r.messages) == 0 {
+		return nil
+	}
+	return &r.messages[len(r.messages)-1]
+}
+
+// decisionState builds the context a's decider sees: the recent messages,
+// older messages recalled by similarity to the latest one, and the agent's
+// long-term memories. Recall failures are logged and leave only the recent
+// messages.
+func (o *Orchestrator) decisionState(ctx context.Context, conversationID string, a *agent.Agent, recent *recentMessages, cfg ContextConfig) *agent.ConversationState {
+	state := &agent.ConversationState{
+		ConversationID: conversationID,
+		Messages:       recent.messages,
+	}
+	if len(state.Messages) > cfg.Recent {
+		state.Messages = state.Messages[len(state.Messages)-cfg.Recent:]
+	}
+	last := state.LastMessage()
+	older := (recent.older || len(recent.messages) > len(state.Messages)) && (cfg.Related > 0 || cfg.Own > 0)
+	if last == nil || (!older && cfg.Memories <= 0) {
+		return state
+	}
+	vec, err := o.embed(ctx, last.Content)
+	if err != nil {
+		log.Printf("orchestrator: conversation %s: embed context query: %v", conversationID, err)
+		return state
+	}
+	if cfg.Memories > 0 {
+		if state.Memories, err = o.memory.Recall(ctx, string(a.ID), vec, cfg.Memories); err != nil {
+			log.Printf("orchestrator: agent %s: recall memories: %v", a.ID, err)
+		}
+	}
+	if !older {
+		return state
+	}
+	// messages in the window are already visible, so skip them when recalling
+	seen := make(map[string]bool, len(state.Messages))
+	for _, m := range state.Messages {
+		seen[m.ID] = true
+	}
+	if cfg.Own > 0 {
+		state.Own = o.recall(ctx, vec, persistence.SearchOptions{
+			ConversationID: conversationID,
+			SenderType:     agent.SenderAgent,
+			SenderID:       string(a.ID),
+			K:              cfg.Own + len(seen),
+		}, seen, cfg.Own)
+	}
+	if cfg.Related > 0 {
+		state.Related = o.recall(ctx, vec, persistence.SearchOptions{
+			ConversationID: conversationID,
+			K:              cfg.Related + len(seen),
+		}, seen, cfg.Related)
+	}
+	return state
+}
+
+// recall returns up to n of the messages most similar to vec that are not in
+// seen, oldest first, and marks them seen.
+func (o *Orchestrator) recall(ctx context.Context, vec []float32, opts persistence.SearchOptions, seen map[string]bool, n int) []agent.Message {
+	hits, err := o.store.SearchMessages(ctx, vec, opts)
+	if err != nil {
+		log.Printf("orchestrator: conversation %s: recall context: %v", opts.ConversationID, err)
+		return nil
+	}
+	var out []agent.Message
+	for _, h := range hits {
+		if len(out) == n {
+			break
+		}
+		if seen[h.ID] {
+			continue
+		}
+		seen[h.ID] = true
+		out = append(out, h.Message)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
@@ -0,0 +1,729 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+var (
+	// ErrDebateRunning is returned when a conversation already has a live debate.
+	ErrDebateRunning = errors.New("debate already running")
+	// ErrNoDebate is returned when no live debate runs in this process for the request.
+	ErrNoDebate = errors.New("no debate running")
+	// ErrDebateEnded is returned when pausing, resuming or cancelling a debate that has ended.
+	ErrDebateEnded = errors.New("debate has ended")
+)
+
+// defaultDebateRounds is used when a debate is started without a round count.
+const defaultDebateRounds = 3
+
+// debateSession is a debate running in this process. debate mirrors the stored
+// row; resumed is closed when a paused debate resumes and nil otherwise.
+type debateSession struct {
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the debate has ended and been saved
+	lineup debateLineup
+	plan   []debateRound
+	// voteWindow is how long the audience votes before and after the debate; 0 without audience votes
+	voteWindow time.Duration
+
+	mu      sync.Mutex
+	debate  persistence.Debate
+	resumed chan struct{}
+}
+
+// snapshot returns a copy of the session's debate.
+func (s *debateSession) snapshot() *persistence.Debate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.debate
+	d.Participants = append([]string(nil), s.debate.Participants...)
+	return &d
+}
+
+// update changes the debate under the lock and returns a copy of the result.
+func (s *debateSession) update(fn func(d *persistence.Debate)) *persistence.Debate {
+	s.mu.Lock()
+	fn(&s.debate)
+	s.mu.Unlock()
+	return s.snapshot()
+}
+
+// waitResumed blocks while the debate is paused.
+func (s *debateSession) waitResumed(ctx context.Context) error {
+	s.mu.Lock()
+	ch := s.resumed
+	s.mu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ch:
+		return nil
+	}
+}
+
+// StartDebate runs a debate in the format opts selects and returns when it
+// ends. Only conversation participants may take part, and the conversation
+// must be active.
+func (o *Orchestrator) StartDebate(ctx context.Context, conversationID string, opts DebateOptions) error {
+	sess, err := o.newDebate(ctx, conversationID, opts)
+	if err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sess.cancel = cancel
+	return o.runDebate(runCtx, sess)
+}
+
+// StartDebateAsync starts a debate like StartDebate but runs it in the background
+// and returns it at once. Follow it with Debate, and control it with PauseDebate,
+// ResumeDebate, CancelDebate or StopDebate.
+func (o *Orchestrator) StartDebateAsync(ctx context.Context, conversationID string, opts DebateOptions) (*persistence.Debate, error) {
+	sess, err := o.newDebate(ctx, conversationID, opts)
+	if err != nil {
+		return nil, err
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	sess.cancel = cancel
+	go func() {
+		defer cancel()
+		err := o.runDebate(runCtx, sess)
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, ErrConversationInactive) {
+			log.Printf("orchestrator: conversation %s: debate %s: %v", conversationID, sess.debate.ID, err)
+		}
+	}()
+	return sess.snapshot(), nil
+}
+
+// newDebate validates a debate request, plans it, stores the debate and
+// registers its session, refusing when the conversation already has a live
+// debate here.
+func (o *Orchestrator) newDebate(ctx context.Context, conversationID string, opts DebateOptions) (*debateSession, error) {
+	lineup, err := o.debateLineup(ctx, conversationID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDebateFormat(&opts, lineup); err != nil {
+		return nil, err
+	}
+	topic := strings.TrimSpace(opts.Topic)
+	if topic == "" {
+		topic = "讨论"
+		if recent, err := o.loadRecent(ctx, conversationID, 1); err == nil && recent.last() != nil {
+			topic = recent.last().Content
+		}
+	}
+	ids := make([]string, len(lineup.debaters))
+	for i, p := range lineup.debaters {
+		ids[i] = string(p.ID)
+	}
+	sess := &debateSession{
+		done:   make(chan struct{}),
+		lineup: *lineup,
+		plan:   planDebate(opts.Format, topic, opts.Rounds, lineup),
+		debate: persistence.Debate{
+			ConversationID: conversationID,
+			Format:         opts.Format,
+			Topic:          topic,
+			Participants:   ids,
+			Sides:          debateSides(opts.Format, lineup.debaters),
+			ModeratorID:    opts.ModeratorID,
+			JudgeID:        opts.JudgeID,
+			AudienceVote:   opts.AudienceVote,
+			State:          persistence.DebateRunning,
+		},
+	}
+	if opts.AudienceVote {
+		sess.voteWindow = time.Duration(opts.VoteSeconds) * time.Second
+	}
+	sess.debate.Rounds = len(sess.plan)
+	o.debateMu.Lock()
+	defer o.debateMu.Unlock()
+	if o.conversationDebate(conversationID) != nil {
+		return nil, ErrDebateRunning
+	}
+	if o.conversationAutonomous(conversationID) != nil {
+		return nil, ErrAutonomousRunning
+	}
+	if err := o.store.CreateDebate(ctx, &sess.debate); err != nil {
+		return nil, err
+	}
+	o.debates[sess.debate.ID] = sess
+	return sess, nil
+}
+
+// conversationDebate returns the live debate of a conversation. Callers hold debateMu.
+func (o *Orchestrator) conversationDebate(conversationID string) *debateSession {
+	for _, sess := range o.debates {
+		if sess.debate.ConversationID == conversationID {
+			return sess
+		}
+	}
+	return nil
+}
+
+// debateLineup resolves the debaters, moderator and judge of opts among the
+// conversation's participants; the conversation must be active.
+func (o *Orchestrator) debateLineup(ctx context.Context, conversationID string, opts DebateOptions) (*debateLineup, error) {
+	c, err := o.store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != persistence.ConversationActive {
+		return nil, ErrConversationInactive
+	}
+	// load conversation participants
+	agents, err := o.store.ListParticipants(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	// map agents by id
+	agentMap := make(map[string]agent.Agent)
+	for _, a := range agents {
+		agentMap[string(a.ID)] = a
+	}
+	official := func(role, id string) (*agent.Agent, error) {
+		if id == "" {
+			return nil, nil
+		}
+		a, ok := agentMap[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s is not a participant", ErrInvalidDebate, role, id)
+		}
+		return &a, nil
+	}
+	var l debateLineup
+	if l.moderator, err = official("moderator", opts.ModeratorID); err != nil {
+		return nil, err
+	}
+	if l.judge, err = official("judge", opts.JudgeID); err != nil {
+		return nil, err
+	}
+	if opts.ModeratorID != "" && opts.ModeratorID == opts.JudgeID {
+		return nil, fmt.Errorf("%w: the moderator cannot judge", ErrInvalidDebate)
+	}
+	officials := map[string]bool{opts.ModeratorID: true, opts.JudgeID: true}
+	if len(opts.Participants) == 0 {
+		for _, a := range agents {
+			if !officials[string(a.ID)] {
+				l.debaters = append(l.debaters, a)
+			}
+		}
+		return &l, nil
+	}
+	for _, pid := range opts.Participants {
+		if officials[pid] {
+			return nil, fmt.Errorf("%w: %s cannot both debate and moderate or judge", ErrInvalidDebate, pid)
+		}
+		if a, ok := agentMap[pid]; ok {
+			l.debaters = append(l.debaters, a)
+		}
+	}
+	return &l, nil
+}
+
+// Debate returns a debate's progress, live when it runs in this process.
+func (o *Orchestrator) Debate(ctx context.Context, id string) (*persistence.Debate, error) {
+	o.debateMu.Lock()
+	sess := o.debates[id]
+	o.debateMu.Unlock()
+	if sess != nil {
+		return sess.snapshot(), nil
+	}
+	return o.store.GetDebate(ctx, id)
+}
+
+// liveDebate returns the session of a debate running in this process, or the
+// reason there is none: ErrNotFound, ErrDebateEnded or ErrNoDebate.
+func (o *Orchestrator) liveDebate(ctx context.Context, id string) (*debateSession, error) {
+	o.debateMu.Lock()
+	sess := o.debates[id]
+	o.debateMu.Unlock()
+	if sess != nil {
+		return sess, nil
+	}
+	d, err := o.store.GetDebate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Ended() {
+		return nil, ErrDebateEnded
+	}
+	return nil, ErrNoDebate
+}
+
+// PauseDebate pauses a debate before its next turn. Pausing a paused debate is a no-op.
+func (o *Orchestrator) PauseDebate(ctx context.Context, id string) (*persistence.Debate, error) {
+	sess, err := o.liveDebate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	d := sess.update(func(d *persistence.Debate) {
+		if d.State == persistence.DebateRunning {
+			d.State = persistence.DebatePaused
+			sess.resumed = make(chan struct{})
+			changed = true
+		}
+	})
+	if d.Ended() {
+		return d, ErrDebateEnded
+	}
+	if changed {
+		o.saveDebate(ctx, d)
+		o.emitDebate(ctx, events.TypeDebatePaused, d)
+	}
+	return d, nil
+}
+
+// ResumeDebate continues a paused debate; the conversation must be active.
+// Resuming a running debate is a no-op.
+func (o *Orchestrator) ResumeDebate(ctx context.Context, id string) (*persistence.Debate, error) {
+	sess, err := o.liveDebate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !o.takesTurns(ctx, sess.snapshot().ConversationID) {
+		return nil, ErrConversationInactive
+	}
+	changed := false
+	d := sess.update(func(d *persistence.Debate) {
+		if d.State == persistence.DebatePaused {
+			d.State = persistence.DebateRunning
+			close(sess.resumed)
+			sess.resumed = nil
+			changed = true
+		}
+	})
+	if d.Ended() {
+		return d, ErrDebateEnded
+	}
+	if changed {
+		o.saveDebate(ctx, d)
+		o.emitDebate(ctx, events.TypeDebateResumed, d)
+	}
+	return d, nil
+}
+
+// CancelDebate stops a debate and returns it once it has ended.
+func (o *Orchestrator) CancelDebate(ctx context.Context, id string) (*persistence.Debate, error) {
+	sess, err := o.liveDebate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sess.cancel()
+	select {
+	case <-sess.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return sess.snapshot(), nil
+}
+
+// StopDebate cancels the conversation's live debate without waiting for it to end.
+func (o *Orchestrator) StopDebate(conversationID string) error {
+	o.debateMu.Lock()
+	defer o.debateMu.Unlock()
+	sess := o.conversationDebate(conversationID)
+	if sess == nil {
+		return ErrNoDebate
+	}
+	sess.cancel()
+	delete(o.debates, sess.debate.ID)
+	return nil
+}
+
+// pauseConversationDebate pauses the conversation's live debate, if any.
+func (o *Orchestrator) pauseConversationDebate(ctx context.Context, conversationID string) {
+	o.debateMu.Lock()
+	sess := o.conversationDebate(conversationID)
+	o.debateMu.Unlock()
+	if sess != nil {
+		_, _ = o.PauseDebate(ctx, sess.snapshot().ID)
+	}
+}
+
+// runDebate runs the rounds of the debate plan, asking each speaker's decider
+// for its turn. It stops early when ctx is cancelled or the conversation stops
+// taking turns, waits while the debate is paused, and records how the debate
+// ended. With a judge, every round is scored and a finished debate gets a verdict.
+func (o *Orchestrator) runDebate(ctx context.Context, sess *debateSession) (err error) {
+	d := sess.snapshot()
+	conversationID := d.ConversationID
+	o.emitDebate(ctx, events.TypeDebateStarted, d)
+	window := o.contextConfigFor(ctx, conversationID)
+	recent, err := o.loadRecent(ctx, conversationID, window.Recent)
+
+	var result *persistence.DebateResult
+	if sess.lineup.judge != nil || d.AudienceVote {
+		result = &persistence.DebateResult{DebateID: d.ID, Totals: make(map[string]float64)}
+		if judge := sess.lineup.judge; judge != nil {
+			result.JudgeID = string(judge.ID)
+		}
+	}
+	// every debater remembers the debate, however far it got
+	spokeIn := 0
+	defer func() {
+		if err == nil && result != nil {
+			o.concludeDebate(ctx, sess, result)
+		}
+		o.endDebate(sess, err)
+		if spokeIn > 0 {
+			o.rememberDebate(conversationID, sess.lineup.debaters, d.Topic, spokeIn)
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	var pre *persistence.Poll
+	if d.AudienceVote {
+		if pre, err = o.audienceVote(ctx, sess, persistence.PollBeforeDebate); err != nil {
+			return err
+		}
+	}
+	for i, round := range sess.plan {
+		r := i + 1
+		d = sess.update(func(d *persistence.Debate) { d.Round, d.Phase, d.SpeakerID = r, round.phase, "" })
+		o.saveDebate(ctx, d)
+		o.emitDebate(ctx, events.TypeDebateRoundStarted, d)
+		var said []agent.Message
+		for _, turn := range round.turns {
+			if err := sess.waitResumed(ctx); err != nil {
+				return err
+			}
+			if !o.takesTurns(ctx, conversationID) {
+				return ErrConversationInactive
+			}
+			d = sess.update(func(d *persistence.Debate) { d.SpeakerID = string(turn.speaker.ID) })
+			o.saveDebate(ctx, d)
+			if m := o.debateTurn(ctx, d, turn, recent, window); m != nil {
+				recent.add(*m, window.Recent)
+				said = append(said, *m)
+				if turn.scored {
+					spokeIn = r
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(o.responseDelay):
+			}
+		}
+		o.emitDebate(ctx, events.TypeDebateRoundEnded, sess.snapshot())
+		if sess.lineup.judge != nil {
+			o.scoreRound(ctx, sess.snapshot(), sess.lineup.judge, round, said, result)
+		}
+	}
+	if pre != nil {
+		post, err := o.audienceVote(ctx, sess, persistence.PollAfterDebate)
+		if err != nil {
+			return err
+		}
+		result.Audience = audienceResult(pre, post, audienceKeys(d, sess.lineup.debaters))
+	}
+	return nil
+}
+
+// audienceVote polls the audience on the debate for the session's vote window
+// and returns the closed poll with its tally.
+func (o *Orchestrator) audienceVote(ctx context.Context, sess *debateSession, stage string) (*persistence.Poll, error) {
+	d := sess.update(func(d *persistence.Debate) { d.Phase, d.SpeakerID = debatePhaseVote, "" })
+	o.saveDebate(ctx, d)
+	question := fmt.Sprintf("Before the debate: where do you stand on %q?", d.Topic)
+	if stage == persistence.PollAfterDebate {
+		question = fmt.Sprintf("After the debate: where do you stand on %q?", d.Topic)
+	}
+	deadline := time.Now().UTC().Add(sess.voteWindow)
+	p := &persistence.Poll{
+		ConversationID: d.ConversationID,
+		Question:       question,
+		Options:        audienceLabels(d, sess.lineup.debaters),
+		CreatedBy:      systemSender.ID,
+		DebateID:       d.ID,
+		Stage:          stage,
+		Deadline:       &deadline,
+	}
+	if err := o.openPoll(ctx, p, systemSender); err != nil {
+		return nil, err
+	}
+	if err := o.waitPoll(ctx, p.ID); err != nil {
+		return nil, err
+	}
+	return o.store.GetPoll(ctx, p.ID)
+}
+
+// debateTurn asks the speaker's decider for its turn in the debate and posts
+// the result, tagged with the debate, round, phase and side. It returns nil
+// when the speaker passes or the message cannot be stored.
+func (o *Orchestrator) debateTurn(ctx context.Context, d *persistence.Debate, turn debateTurn, recent *recentMessages, window ContextConfig) *agent.Message {
+	state := o.decisionState(ctx, d.ConversationID, turn.speaker, recent, window)
+	state.Directive = turn.directive
+	action, err := o.deciderFor(turn.speaker).DecideAction(ctx, turn.speaker, state)
+	if err != nil || action == nil {
+		return nil
+	}
+	m := debateMessage(d, turn.speaker, action)
+	if err := o.postMessage(ctx, m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// debateMessage builds the message a speaker posts in a debate.
+func debateMessage(d *persistence.Debate, a *agent.Agent, action *agent.Action) *agent.Message {
+	m := agentMessage(d.ConversationID, a, action)
+	m.Metadata["debate_id"] = d.ID
+	m.Metadata["round"] = d.Round
+	if d.Phase != "" {
+		m.Metadata["phase"] = d.Phase
+	}
+	if side := d.Sides[string(a.ID)]; side != "" {
+		m.Metadata["side"] = side
+	}
+	return m
+}
+
+// scoreRound has the judge score the debaters of a round from what was said
+// in it, adds the scores to result, saves it and announces them.
+func (o *Orchestrator) scoreRound(ctx context.Context, d *persistence.Debate, judge *agent.Agent, round debateRound, said []agent.Message, result *persistence.DebateResult) {
+	speakers := round.speakers()
+	if len(speakers) == 0 {
+		return
+	}
+	state := &agent.ConversationState{
+		ConversationID: d.ConversationID,
+		Messages:       said,
+		Directive:      fmt.Sprintf("This was round %d of %d of the debate on %q.", d.Round, d.Rounds, d.Topic),
+	}
+	scores := agent.DefaultScores(state, speakers)
+	if s, ok := o.deciderFor(judge).(agent.Scorer); ok {
+		if sc, err := s.Score(ctx, judge, state, speakers); err == nil {
+			scores = sc
+		} else {
+			log.Printf("orchestrator: conversation %s: debate %s: judge %s: %v", d.ConversationID, d.ID, judge.ID, err)
+		}
+	}
+	rs := persistence.RoundScores{Round: d.Round, Phase: d.Phase, Scores: make(map[string]float64, len(speakers))}
+	parts := make([]string, len(speakers))
+	for i, sp := range speakers {
+		score := scores[sp.ID]
+		rs.Scores[string(sp.ID)] = score
+		result.Totals[string(sp.ID)] += score
+		parts[i] = fmt.Sprintf("%s %g", sp.Name, score)
+	}
+	result.Rounds = append(result.Rounds, rs)
+	o.saveDebateResult(ctx, d, result)
+
+	label := fmt.Sprintf("Round %d", d.Round)
+	if d.Phase != "" {
+		label += " (" + d.Phase + ")"
+	}
+	o.judgeSays(ctx, d, judge, fmt.Sprintf("%s scores: %s.", label, strings.Join(parts, ", ")))
+	payload := debatePayload(d)
+	payload.Scores = rs.Scores
+	o.emit(ctx, events.TypeDebateScored, d.ConversationID, "", systemSender, payload)
+}
+
+// concludeDebate completes the result of a finished debate. The judge names
+// the debater with the highest total and, in formats with sides, the side
+// whose debaters average the highest total. When the audience voted it decides
+// instead, and the side or debater whose share of the vote grew the most wins.
+// Ties leave no winner. The verdict is saved, announced by the judge if there
+// is one, and sent as debate.judged.
+func (o *Orchestrator) concludeDebate(ctx context.Context, sess *debateSession, result *persistence.DebateResult) {
+	d := sess.snapshot()
+	judge := sess.lineup.judge
+	names := make(map[string]string, len(sess.lineup.debaters))
+	for _, a := range sess.lineup.debaters {
+		names[string(a.ID)] = a.Name
+	}
+	result.Final = true
+	var verdict string
+	if judge != nil {
+		result.DecidedBy = persistence.DecidedByJudge
+		result.WinnerID = topScore(result.Totals)
+		verdict = "Verdict: a draw."
+		if result.WinnerID != "" {
+			verdict = fmt.Sprintf("Verdict: %s wins with %g points.", names[result.WinnerID], result.Totals[result.WinnerID])
+		}
+		if len(d.Sides) > 0 {
+			sum, count := make(map[string]float64), make(map[string]int)
+			for id, side := range d.Sides {
+				sum[side] += result.Totals[id]
+				count[side]++
+			}
+			result.SideTotals = make(map[string]float64, len(sum))
+			for side, total := range sum {
+				result.SideTotals[side] = total / float64(count[side])
+			}
+			result.WinningSide = topScore(result.SideTotals)
+			if result.WinningSide != "" {
+				verdict += fmt.Sprintf(" The %s side carries the debate.", result.WinningSide)
+			} else {
+				verdict += " The sides are level."
+			}
+		}
+	}
+	if a := result.Audience; a != nil {
+		result.DecidedBy = persistence.DecidedByAudience
+		winner := audienceWinner(a)
+		label := winner
+		if len(d.Sides) > 0 {
+			result.WinningSide = winner
+		} else {
+			result.WinnerID, label = winner, names[winner]
+		}
+		audience := "Audience verdict: a draw."
+		if winner != "" {
+			audience = fmt.Sprintf("Audience verdict: %s wins, gaining %.1f points of the vote.", label, a.Swing[indexOf(a.Keys, winner)])
+		}
+		if best := topScore(result.Totals); judge != nil && best != "" {
+			audience += fmt.Sprintf(" Best speaker: %s with %g points.", names[best], result.Totals[best])
+		}
+		verdict = audience
+	}
+	o.saveDebateResult(ctx, d, result)
+	if judge != nil {
+		o.judgeSays(ctx, d, judge, verdict)
+	}
+	payload := debatePayload(d)
+	payload.Scores, payload.WinnerID, payload.WinningSide = result.Totals, result.WinnerID, result.WinningSide
+	o.emit(ctx, events.TypeDebateJudged, d.ConversationID, "", systemSender, payload)
+}
+
+// topScore returns the key with the highest score, or "" when the top is shared.
+func topScore(scores map[string]float64) string {
+	best, top, shared := "", 0.0, false
+	for k, v := range scores {
+		switch {
+		case best == "" || v > top:
+			best, top, shared = k, v, false
+		case v == top:
+			shared = true
+		}
+	}
+	if shared {
+		return ""
+	}
+	return best
+}
+
+// judgeSays posts an announcement of the judge in the debate.
+func (o *Orchestrator) judgeSays(ctx context.Context, d *persistence.Debate, judge *agent.Agent, text string) {
+	m := debateMessage(d, judge, &agent.Action{Type: "speak", Payload: text})
+	m.Metadata["judge"] = true
+	if err := o.postMessage(ctx, m); err != nil {
+		log.Printf("orchestrator: conversation %s: debate %s: post verdict: %v", d.ConversationID, d.ID, err)
+	}
+}
+
+// saveDebateResult stores a judge's result; failures are logged, the debate goes on.
+func (o *Orchestrator) saveDebateResult(ctx context.Context, d *persistence.Debate, result *persistence.DebateResult) {
+	if err := o.store.SaveDebateResult(ctx, result); err != nil {
+		log.Printf("orchestrator: conversation %s: save debate result %s: %v", d.ConversationID, d.ID, err)
+	}
+}
+
+// DebateResult returns the judge's result of a debate: the scores so far while
+// it runs, and the verdict once Final is set.
+func (o *Orchestrator) DebateResult(ctx context.Context, debateID string) (*persistence.DebateResult, error) {
+	return o.store.GetDebateResult(ctx, debateID)
+}
+
+// endDebate records the final state of a debate that stopped with err,
+// announces it and unregisters the session.
+func (o *Orchestrator) endDebate(sess *debateSession, err error) {
+	// the run context may be cancelled; the final state must still be saved
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	now := time.Now().UTC()
+	d := sess.update(func(d *persistence.Debate) {
+		switch {
+		case err == nil:
+			d.State = persistence.DebateFinished
+		case errors.Is(err, context.Canceled):
+			d.State = persistence.DebateCancelled
+		default:
+			d.State, d.Error = persistence.DebateFailed, err.Error()
+		}
+		d.SpeakerID, d.EndedAt = "", &now
+		if sess.resumed != nil {
+			close(sess.resumed)
+			sess.resumed = nil
+		}
+	})
+	o.saveDebate(ctx, d)
+	o.emitDebate(ctx, events.TypeDebateEnded, d)
+	o.debateMu.Lock()
+	if o.debates[d.ID] == sess {
+		delete(o.debates, d.ID)
+	}
+	o.debateMu.Unlock()
+	close(sess.done)
+}
+
+// saveDebate stores a debate's progress; failures are logged, the debate goes on.
+func (o *Orchestrator) saveDebate(ctx context.Context, d *persistence.Debate) {
+	if err := o.store.UpdateDebate(ctx, d); err != nil {
+		log.Printf("orchestrator: conversation %s: save debate %s: %v", d.ConversationID, d.ID, err)
+	}
+}
+
+// emitDebate publishes a debate.* event describing d.
+func (o *Orchestrator) emitDebate(ctx context.Context, typ string, d *persistence.Debate) {
+	payload := debatePayload(d)
+	if typ == events.TypeDebateStarted {
+		payload.Participants, payload.Sides = d.Participants, d.Sides
+	}
+	o.emit(ctx, typ, d.ConversationID, "", systemSender, payload)
+}
+
+// debatePayload describes d for a debate.* event.
+func debatePayload(d *persistence.Debate) events.DebatePayload {
+	return events.DebatePayload{
+		DebateID: d.ID,
+		Format:   d.Format,
+		State:    d.State,
+		Round:    d.Round,
+		Rounds:   d.Rounds,
+		Phase:    d.Phase,
+		Error:    d.Error,
+	}
+}
+
+// rememberDebate stores an episode memory of the debate for each participant.
+func (o *Orchestrator) rememberDebate(conversationID string, participants []agent.Agent, topic string, rounds int) {
+	mems := make([]agent.Memory, 0, len(participants))
+	for _, p := range participants {
+		var others []string
+		for _, q := range participants {
+			if q.ID != p.ID {
+				others = append(others, q.Name)
+			}
+		}
+		mems = append(mems, agent.Memory{
+			AgentID:        string(p.ID),
+			Kind:           agent.MemoryEpisode,
+			Subject:        topic,
+			Content:        fmt.Sprintf("Debated %q with %s over %d round(s)", topic, strings.Join(others, ", "), rounds),
+			ConversationID: conversationID,
+		})
+	}
+	o.remember(conversationID, func(ctx context.Context) error {
+		return o.memory.Save(ctx, mems)
+	})
+}
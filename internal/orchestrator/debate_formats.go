@@ -0,0 +1,362 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+// Debate formats.
+const (
+	// FormatOpen is a round-robin: every debater speaks once per round.
+	FormatOpen = "open"
+	// FormatOxford splits debaters into sides for and against the motion, with
+	// an opening round, rebuttal rounds and a closing round.
+	FormatOxford = "oxford"
+	// FormatLincolnDouglas is a one-on-one debate: constructives with
+	// cross-examination, then rebuttals, affirmative speaking first and last.
+	FormatLincolnDouglas = "lincoln_douglas"
+	// FormatPanel has a moderator put a question to the panel each round.
+	FormatPanel = "panel"
+)
+
+// Sides of the formats that have them.
+const (
+	SideFor         = "for"
+	SideAgainst     = "against"
+	SideAffirmative = "affirmative"
+	SideNegative    = "negative"
+)
+
+const (
+	// debatePhaseVote is the phase of a debate while its audience votes.
+	debatePhaseVote = "audience_vote"
+	// audienceUndecided is the audience poll option, and key, of voters who back no one.
+	audienceUndecided = "undecided"
+	// defaultVoteSeconds is how long the audience votes when a debate does not say.
+	defaultVoteSeconds = 60
+)
+
+// ErrInvalidDebate is returned for an unknown format or a line-up or round
+// count the format cannot run with.
+var ErrInvalidDebate = errors.New("invalid debate")
+
+// DebateOptions configures a debate. Zero fields take the defaults.
+type DebateOptions struct {
+	// Participants are the debaters in speaking order; empty selects every
+	// conversation participant except the moderator and the judge.
+	Participants []string `json:"participants"`
+	// Rounds defaults to 3. Oxford debates need at least 3 (opening, rebuttals,
+	// closing); Lincoln-Douglas debates always have 2.
+	Rounds int    `json:"rounds"`
+	Format string `json:"format"` // FormatOpen (default), FormatOxford, FormatLincolnDouglas or FormatPanel
+	// Topic is the motion or question; it defaults to the latest message.
+	Topic string `json:"topic"`
+	// ModeratorID is a participant who opens and closes the debate; panels require one.
+	ModeratorID string `json:"moderator_id"`
+	// JudgeID is a participant who scores every debater each round and names a winner.
+	JudgeID string `json:"judge_id"`
+	// AudienceVote polls the conversation before and after the debate, and the
+	// audience's swing decides the winner. Each poll is open for VoteSeconds
+	// (default 60) or until closed.
+	AudienceVote bool `json:"audience_vote"`
+	VoteSeconds  int  `json:"vote_seconds"`
+}
+
+// debateLineup is who takes part in a debate.
+type debateLineup struct {
+	debaters  []agent.Agent
+	moderator *agent.Agent
+	judge     *agent.Agent
+}
+
+// debateTurn is one speaking turn of a debate plan.
+type debateTurn struct {
+	speaker   *agent.Agent
+	directive string
+	scored    bool // a debater's turn, which the judge scores
+}
+
+// debateRound is a round of a debate plan; the judge scores it when it ends.
+type debateRound struct {
+	phase string
+	turns []debateTurn
+}
+
+// speakers returns the debaters with scored turns in the round, in order of first turn.
+func (r debateRound) speakers() []agent.Agent {
+	var out []agent.Agent
+	seen := make(map[agent.AgentID]bool)
+	for _, t := range r.turns {
+		if t.scored && !seen[t.speaker.ID] {
+			seen[t.speaker.ID] = true
+			out = append(out, *t.speaker)
+		}
+	}
+	return out
+}
+
+// checkDebateFormat normalizes opts.Format and opts.Rounds and checks that the
+// format can run with the line-up.
+func checkDebateFormat(opts *DebateOptions, l *debateLineup) error {
+	if opts.Format == "" {
+		opts.Format = FormatOpen
+	}
+	if len(l.debaters) < 2 {
+		return fmt.Errorf("%w: need at least two participants", ErrInvalidDebate)
+	}
+	if opts.VoteSeconds < 0 {
+		return fmt.Errorf("%w: vote_seconds must not be negative", ErrInvalidDebate)
+	}
+	if opts.AudienceVote && opts.VoteSeconds == 0 {
+		opts.VoteSeconds = defaultVoteSeconds
+	}
+	switch opts.Format {
+	case FormatOpen:
+		if opts.Rounds <= 0 {
+			opts.Rounds = defaultDebateRounds
+		}
+	case FormatOxford:
+		if opts.Rounds <= 0 {
+			opts.Rounds = defaultDebateRounds
+		}
+		if opts.Rounds < 3 {
+			return fmt.Errorf("%w: oxford debates need at least 3 rounds", ErrInvalidDebate)
+		}
+	case FormatLincolnDouglas:
+		if len(l.debaters) != 2 {
+			return fmt.Errorf("%w: lincoln_douglas debates need exactly two debaters", ErrInvalidDebate)
+		}
+		if opts.Rounds != 0 && opts.Rounds != 2 {
+			return fmt.Errorf("%w: lincoln_douglas debates have 2 rounds", ErrInvalidDebate)
+		}
+		opts.Rounds = 2
+	case FormatPanel:
+		if l.moderator == nil {
+			return fmt.Errorf("%w: panel debates need a moderator", ErrInvalidDebate)
+		}
+		if opts.Rounds <= 0 {
+			opts.Rounds = defaultDebateRounds
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidDebate, opts.Format)
+	}
+	return nil
+}
+
+// debateSides assigns the debaters of sided formats to their sides: Oxford
+// debaters alternate for and against, Lincoln-Douglas has the first affirm.
+// Other formats have no sides.
+func debateSides(format string, debaters []agent.Agent) map[string]string {
+	var names [2]string
+	switch format {
+	case FormatOxford:
+		names = [2]string{SideFor, SideAgainst}
+	case FormatLincolnDouglas:
+		names = [2]string{SideAffirmative, SideNegative}
+	default:
+		return nil
+	}
+	sides := make(map[string]string, len(debaters))
+	for i, d := range debaters {
+		sides[string(d.ID)] = names[i%2]
+	}
+	return sides
+}
+
+// planDebate lays out the rounds of a checked debate. A moderator opens the
+// first round and closes the last in every format.
+func planDebate(format, topic string, rounds int, l *debateLineup) []debateRound {
+	var plan []debateRound
+	switch format {
+	case FormatOxford:
+		plan = planOxford(topic, rounds, l.debaters)
+	case FormatLincolnDouglas:
+		plan = planLincolnDouglas(topic, &l.debaters[0], &l.debaters[1])
+	case FormatPanel:
+		plan = planPanel(topic, rounds, l.debaters, l.moderator)
+	default:
+		plan = planOpen(topic, rounds, l.debaters)
+	}
+	if m := l.moderator; m != nil {
+		names := make([]string, len(l.debaters))
+		for i, d := range l.debaters {
+			names[i] = d.Name
+		}
+		intro := debateTurn{speaker: m, directive: fmt.Sprintf("As moderator, open the debate on %q: introduce the topic and the speakers, %s.", topic, strings.Join(names, ", "))}
+		outro := debateTurn{speaker: m, directive: "As moderator, close the debate: thank the speakers and sum up the main arguments."}
+		plan[0].turns = append([]debateTurn{intro}, plan[0].turns...)
+		last := &plan[len(plan)-1]
+		last.turns = append(last.turns, outro)
+	}
+	return plan
+}
+
+func planOpen(topic string, rounds int, debaters []agent.Agent) []debateRound {
+	plan := make([]debateRound, rounds)
+	for r := range plan {
+		for i := range debaters {
+			plan[r].turns = append(plan[r].turns, debateTurn{
+				speaker:   &debaters[i],
+				directive: fmt.Sprintf("Round %d of %d on %q: argue your position and answer the others.", r+1, rounds, topic),
+				scored:    true,
+			})
+		}
+	}
+	return plan
+}
+
+func planOxford(topic string, rounds int, debaters []agent.Agent) []debateRound {
+	sides := debateSides(FormatOxford, debaters)
+	plan := make([]debateRound, rounds)
+	for r := range plan {
+		var phase, task string
+		switch r {
+		case 0:
+			phase, task = "opening", "Opening statement %s the motion %q: set out your case."
+		case rounds - 1:
+			phase, task = "closing", "Closing statement %s the motion %q: sum up why the house should side with you."
+		default:
+			phase, task = "rebuttal", "Rebuttal %s the motion %q: answer the other side's strongest points."
+		}
+		plan[r].phase = phase
+		for i := range debaters {
+			plan[r].turns = append(plan[r].turns, debateTurn{
+				speaker:   &debaters[i],
+				directive: fmt.Sprintf(task, sides[string(debaters[i].ID)], topic),
+				scored:    true,
+			})
+		}
+	}
+	return plan
+}
+
+func planLincolnDouglas(topic string, aff, neg *agent.Agent) []debateRound {
+	turn := func(a *agent.Agent, directive string) debateTurn {
+		return debateTurn{speaker: a, directive: directive, scored: true}
+	}
+	return []debateRound{
+		{phase: "constructive", turns: []debateTurn{
+			turn(aff, fmt.Sprintf("Affirmative constructive on the resolution %q: present your value, your criterion and your case.", topic)),
+			turn(neg, "Cross-examine the affirmative: ask one pointed question about their case."),
+			turn(aff, "Answer the negative's cross-examination question."),
+			turn(neg, fmt.Sprintf("Negative constructive on the resolution %q: present your case and attack the affirmative's.", topic)),
+			turn(aff, "Cross-examine the negative: ask one pointed question about their case."),
+			turn(neg, "Answer the affirmative's cross-examination question."),
+		}},
+		{phase: "rebuttal", turns: []debateTurn{
+			turn(aff, "First affirmative rebuttal: rebuild your case and answer the negative's attacks."),
+			turn(neg, "Negative rebuttal: make your final case and explain why you win the round."),
+			turn(aff, "Final affirmative rebuttal: explain why you win the round."),
+		}},
+	}
+}
+
+func planPanel(topic string, rounds int, panelists []agent.Agent, moderator *agent.Agent) []debateRound {
+	plan := make([]debateRound, rounds)
+	for r := range plan {
+		plan[r].phase = "question"
+		plan[r].turns = append(plan[r].turns, debateTurn{
+			speaker:   moderator,
+			directive: fmt.Sprintf("As moderator, put question %d of %d about %q to the panel.", r+1, rounds, topic),
+		})
+		for i := range panelists {
+			plan[r].turns = append(plan[r].turns, debateTurn{
+				speaker:   &panelists[i],
+				directive: "Answer the moderator's question from your own expertise, engaging with the other panelists.",
+				scored:    true,
+			})
+		}
+	}
+	return plan
+}
+
+// audienceKeys returns what the options of a debate's audience polls stand
+// for: the sides in formats that have them, else the debaters' ids, and
+// audienceUndecided last.
+func audienceKeys(d *persistence.Debate, debaters []agent.Agent) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, a := range debaters {
+		key := string(a.ID)
+		if len(d.Sides) > 0 {
+			key = d.Sides[key]
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return append(keys, audienceUndecided)
+}
+
+// audienceLabels returns the options of a debate's audience polls, parallel to audienceKeys.
+func audienceLabels(d *persistence.Debate, debaters []agent.Agent) []string {
+	names := make(map[string]string, len(debaters))
+	for _, a := range debaters {
+		names[string(a.ID)] = a.Name
+	}
+	keys := audienceKeys(d, debaters)
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		labels[i] = key
+		if name, ok := names[key]; ok && len(d.Sides) == 0 {
+			labels[i] = name
+		}
+	}
+	return labels
+}
+
+// audienceResult compares the tallies of the polls before and after a debate.
+func audienceResult(pre, post *persistence.Poll, keys []string) *persistence.AudienceVote {
+	a := &persistence.AudienceVote{
+		PrePollID:  pre.ID,
+		PostPollID: post.ID,
+		Keys:       keys,
+		Pre:        pre.Counts,
+		Post:       post.Counts,
+		Swing:      make([]float64, len(keys)),
+	}
+	share := func(counts []int, total, i int) float64 {
+		if total == 0 || i >= len(counts) {
+			return 0
+		}
+		return 100 * float64(counts[i]) / float64(total)
+	}
+	for i := range keys {
+		a.Swing[i] = share(post.Counts, post.Total(), i) - share(pre.Counts, pre.Total(), i)
+	}
+	return a
+}
+
+// audienceWinner returns the key whose share of the vote grew the most, or ""
+// on a tie or when nobody voted after the debate. Undecided voters win nothing.
+func audienceWinner(a *persistence.AudienceVote) string {
+	total := 0
+	for _, n := range a.Post {
+		total += n
+	}
+	if total == 0 {
+		return ""
+	}
+	swings := make(map[string]float64, len(a.Keys))
+	for i, key := range a.Keys {
+		if key != audienceUndecided {
+			swings[key] = a.Swing[i]
+		}
+	}
+	return topScore(swings)
+}
+
+// indexOf returns the index of s in list, or -1.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
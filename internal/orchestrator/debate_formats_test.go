@@ -0,0 +1,183 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+func TestCheckDebateFormat(t *testing.T) {
+	two := []agent.Agent{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}}
+	three := append(two, agent.Agent{ID: "c", Name: "C"})
+	mod := &agent.Agent{ID: "m", Name: "M"}
+	tests := []struct {
+		name   string
+		opts   DebateOptions
+		lineup debateLineup
+		rounds int // expected rounds when valid
+		valid  bool
+	}{
+		{"open defaults", DebateOptions{}, debateLineup{debaters: two}, 3, true},
+		{"one debater", DebateOptions{}, debateLineup{debaters: two[:1]}, 0, false},
+		{"oxford", DebateOptions{Format: FormatOxford, Rounds: 4}, debateLineup{debaters: three}, 4, true},
+		{"oxford too short", DebateOptions{Format: FormatOxford, Rounds: 2}, debateLineup{debaters: two}, 0, false},
+		{"lincoln-douglas", DebateOptions{Format: FormatLincolnDouglas}, debateLineup{debaters: two}, 2, true},
+		{"lincoln-douglas three debaters", DebateOptions{Format: FormatLincolnDouglas}, debateLineup{debaters: three}, 0, false},
+		{"lincoln-douglas rounds", DebateOptions{Format: FormatLincolnDouglas, Rounds: 5}, debateLineup{debaters: two}, 0, false},
+		{"panel", DebateOptions{Format: FormatPanel, Rounds: 2}, debateLineup{debaters: two, moderator: mod}, 2, true},
+		{"panel without moderator", DebateOptions{Format: FormatPanel}, debateLineup{debaters: two}, 0, false},
+		{"unknown", DebateOptions{Format: "duel"}, debateLineup{debaters: two}, 0, false},
+	}
+	for _, tt := range tests {
+		err := checkDebateFormat(&tt.opts, &tt.lineup)
+		if !tt.valid {
+			if !errors.Is(err, ErrInvalidDebate) {
+				t.Errorf("%s: expected ErrInvalidDebate, got %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || tt.opts.Rounds != tt.rounds {
+			t.Errorf("%s: expected %d rounds, got %d (%v)", tt.name, tt.rounds, tt.opts.Rounds, err)
+		}
+	}
+}
+
+func TestPlanDebate(t *testing.T) {
+	debaters := []agent.Agent{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}}
+	mod := &agent.Agent{ID: "m", Name: "M"}
+
+	plan := planDebate(FormatOxford, "cats", 4, &debateLineup{debaters: debaters, moderator: mod})
+	var phases []string
+	for _, r := range plan {
+		phases = append(phases, r.phase)
+	}
+	if strings.Join(phases, ",") != "opening,rebuttal,rebuttal,closing" {
+		t.Fatalf("unexpected oxford phases %v", phases)
+	}
+	first, last := plan[0].turns, plan[3].turns
+	if first[0].speaker != mod || first[0].scored || last[len(last)-1].speaker != mod {
+		t.Fatal("expected the moderator to open and close the debate")
+	}
+	if !strings.Contains(first[1].directive, "for the motion") || !strings.Contains(first[2].directive, "against the motion") {
+		t.Fatalf("expected alternating sides, got %q and %q", first[1].directive, first[2].directive)
+	}
+
+	plan = planDebate(FormatLincolnDouglas, "cats", 2, &debateLineup{debaters: debaters})
+	if len(plan) != 2 || len(plan[0].turns) != 6 || len(plan[1].turns) != 3 {
+		t.Fatalf("unexpected lincoln-douglas plan %+v", plan)
+	}
+	if r := plan[1].turns; r[0].speaker.ID != "a" || r[2].speaker.ID != "a" {
+		t.Fatal("expected the affirmative to rebut first and last")
+	}
+
+	plan = planDebate(FormatPanel, "cats", 2, &debateLineup{debaters: debaters, moderator: mod})
+	for i, r := range plan {
+		if r.turns[0].speaker != mod || r.turns[0].scored || len(r.speakers()) != 2 {
+			t.Fatalf("round %d: expected a moderator question and two scored answers, got %+v", i+1, r)
+		}
+	}
+}
+
+func TestJudgedDebate(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	// Alice argues at length, so the default scoring favors her
+	long := map[string]interface{}{
+		agent.ProfileDeciderKey: "scripted",
+		agent.ProfileDeciderConfigKey: map[string]interface{}{"loop": true, "lines": []interface{}{
+			"cats are independent clean quiet affectionate curious playful graceful agile hunters that need little space " +
+				"no walks and keep mice away while purring softly on long winter evenings beside warm fires",
+		}},
+	}
+	alice, _ := store.CreateAgent(ctx, "Alice", "cats", long)
+	bob, _ := store.CreateAgent(ctx, "Bob", "dogs", nil)
+	mod, _ := store.CreateAgent(ctx, "Mo", "host", nil)
+	judge, _ := store.CreateAgent(ctx, "Jude", "judge", nil)
+	convID, _ := o.CreateConversation(ctx, "debate", []string{alice, bob, mod, judge}, nil)
+	sub, _ := broker.Subscribe(ctx, "conversation:"+convID)
+	defer sub.Close()
+
+	opts := DebateOptions{Format: FormatOxford, Topic: "cats beat dogs", ModeratorID: mod, JudgeID: judge}
+	if err := o.StartDebate(ctx, convID, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var scored, judged int
+	for judged == 0 {
+		evt := nextEvent(t, sub)
+		switch evt.Type {
+		case events.TypeDebateScored:
+			scored++
+		case events.TypeDebateJudged:
+			judged++
+			var p events.DebatePayload
+			if err := evt.Decode(&p); err != nil || p.WinnerID != alice || p.WinningSide != SideFor {
+				t.Fatalf("unexpected verdict %+v (%v)", p, err)
+			}
+		}
+	}
+	if scored != 3 {
+		t.Fatalf("expected a score for each of 3 rounds, got %d", scored)
+	}
+
+	debates := debateIDs(t, store, convID)
+	res, err := o.DebateResult(ctx, debates[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Final || res.WinnerID != alice || len(res.Rounds) != 3 || res.SideTotals[SideFor] <= res.SideTotals[SideAgainst] {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	msgs, _ := store.GetConversationMessages(ctx, convID)
+	// moderator intro + 3 rounds of 2 + moderator outro + 3 round scores + verdict
+	if len(msgs) != 12 {
+		t.Fatalf("expected 12 messages, got %d", len(msgs))
+	}
+	if side := msgs[1].Metadata["side"]; msgs[1].SenderID != alice || side != SideFor {
+		t.Fatalf("expected Alice to open for the motion, got %+v", msgs[1])
+	}
+	if verdict := msgs[len(msgs)-1]; verdict.SenderID != judge || !strings.Contains(verdict.Content, "Alice wins") {
+		t.Fatalf("expected the judge to announce Alice, got %+v", verdict)
+	}
+}
+
+func TestDebateLineupRejectsOfficialsAsDebaters(t *testing.T) {
+	o, store, _ := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "cats", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "dogs", nil)
+	outsider, _ := store.CreateAgent(ctx, "Olga", "outside", nil)
+	convID, _ := o.CreateConversation(ctx, "debate", []string{alice, bob}, nil)
+	for _, opts := range []DebateOptions{
+		{Participants: []string{alice, bob}, JudgeID: bob},
+		{JudgeID: outsider},
+		{ModeratorID: alice, JudgeID: alice},
+	} {
+		if err := o.StartDebate(ctx, convID, opts); !errors.Is(err, ErrInvalidDebate) {
+			t.Fatalf("%+v: expected ErrInvalidDebate, got %v", opts, err)
+		}
+	}
+}
+
+// debateIDs returns the ids of the debates held in a conversation, from its event log.
+func debateIDs(t *testing.T, store persistence.Store, conversationID string) []string {
+	t.Helper()
+	evts, err := store.ListEvents(context.Background(), conversationID, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, evt := range evts {
+		var p events.DebatePayload
+		if evt.Type == events.TypeDebateStarted && evt.Decode(&p) == nil {
+			ids = append(ids, p.DebateID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,130 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+var (
+	// ErrConversationClosed is returned when a message is posted to a closed or archived conversation.
+	ErrConversationClosed = errors.New("conversation is closed")
+	// ErrConversationInactive is returned when a debate is started in a conversation that is not active.
+	ErrConversationInactive = errors.New("conversation is not active")
+	// ErrInvalidConversationUpdate is returned for an unknown status or an empty title.
+	ErrInvalidConversationUpdate = errors.New("invalid conversation update")
+	// ErrStatusTransition is returned when a conversation cannot move to the requested status.
+	ErrStatusTransition = errors.New("status transition not allowed")
+)
+
+// statusTransitions lists the statuses each status may change to. Closing ends
+// a conversation for good; archiving only hides it further.
+var statusTransitions = map[string][]string{
+	persistence.ConversationActive:   {persistence.ConversationPaused, persistence.ConversationClosed, persistence.ConversationArchived},
+	persistence.ConversationPaused:   {persistence.ConversationActive, persistence.ConversationClosed, persistence.ConversationArchived},
+	persistence.ConversationClosed:   {persistence.ConversationArchived},
+	persistence.ConversationArchived: nil,
+}
+
+// checkTransition reports whether a conversation may move from one status to another.
+func checkTransition(from, to string) error {
+	if _, ok := statusTransitions[to]; !ok {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidConversationUpdate, to)
+	}
+	if from == to {
+		return nil
+	}
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrStatusTransition, from, to)
+}
+
+// acceptsMessages reports whether users may still post to a conversation in status.
+func acceptsMessages(status string) bool {
+	return status == persistence.ConversationActive || status == persistence.ConversationPaused
+}
+
+// takesTurns reports whether agents may speak in the conversation right now.
+// Lookup failures count as no, so a deleted conversation stops its agents.
+func (o *Orchestrator) takesTurns(ctx context.Context, conversationID string) bool {
+	c, err := o.store.GetConversation(ctx, conversationID)
+	return err == nil && c.Status == persistence.ConversationActive
+}
+
+// UpdateConversation renames a conversation and/or changes its status, and
+// announces the change with conversation.updated. Pausing the conversation
+// pauses its debate or autonomous run; closing or archiving it cancels them.
+func (o *Orchestrator) UpdateConversation(ctx context.Context, conversationID string, patch persistence.ConversationPatch) (*persistence.Conversation, error) {
+	if patch.Title != nil {
+		title := strings.TrimSpace(*patch.Title)
+		if title == "" {
+			return nil, fmt.Errorf("%w: title must not be empty", ErrInvalidConversationUpdate)
+		}
+		patch.Title = &title
+	}
+	cur, err := o.store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Status != nil {
+		if err := checkTransition(cur.Status, *patch.Status); err != nil {
+			return nil, err
+		}
+		// pause the debate or autonomous run first, so it does not fail on its next turn
+		if *patch.Status == persistence.ConversationPaused {
+			o.pauseConversationDebate(ctx, conversationID)
+			o.pauseConversationAutonomous(ctx, conversationID)
+		}
+	}
+	c, err := o.store.UpdateConversation(ctx, conversationID, patch)
+	if err != nil {
+		return nil, err
+	}
+	if !acceptsMessages(c.Status) {
+		_ = o.StopDebate(conversationID)
+		_ = o.StopAutonomous(conversationID)
+		o.policyMu.Lock()
+		o.forgetPolicies(conversationID, "")
+		o.policyMu.Unlock()
+	}
+	o.emit(ctx, events.TypeConversationUpdated, conversationID, "", systemSender, events.ConversationPayload{
+		Title:  c.Title,
+		Status: c.Status,
+	})
+	return c, nil
+}
+
+// DeleteConversation stops the conversation's debate or autonomous run, deletes it with its
+// messages and event log, and tells live subscribers with conversation.deleted.
+// The event is not logged, since the log is deleted with the conversation.
+func (o *Orchestrator) DeleteConversation(ctx context.Context, conversationID string) error {
+	c, err := o.store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+	_ = o.StopDebate(conversationID)
+	_ = o.StopAutonomous(conversationID)
+	if err := o.store.DeleteConversation(ctx, conversationID); err != nil {
+		return err
+	}
+	o.policyMu.Lock()
+	o.forgetPolicies(conversationID, "")
+	o.policyMu.Unlock()
+	evt, err := events.New(events.TypeConversationDeleted, conversationID, systemSender, events.ConversationPayload{Title: c.Title, Status: c.Status})
+	if err != nil {
+		log.Printf("orchestrator: %v", err)
+		return nil
+	}
+	if err := o.ps.Publish(ctx, events.Channel(conversationID), evt); err != nil {
+		log.Printf("orchestrator: conversation %s: publish %s: %v", conversationID, evt.Type, err)
+	}
+	return nil
+}
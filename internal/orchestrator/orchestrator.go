@@ -2,180 +2,420 @@ package orchestrator
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/yourname/multiagent-social/internal/agent"
 	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/memory"
 	"github.com/yourname/multiagent-social/internal/persistence"
 	"github.com/yourname/multiagent-social/internal/pubsub"
 )
 
 // Orchestrator coordinates conversations and agent actions.
 type Orchestrator struct {
-	store         *persistence.PostgresStore
-	ps            *pubsub.RedisPubSub
+	store         persistence.Store
+	ps            pubsub.Broker
+	deciders      *agent.Registry
+	embedder      embeddings.Embedder
+	embed         embedFunc // embedder for a single text, used by turn policies
+	pipeline      *embeddings.Pipeline
+	memory        *memory.Manager
 	responseDelay time.Duration
+
+	// memMu guards memClosed; memWG tracks background memory writes.
+	memMu     sync.Mutex
+	memClosed bool
+	memWG     sync.WaitGroup
+
+	// pubLocks keeps log order and publish order in step per conversation within
+	// this process; pubMu guards the map.
+	pubMu    sync.Mutex
+	pubLocks map[string]*pubLock // conversation id -> lock, while someone holds or waits for it
+
+	policyMu sync.Mutex
+	policies map[string]TurnPolicy // conversation id + policy config -> policy
+
+	debateMu sync.Mutex
+	debates  map[string]*debateSession // debate id -> debate running in this process
+	// autonomous holds the autonomous runs in this process by run id, also under debateMu
+	autonomous map[string]*autonomousSession
+
+	pollMu     sync.Mutex
+	pollClosed map[string]chan struct{} // poll id -> closed when the poll closes, for open polls with a deadline
 }
 
-// NewOrchestrator constructs an orchestrator instance.
-func NewOrchestrator(store *persistence.PostgresStore, ps *pubsub.RedisPubSub) *Orchestrator {
+// NewOrchestrator constructs an orchestrator instance. Messages, personas and
+// agent memories are embedded with embedder; a nil embedder uses the offline
+// HashEmbedder. Message embeddings and memories are written in the background;
+// call Close to drain them.
+func NewOrchestrator(store persistence.Store, ps pubsub.Broker, embedder embeddings.Embedder) *Orchestrator {
+	if embedder == nil {
+		embedder = embeddings.NewHashEmbedder(0)
+	}
 	return &Orchestrator{
-		store:         store,
-		ps:            ps,
+		store:    store,
+		ps:       ps,
+		deciders: agent.DefaultRegistry,
+		embedder: embedder,
+		pipeline: embeddings.NewPipeline(embedder, store, embeddings.PipelineConfig{}),
+		memory:   memory.NewManager(store, embedder, nil),
+		embed: func(ctx context.Context, text string) (embeddings.Vector, error) {
+			return embeddings.EmbedOne(ctx, embedder, text)
+		},
 		responseDelay: 500 * time.Millisecond,
+		policies:      make(map[string]TurnPolicy),
+		debates:       make(map[string]*debateSession),
+		autonomous:    make(map[string]*autonomousSession),
+		pollClosed:    make(map[string]chan struct{}),
+		pubLocks:      make(map[string]*pubLock),
 	}
 }
 
-// CreateConversation creates a conversation row and returns its id.
-func (o *Orchestrator) CreateConversation(ctx context.Context, title string, agentIDs []string) (string, error) {
-	id, err := o.store.CreateConversation(ctx, title)
+// SetMemoryExtractor replaces the extractor that decides what agents remember
+// after each turn (HeuristicExtractor by default). Call it before serving.
+func (o *Orchestrator) SetMemoryExtractor(e memory.Extractor) {
+	o.memory = memory.NewManager(o.store, o.embedder, e)
+}
+
+// CreateConversation creates a conversation row with the given participants and metadata
+// (e.g. "turn_policy") and returns its id. When agentIDs is empty every existing agent joins.
+func (o *Orchestrator) CreateConversation(ctx context.Context, title string, agentIDs []string, metadata map[string]interface{}) (string, error) {
+	if err := validateTurnPolicy(metadata); err != nil {
+		return "", err
+	}
+	if err := validateContextConfig(metadata); err != nil {
+		return "", err
+	}
+	if len(agentIDs) == 0 {
+		agents, err := o.store.ListAgents(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, a := range agents {
+			agentIDs = append(agentIDs, string(a.ID))
+		}
+	}
+	id, err := o.store.CreateConversationWith(ctx, title, agentIDs, metadata)
 	if err != nil {
 		return "", err
 	}
 	// publish event for consumers
-	_ = o.ps.Publish(ctx, fmt.Sprintf("conversation:%s", id), map[string]interface{}{
-		"event": "conversation.created",
-		"id":    id,
-		"title": title,
+	o.emit(ctx, events.TypeConversationCreated, id, "", systemSender, events.ConversationPayload{
+		Title:        title,
+		Status:       persistence.ConversationActive,
+		Participants: agentIDs,
 	})
 	return id, nil
 }
 
-// HandleUserMessage stores the user message and schedules agent responses in turn order.
-func (o *Orchestrator) HandleUserMessage(ctx context.Context, conversationID string, userID string, content string) error {
-	// persist the user message
-	msgID, err := o.store.InsertMessage(ctx, conversationID, "user", userID, content)
+// AddParticipants adds agents to a conversation and announces each join.
+func (o *Orchestrator) AddParticipants(ctx context.Context, conversationID string, agentIDs []string) error {
+	if err := o.store.AddParticipants(ctx, conversationID, agentIDs...); err != nil {
+		return err
+	}
+	for _, id := range agentIDs {
+		o.emit(ctx, events.TypeParticipantJoined, conversationID, "", systemSender, events.ParticipantPayload{AgentID: id})
+	}
+	return nil
+}
+
+// RemoveParticipant removes an agent from a conversation and announces it.
+func (o *Orchestrator) RemoveParticipant(ctx context.Context, conversationID, agentID string) error {
+	if err := o.store.RemoveParticipant(ctx, conversationID, agentID); err != nil {
+		return err
+	}
+	o.emit(ctx, events.TypeParticipantLeft, conversationID, "", systemSender, events.ParticipantPayload{AgentID: agentID})
+	return nil
+}
+
+// HandleUserMessage stores the user message, schedules agent responses in turn order
+// and returns the stored message id. Closed and archived conversations reject the
+// message with ErrConversationClosed; in paused ones agents do not reply.
+func (o *Orchestrator) HandleUserMessage(ctx context.Context, conversationID string, userID string, content string) (string, error) {
+	return o.HandleUserReply(ctx, conversationID, userID, content, "")
+}
+
+// HandleUserReply is HandleUserMessage for a message answering replyTo, a
+// message of the same conversation (none when empty); a replyTo from elsewhere
+// returns ErrInvalidReply. Participants mentioned with @Name reply first; during
+// an autonomous run the run answers the message instead.
+func (o *Orchestrator) HandleUserReply(ctx context.Context, conversationID, userID, content, replyTo string) (string, error) {
+	c, err := o.store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return "", err
+	}
+	if !acceptsMessages(c.Status) {
+		return "", ErrConversationClosed
+	}
+	participants, err := o.store.ListParticipants(ctx, conversationID)
 	if err != nil {
+		return "", err
+	}
+	m := &agent.Message{
+		ConversationID: conversationID,
+		SenderType:     agent.SenderUser,
+		SenderID:       userID,
+		SenderName:     userID,
+		Content:        content,
+		ReplyTo:        replyTo,
+		Mentions:       agent.ParseMentions(content, participants),
+	}
+	if err := o.postMessage(ctx, m); err != nil {
+		if replyTo != "" && errors.Is(err, persistence.ErrNotFound) {
+			return "", ErrInvalidReply
+		}
+		return "", err
+	}
+	// a live autonomous run takes the message up before its next turn
+	if sess := o.liveAutonomousIn(conversationID); sess != nil {
+		sess.interject(*m)
+		return m.ID, nil
+	}
+	// run agent responses asynchronously so request returns fast
+	if c.Status == persistence.ConversationActive {
+		go o.scheduleAgentResponses(context.Background(), conversationID, m)
+	}
+	return m.ID, nil
+}
+
+// agentMessage builds the message an agent posts for action.
+func agentMessage(conversationID string, a *agent.Agent, action *agent.Action) *agent.Message {
+	return &agent.Message{
+		ConversationID: conversationID,
+		SenderType:     agent.SenderAgent,
+		SenderID:       string(a.ID),
+		SenderName:     a.Name,
+		Content:        action.Payload,
+		Metadata:       map[string]interface{}{"action": action.Type},
+	}
+}
+
+// postMessage persists m, publishes message.created and embeds it in the background.
+func (o *Orchestrator) postMessage(ctx context.Context, m *agent.Message) error {
+	if err := o.store.InsertMessage(ctx, m); err != nil {
 		return err
 	}
-	// publish user message event
-	_ = o.ps.Publish(ctx, fmt.Sprintf("conversation:%s", conversationID), map[string]interface{}{
-		"event":   "message.created",
-		"sender":  userID,
-		"content": content,
-	})
-	// generate and save embedding for this message asynchronously using OpenAI
+	evt := events.MessagePayload{Content: m.Content, ReplyTo: m.ReplyTo, ThreadID: m.ThreadID, Mentions: m.Mentions}
+	o.emit(ctx, events.TypeMessageCreated, m.ConversationID, m.ID,
+		&events.Sender{Type: m.SenderType, ID: m.SenderID, Name: m.SenderName}, evt)
+	// embed in the background; a full queue drops the job rather than block the conversation
+	o.pipeline.Enqueue(embeddings.Job{ConversationID: m.ConversationID, MessageID: m.ID, Text: m.Content})
+	return nil
+}
+
+// Close waits for pending memory writes and queued message embeddings to be
+// stored, giving up when ctx ends.
+func (o *Orchestrator) Close(ctx context.Context) error {
+	o.memMu.Lock()
+	o.memClosed = true
+	o.memMu.Unlock()
+	done := make(chan struct{})
 	go func() {
-		if vec, err := embeddings.GenerateEmbedding(context.Background(), content); err == nil {
-			_ = embeddings.SaveEmbedding(context.Background(), o.store, conversationID, msgID, vec)
-		}
+		o.memWG.Wait()
+		close(done)
 	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return o.pipeline.Close(ctx)
+}
 
-	// run agent responses asynchronously so request returns fast
-	go o.scheduleAgentResponses(context.Background(), conversationID)
-	return nil
+// memoryTimeout bounds one background memory write.
+const memoryTimeout = 30 * time.Second
+
+// remember runs a memory write in the background so extraction never delays
+// the conversation. Writes after Close are dropped.
+func (o *Orchestrator) remember(conversationID string, write func(ctx context.Context) error) {
+	o.memMu.Lock()
+	if o.memClosed {
+		o.memMu.Unlock()
+		return
+	}
+	o.memWG.Add(1)
+	o.memMu.Unlock()
+	go func() {
+		defer o.memWG.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), memoryTimeout)
+		defer cancel()
+		if err := write(ctx); err != nil {
+			log.Printf("orchestrator: conversation %s: remember: %v", conversationID, err)
+		}
+	}()
 }
 
-// scheduleAgentResponses loads agents, gets context, and runs responses in sequence (turns).
-func (o *Orchestrator) scheduleAgentResponses(ctx context.Context, conversationID string) {
-	agents, err := o.store.ListAgents(ctx)
+// scheduleAgentResponses loads participants, asks the conversation's turn policy
+// who replies to trigger, and runs their responses in that order. Agents that
+// trigger mentions reply first, and every reply is threaded under trigger.
+func (o *Orchestrator) scheduleAgentResponses(ctx context.Context, conversationID string, trigger *agent.Message) {
+	agents, err := o.store.ListParticipants(ctx, conversationID)
 	if err != nil || len(agents) == 0 {
 		return
 	}
-	// gather current messages as context
-	messages, err := o.store.GetConversationMessages(ctx, conversationID)
+	// gather the latest messages as context
+	window := o.contextConfigFor(ctx, conversationID)
+	recent, err := o.loadRecent(ctx, conversationID, window.Recent)
 	if err != nil {
 		return
 	}
-	// choose up to 3 agents in order (simple selection: first N)
-	limit := 3
-	for i, a := range agents {
-		if i >= limit {
-			break
+	policy, cfg := o.turnPolicyFor(ctx, conversationID)
+	speakers, err := policy.Select(ctx, &TurnContext{
+		ConversationID: conversationID,
+		Participants:   agents,
+		Messages:       recent.messages,
+		Decider:        o.deciderFor,
+	})
+	if err != nil {
+		// mentioned agents still answer
+		log.Printf("orchestrator: conversation %s: turn policy %s: %v", conversationID, cfg.Name, err)
+		speakers = nil
+	}
+	speakers = mentionedFirst(speakers, agents, trigger)
+	for i, a := range speakers {
+		if i > 0 {
+			// wait a bit to simulate turn-taking
+			time.Sleep(cfg.delay(o.responseDelay))
 		}
-		decider := &agent.SimpleDecider{}
-		action, derr := decider.DecideAction(ctx, &a, &agent.ConversationState{
-			ConversationID: conversationID,
-			Messages:       messages,
-		})
+		// the conversation may have been paused, closed or deleted meanwhile
+		if !o.takesTurns(ctx, conversationID) {
+			return
+		}
+		decider := o.deciderFor(&a)
+		state := o.decisionState(ctx, conversationID, &a, recent, window)
+		if slices.Contains(trigger.Mentioned(), string(a.ID)) {
+			state.Mention = trigger
+		}
+		action, derr := decider.DecideAction(ctx, &a, state)
 		if derr != nil || action == nil {
 			continue
 		}
-		// persist agent message
-		msgID, merr := o.store.InsertMessage(ctx, conversationID, "agent", string(a.ID), action.Payload)
-		if merr != nil {
+		// persist and publish agent message
+		m := agentMessage(conversationID, &a, action)
+		m.ReplyTo = trigger.ID
+		m.Mentions = agent.ParseMentions(m.Content, agents)
+		if err := o.postMessage(ctx, m); err != nil {
 			continue
 		}
-		// save embedding for agent message using OpenAI
-		go func(mid string, payload string) {
-			if vec, err := embeddings.GenerateEmbedding(context.Background(), payload); err == nil {
-				_ = embeddings.SaveEmbedding(context.Background(), o.store, conversationID, mid, vec)
-			}
-		}(msgID, action.Payload)
-		// publish agent speak event
-		_ = o.ps.Publish(ctx, fmt.Sprintf("conversation:%s", conversationID), map[string]interface{}{
-			"event":   "message.created",
-			"sender":  a.Name,
-			"content": action.Payload,
+		o.remember(conversationID, func(ctx context.Context) error {
+			return o.memory.Record(ctx, &memory.Turn{Agent: &a, State: state, Said: m})
 		})
-		// append to messages for next agent context
-		messages = append(messages, action.Payload)
-		// wait a bit to simulate turn-taking
-		time.Sleep(o.responseDelay)
+		// add to the recent messages for next agent context
+		recent.add(*m, window.Recent)
 	}
 }
 
-// StartDebate starts a structured debate between selected agents for given rounds.
-func (o *Orchestrator) StartDebate(ctx context.Context, conversationID string, participantIDs []string, rounds int) error {
-	if rounds <= 0 {
-		rounds = 3
+// turnPolicyFor returns the turn policy configured in the conversation's metadata,
+// falling back to round-robin when the metadata is missing or invalid. Policies are
+// cached per conversation so stateful ones (round-robin rotation) persist between turns.
+func (o *Orchestrator) turnPolicyFor(ctx context.Context, conversationID string) (TurnPolicy, TurnPolicyConfig) {
+	md, err := o.store.GetConversationMetadata(ctx, conversationID)
+	if err != nil {
+		md = nil
 	}
-	// load agents
-	agents, err := o.store.ListAgents(ctx)
+	cfg, err := turnPolicyConfigFrom(md)
 	if err != nil {
-		return err
+		log.Printf("orchestrator: conversation %s: invalid turn policy: %v", conversationID, err)
+		cfg, _ = turnPolicyConfigFrom(nil)
 	}
-	// map agents by id
-	agentMap := make(map[string]agent.Agent)
-	for _, a := range agents {
-		agentMap[string(a.ID)] = a
+	cfgBytes, _ := json.Marshal(cfg)
+	key := conversationID + "|" + string(cfgBytes)
+
+	o.policyMu.Lock()
+	defer o.policyMu.Unlock()
+	if p, ok := o.policies[key]; ok {
+		return p, cfg
+	}
+	p, err := newTurnPolicy(cfg, o.embed)
+	if err != nil {
+		log.Printf("orchestrator: conversation %s: %v; using round_robin", conversationID, err)
+		cfg.Name = "round_robin"
+		p = &RoundRobinPolicy{Max: cfg.MaxSpeakers}
 	}
-	// filter participants
-	var participants []agent.Agent
-	for _, pid := range participantIDs {
-		if a, ok := agentMap[pid]; ok {
-			participants = append(participants, a)
+	// a changed config replaces the conversation's cached policy rather than adding to it
+	o.forgetPolicies(conversationID, key)
+	o.policies[key] = p
+	return p, cfg
+}
+
+// forgetPolicies drops the conversation's cached turn policies except keep.
+// The caller holds policyMu.
+func (o *Orchestrator) forgetPolicies(conversationID, keep string) {
+	for key := range o.policies {
+		if key != keep && strings.HasPrefix(key, conversationID+"|") {
+			delete(o.policies, key)
 		}
 	}
-	if len(participants) < 2 {
-		return fmt.Errorf("need at least two participants")
+}
+
+// deciderFor resolves the agent's decider from its BehaviorProfile, falling back
+// to SimpleDecider when the profile names an unknown or misconfigured decider.
+func (o *Orchestrator) deciderFor(a *agent.Agent) agent.Decider {
+	d, err := o.deciders.ForAgent(a)
+	if err != nil {
+		log.Printf("orchestrator: agent %s: %v; using simple decider", a.ID, err)
+		return &agent.SimpleDecider{}
 	}
+	return d
+}
 
-	// initial context
-	messages, err := o.store.GetConversationMessages(ctx, conversationID)
+// systemSender marks events the orchestrator raises on its own behalf.
+var systemSender = &events.Sender{Type: events.SenderSystem, ID: "orchestrator"}
+
+// emit builds an event, appends it to the conversation's durable event log (which
+// assigns its sequence number) and publishes it. Failures are logged; they never
+// fail the caller. Events are logged before they are published, so subscribers
+// can always fill gaps from the log.
+func (o *Orchestrator) emit(ctx context.Context, typ, conversationID, messageID string, sender *events.Sender, payload interface{}) {
+	evt, err := events.New(typ, conversationID, sender, payload)
 	if err != nil {
-		return err
+		log.Printf("orchestrator: %v", err)
+		return
 	}
-	topic := "讨论"
-	if len(messages) > 0 {
-		topic = messages[len(messages)-1]
-	}
-
-	// run debate rounds: each participant speaks in order per round
-	for r := 0; r < rounds; r++ {
-		for _, p := range participants {
-			// generate a debate-style payload
-			payload := fmt.Sprintf("%s（第%d轮）: 我对%s的看法是基于我的身份[%s]，我认为...", p.Name, r+1, topic, p.Persona)
-			// persist
-			msgID, merr := o.store.InsertMessage(ctx, conversationID, "agent", string(p.ID), payload)
-			if merr != nil {
-				continue
-			}
-			_ = o.ps.Publish(ctx, fmt.Sprintf("conversation:%s", conversationID), map[string]interface{}{
-				"event":   "message.created",
-				"sender":  p.Name,
-				"content": payload,
-			})
-			// save embedding
-			go func(mid, content string) {
-				if vec, err := embeddings.GenerateEmbedding(context.Background(), content); err == nil {
-					_ = embeddings.SaveEmbedding(context.Background(), o.store, conversationID, mid, vec)
-				}
-			}(msgID, payload)
-			time.Sleep(o.responseDelay)
-		}
+	evt.MessageID = messageID
+	unlock := o.lockPublish(conversationID)
+	defer unlock()
+	if err := o.store.AppendEvent(ctx, evt); err != nil {
+		log.Printf("orchestrator: conversation %s: log %s: %v", conversationID, typ, err)
+		return
+	}
+	if err := o.ps.Publish(ctx, events.Channel(conversationID), evt); err != nil {
+		log.Printf("orchestrator: conversation %s: publish %s: %v", conversationID, typ, err)
 	}
-	return nil
 }
 
+// pubLock serializes emit for one conversation; refs counts its holders and waiters.
+type pubLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockPublish locks conversationID's log and publish order and returns the
+// unlock function. Conversations do not wait on each other, and a lock is
+// dropped once nobody holds or waits for it.
+func (o *Orchestrator) lockPublish(conversationID string) func() {
+	o.pubMu.Lock()
+	l, ok := o.pubLocks[conversationID]
+	if !ok {
+		l = &pubLock{}
+		o.pubLocks[conversationID] = l
+	}
+	l.refs++
+	o.pubMu.Unlock()
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		o.pubMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(o.pubLocks, conversationID)
+		}
+		o.pubMu.Unlock()
+	}
+}
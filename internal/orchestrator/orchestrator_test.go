@@ -0,0 +1,432 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+)
+
+// newTestOrchestrator wires an orchestrator to in-memory storage and pubsub.
+func newTestOrchestrator(t *testing.T) (*Orchestrator, *persistence.MemoryStore, *pubsub.MemoryBroker) {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "")
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	t.Cleanup(func() { _ = broker.Close() })
+	o := NewOrchestrator(store, broker, embeddings.NewHashEmbedder(64))
+	o.responseDelay = 0
+	t.Cleanup(func() { _ = o.Close(context.Background()) })
+	return o, store, broker
+}
+
+// nextEvent waits for the next event on sub.
+func nextEvent(t *testing.T, sub pubsub.Subscription) *events.Event {
+	t.Helper()
+	select {
+	case payload := <-sub.Channel():
+		var evt events.Event
+		if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+			t.Fatalf("bad event %s: %v", payload, err)
+		}
+		return &evt
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return nil
+	}
+}
+
+func TestOnlyParticipantsReply(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	_, _ = store.CreateAgent(ctx, "Bob", "fitness", nil)
+
+	convID, err := o.CreateConversation(ctx, "test", []string{alice}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := broker.Subscribe(ctx, "conversation:"+convID)
+	defer sub.Close()
+
+	if _, err := o.HandleUserMessage(ctx, convID, "u1", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if evt := nextEvent(t, sub); evt.Sender == nil || evt.Sender.ID != "u1" {
+		t.Fatalf("expected user message event, got %+v", evt)
+	}
+	if evt := nextEvent(t, sub); evt.Sender == nil || evt.Sender.Name != "Alice" {
+		t.Fatalf("expected Alice to reply, got %+v", evt)
+	}
+	select {
+	case payload := <-sub.Channel():
+		t.Fatalf("unexpected extra event %s", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+	msgs, _ := store.GetConversationMessages(ctx, convID)
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 stored messages, got %v", msgs)
+	}
+}
+
+func TestEventEnvelope(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	convID, err := o.CreateConversation(ctx, "test", []string{alice}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+	defer sub.Close()
+
+	if _, err := o.HandleUserMessage(ctx, convID, "u1", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	user := nextEvent(t, sub)
+	reply := nextEvent(t, sub)
+	if user.Type != events.TypeMessageCreated || user.Version != events.SchemaVersion || user.ConversationID != convID {
+		t.Fatalf("unexpected envelope %+v", user)
+	}
+	if user.MessageID == "" || user.Timestamp.IsZero() || user.Sender.Type != events.SenderUser {
+		t.Fatalf("incomplete envelope %+v", user)
+	}
+	var p events.MessagePayload
+	if err := user.Decode(&p); err != nil || p.Content != "hello" {
+		t.Fatalf("unexpected payload %s: %v", user.Payload, err)
+	}
+	if reply.Sender.Type != events.SenderAgent || reply.Sender.ID != alice {
+		t.Fatalf("expected agent sender %s, got %+v", alice, reply.Sender)
+	}
+	// conversation.created took seq 1 before we subscribed
+	if user.Seq != 2 || reply.Seq != 3 {
+		t.Fatalf("expected seq 2, 3; got %d, %d", user.Seq, reply.Seq)
+	}
+}
+
+func TestEmitKeepsOrderPerConversation(t *testing.T) {
+	o, _, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	var (
+		convs []string
+		subs  []pubsub.Subscription
+	)
+	for range 2 {
+		convID, err := o.CreateConversation(ctx, "order", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+		defer sub.Close()
+		convs, subs = append(convs, convID), append(subs, sub)
+	}
+	const perWriter = 5
+	var wg sync.WaitGroup
+	for _, convID := range convs {
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range perWriter {
+					o.emit(ctx, events.TypeConversationUpdated, convID, "", systemSender, events.ConversationPayload{Title: "order"})
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	for i, sub := range subs {
+		// conversation.created took seq 1 before we subscribed
+		for want := int64(2); want < 2+2*perWriter; want++ {
+			if evt := nextEvent(t, sub); evt.Seq != want {
+				t.Fatalf("conversation %d: expected seq %d, got %d", i, want, evt.Seq)
+			}
+		}
+	}
+	o.pubMu.Lock()
+	defer o.pubMu.Unlock()
+	if len(o.pubLocks) != 0 {
+		t.Fatalf("expected unused locks to be dropped, got %d", len(o.pubLocks))
+	}
+}
+
+func TestStopDebate(t *testing.T) {
+	o, store, _ := newTestOrchestrator(t)
+	o.responseDelay = 50 * time.Millisecond
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	convID, _ := o.CreateConversation(ctx, "debate", []string{alice, bob}, nil)
+
+	if _, err := o.StartDebateAsync(ctx, convID, DebateOptions{Rounds: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.StartDebateAsync(ctx, convID, DebateOptions{Rounds: 1}); err != ErrDebateRunning {
+		t.Fatalf("expected ErrDebateRunning, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := o.StopDebate(convID); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.StopDebate(convID); err != ErrNoDebate {
+		t.Fatalf("expected ErrNoDebate, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	msgs, _ := store.GetConversationMessages(ctx, convID)
+	if len(msgs) == 0 || len(msgs) > 2 {
+		t.Fatalf("expected the debate to stop after its first turn, got %d messages", len(msgs))
+	}
+}
+
+func TestConversationLifecycle(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	convID, _ := o.CreateConversation(ctx, "lifecycle", []string{alice, bob}, nil)
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+	defer sub.Close()
+
+	paused := persistence.ConversationPaused
+	c, err := o.UpdateConversation(ctx, convID, persistence.ConversationPatch{Status: &paused})
+	if err != nil || c.Status != paused {
+		t.Fatalf("pause: %+v, %v", c, err)
+	}
+	if evt := nextEvent(t, sub); evt.Type != events.TypeConversationUpdated {
+		t.Fatalf("expected conversation.updated, got %+v", evt)
+	}
+	// paused conversations take messages, but agents stay silent and cannot debate
+	if _, err := o.HandleUserMessage(ctx, convID, "u1", "anyone?"); err != nil {
+		t.Fatal(err)
+	}
+	if evt := nextEvent(t, sub); evt.Sender == nil || evt.Sender.ID != "u1" {
+		t.Fatalf("expected the user message, got %+v", evt)
+	}
+	select {
+	case payload := <-sub.Channel():
+		t.Fatalf("agents replied in a paused conversation: %s", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := o.StartDebate(ctx, convID, DebateOptions{Rounds: 1}); !errors.Is(err, ErrConversationInactive) {
+		t.Fatalf("expected ErrConversationInactive, got %v", err)
+	}
+
+	closed, active := persistence.ConversationClosed, persistence.ConversationActive
+	o.turnPolicyFor(ctx, convID)
+	if _, err := o.UpdateConversation(ctx, convID, persistence.ConversationPatch{Status: &closed}); err != nil {
+		t.Fatal(err)
+	}
+	if len(o.policies) != 0 {
+		t.Fatalf("expected closing to evict the cached turn policy, got %v", o.policies)
+	}
+	if _, err := o.HandleUserMessage(ctx, convID, "u1", "hello?"); !errors.Is(err, ErrConversationClosed) {
+		t.Fatalf("expected ErrConversationClosed, got %v", err)
+	}
+	if _, err := o.UpdateConversation(ctx, convID, persistence.ConversationPatch{Status: &active}); !errors.Is(err, ErrStatusTransition) {
+		t.Fatalf("expected ErrStatusTransition reopening, got %v", err)
+	}
+	bogus := "finished"
+	if _, err := o.UpdateConversation(ctx, convID, persistence.ConversationPatch{Status: &bogus}); !errors.Is(err, ErrInvalidConversationUpdate) {
+		t.Fatalf("expected ErrInvalidConversationUpdate, got %v", err)
+	}
+
+	if err := o.DeleteConversation(ctx, convID); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		evt := nextEvent(t, sub)
+		if evt.Type == events.TypeConversationDeleted {
+			break
+		}
+	}
+	if _, err := store.GetConversation(ctx, convID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected the conversation to be deleted, got %v", err)
+	}
+}
+
+func TestDebateSessionPauseResumeCancel(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	o.responseDelay = 20 * time.Millisecond
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	convID, _ := o.CreateConversation(ctx, "debate", []string{alice, bob}, nil)
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+	defer sub.Close()
+
+	d, err := o.StartDebateAsync(ctx, convID, DebateOptions{Rounds: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID == "" || d.State != persistence.DebateRunning || d.Rounds != 50 || len(d.Participants) != 2 {
+		t.Fatalf("unexpected debate %+v", d)
+	}
+	// debate.started, then debate.round_started for round 1
+	for _, typ := range []string{events.TypeDebateStarted, events.TypeDebateRoundStarted} {
+		evt := nextEvent(t, sub)
+		var p events.DebatePayload
+		if evt.Type != typ || evt.Decode(&p) != nil || p.DebateID != d.ID {
+			t.Fatalf("expected %s for %s, got %+v", typ, d.ID, evt)
+		}
+	}
+
+	if d, err = o.PauseDebate(ctx, d.ID); err != nil || d.State != persistence.DebatePaused {
+		t.Fatalf("pause: %+v, %v", d, err)
+	}
+	time.Sleep(60 * time.Millisecond) // let a turn in flight finish
+	before, _ := store.GetConversationMessages(ctx, convID)
+	time.Sleep(100 * time.Millisecond)
+	after, _ := store.GetConversationMessages(ctx, convID)
+	if len(after) != len(before) {
+		t.Fatalf("paused debate kept speaking: %d then %d messages", len(before), len(after))
+	}
+	if stored, _ := store.GetDebate(ctx, d.ID); stored.State != persistence.DebatePaused {
+		t.Fatalf("pause not stored: %+v", stored)
+	}
+
+	if d, err = o.ResumeDebate(ctx, d.ID); err != nil || d.State != persistence.DebateRunning {
+		t.Fatalf("resume: %+v, %v", d, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if resumed, _ := store.GetConversationMessages(ctx, convID); len(resumed) <= len(after) {
+		t.Fatal("resumed debate did not continue")
+	}
+
+	if d, err = o.CancelDebate(ctx, d.ID); err != nil || d.State != persistence.DebateCancelled || d.EndedAt == nil {
+		t.Fatalf("cancel: %+v, %v", d, err)
+	}
+	if stored, _ := o.Debate(ctx, d.ID); stored.State != persistence.DebateCancelled {
+		t.Fatalf("cancel not stored: %+v", stored)
+	}
+	if _, err := o.PauseDebate(ctx, d.ID); !errors.Is(err, ErrDebateEnded) {
+		t.Fatalf("expected ErrDebateEnded pausing a cancelled debate, got %v", err)
+	}
+	if _, err := o.Debate(ctx, "00000000-0000-4000-8000-000000000000"); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an unknown debate, got %v", err)
+	}
+}
+
+func TestDebateFinishes(t *testing.T) {
+	o, store, _ := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	convID, _ := o.CreateConversation(ctx, "debate", []string{alice, bob}, nil)
+	d, err := o.StartDebateAsync(ctx, convID, DebateOptions{Rounds: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if d, _ = o.Debate(ctx, d.ID); d.Ended() || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if d.State != persistence.DebateFinished || d.Round != 2 {
+		t.Fatalf("expected the debate to finish both rounds, got %+v", d)
+	}
+	msgs, _ := store.GetConversationMessages(ctx, convID)
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 debate messages, got %d", len(msgs))
+	}
+}
+
+func TestDecisionStateRecallsOlderContext(t *testing.T) {
+	o, store, _ := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	convID, _ := o.CreateConversation(ctx, "recall", []string{alice}, nil)
+	post := func(senderType, senderID, content string) {
+		m := &agent.Message{ConversationID: convID, SenderType: senderType, SenderID: senderID, Content: content}
+		if err := store.InsertMessage(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		vec, _ := o.embed(ctx, content)
+		if err := store.SaveEmbedding(ctx, convID, m.ID, vec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post(agent.SenderUser, "u1", "cats purr when they are happy")
+	post(agent.SenderAgent, alice, "jazz is the best music")
+	post(agent.SenderUser, "u1", "the weather is nice today")
+	post(agent.SenderUser, "u1", "why do cats purr")
+	a, _ := store.GetAgent(ctx, alice)
+	recent, err := o.loadRecent(ctx, convID, 1)
+	if err != nil || len(recent.messages) != 1 || !recent.older {
+		t.Fatalf("expected one recent message and older ones, got %+v (%v)", recent, err)
+	}
+	state := o.decisionState(ctx, convID, a, recent, ContextConfig{Recent: 1, Related: 1, Own: 1})
+	if len(state.Messages) != 1 || state.Messages[0].Content != "why do cats purr" {
+		t.Fatalf("expected only the latest message in the window, got %+v", state.Messages)
+	}
+	if len(state.Own) != 1 || state.Own[0].Content != "jazz is the best music" {
+		t.Fatalf("expected Alice's earlier statement, got %+v", state.Own)
+	}
+	if len(state.Related) != 1 || state.Related[0].Content != "cats purr when they are happy" {
+		t.Fatalf("expected the related cat message, got %+v", state.Related)
+	}
+
+	// a window holding the whole conversation leaves nothing to recall
+	if recent, err = o.loadRecent(ctx, convID, 10); err != nil || len(recent.messages) != 4 || recent.older {
+		t.Fatalf("expected all 4 messages and no older ones, got %+v (%v)", recent, err)
+	}
+	state = o.decisionState(ctx, convID, a, recent, ContextConfig{Recent: 10, Related: 5, Own: 5})
+	if len(state.Messages) != 4 || len(state.Related) != 0 || len(state.Own) != 0 {
+		t.Fatalf("expected no recall when the window holds everything, got %+v", state)
+	}
+}
+
+func TestRecentMessagesStayBounded(t *testing.T) {
+	r := &recentMessages{}
+	for _, content := range []string{"one", "two", "three"} {
+		r.add(agent.Message{Content: content}, 2)
+	}
+	if len(r.messages) != 2 || r.messages[0].Content != "two" || r.last().Content != "three" || !r.older {
+		t.Fatalf("expected the latest two messages and older ones, got %+v", r)
+	}
+}
+
+func TestAgentsRememberAcrossConversations(t *testing.T) {
+	o, store, _ := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "fitness", nil)
+	first, _ := o.CreateConversation(ctx, "first", []string{alice}, nil)
+	m := &agent.Message{ConversationID: first, SenderType: agent.SenderUser, SenderID: "u1", Content: "my name is Dana and I love jazz"}
+	if err := o.postMessage(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	o.scheduleAgentResponses(ctx, first, m)
+	_ = o.AddParticipants(ctx, first, []string{bob})
+	if err := o.StartDebate(ctx, first, DebateOptions{Rounds: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	mems, _ := store.ListMemories(ctx, alice, 0)
+	kinds := map[string]int{}
+	for _, m := range mems {
+		kinds[m.Kind]++
+	}
+	if kinds[agent.MemoryFact] != 1 || kinds[agent.MemoryOpinion] != 1 || kinds[agent.MemoryEpisode] != 1 {
+		t.Fatalf("expected a fact, an opinion and a debate episode, got %+v", mems)
+	}
+
+	second, _ := store.CreateConversation(ctx, "second")
+	recent := &recentMessages{messages: []agent.Message{{ID: "x", ConversationID: second, SenderType: agent.SenderUser, SenderID: "u1", Content: "Dana loves jazz, remember?"}}}
+	a, _ := store.GetAgent(ctx, alice)
+	state := o.decisionState(ctx, second, a, recent, ContextConfig{Recent: 20, Memories: 1})
+	if len(state.Memories) != 1 || state.Memories[0].Kind != agent.MemoryFact {
+		t.Fatalf("expected the fact about Dana to be recalled, got %+v", state.Memories)
+	}
+}
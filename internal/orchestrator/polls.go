@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+var (
+	// ErrInvalidPoll is returned for a poll without a question, with too few or
+	// too many options, or with a deadline that has passed.
+	ErrInvalidPoll = errors.New("invalid poll")
+	// ErrInvalidVote is returned for a vote for an option the poll does not have.
+	ErrInvalidVote = errors.New("invalid vote")
+)
+
+// maxPollOptions caps the options of a poll.
+const maxPollOptions = 20
+
+// PollOptions describes a poll. Deadline, or DurationSeconds from now, closes
+// it; without either it stays open until closed.
+type PollOptions struct {
+	Question        string     `json:"question"`
+	Options         []string   `json:"options"`
+	Deadline        *time.Time `json:"deadline"`
+	DurationSeconds int        `json:"duration_seconds"`
+}
+
+// CreatePoll opens a poll in a conversation that takes messages and announces
+// it with poll.created.
+func (o *Orchestrator) CreatePoll(ctx context.Context, conversationID, createdBy string, opts PollOptions) (*persistence.Poll, error) {
+	p := &persistence.Poll{
+		ConversationID: conversationID,
+		Question:       strings.TrimSpace(opts.Question),
+		CreatedBy:      createdBy,
+		Deadline:       opts.Deadline,
+	}
+	for _, opt := range opts.Options {
+		if opt = strings.TrimSpace(opt); opt != "" {
+			p.Options = append(p.Options, opt)
+		}
+	}
+	if p.Question == "" {
+		return nil, fmt.Errorf("%w: question is required", ErrInvalidPoll)
+	}
+	if len(p.Options) < 2 || len(p.Options) > maxPollOptions {
+		return nil, fmt.Errorf("%w: need 2 to %d options", ErrInvalidPoll, maxPollOptions)
+	}
+	if opts.DurationSeconds < 0 {
+		return nil, fmt.Errorf("%w: duration_seconds must not be negative", ErrInvalidPoll)
+	}
+	if p.Deadline == nil && opts.DurationSeconds > 0 {
+		at := time.Now().UTC().Add(time.Duration(opts.DurationSeconds) * time.Second)
+		p.Deadline = &at
+	}
+	if p.Deadline != nil && !p.Deadline.After(time.Now()) {
+		return nil, fmt.Errorf("%w: deadline has passed", ErrInvalidPoll)
+	}
+	c, err := o.store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !acceptsMessages(c.Status) {
+		return nil, ErrConversationClosed
+	}
+	if err := o.openPoll(ctx, p, userSender(createdBy)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// openPoll stores p, arranges for it to close at its deadline and announces it.
+func (o *Orchestrator) openPoll(ctx context.Context, p *persistence.Poll, sender *events.Sender) error {
+	if err := o.store.CreatePoll(ctx, p); err != nil {
+		return err
+	}
+	if p.Deadline != nil {
+		o.pollMu.Lock()
+		o.pollClosed[p.ID] = make(chan struct{})
+		o.pollMu.Unlock()
+		id := p.ID
+		time.AfterFunc(time.Until(*p.Deadline), func() { o.expirePoll(id) })
+	}
+	o.emitPoll(ctx, events.TypePollCreated, p, sender)
+	return nil
+}
+
+// Poll returns a poll with its tally.
+func (o *Orchestrator) Poll(ctx context.Context, id string) (*persistence.Poll, error) {
+	return o.store.GetPoll(ctx, id)
+}
+
+// Polls returns a conversation's polls with their tallies, newest first.
+func (o *Orchestrator) Polls(ctx context.Context, conversationID string) ([]persistence.Poll, error) {
+	return o.store.ListPolls(ctx, conversationID)
+}
+
+// Vote records voterID's vote for an option of an open poll, replacing the
+// voter's earlier vote, and broadcasts the new tally with poll.voted. Anonymous
+// votes (an empty voterID) are rejected with ErrInvalidVote.
+func (o *Orchestrator) Vote(ctx context.Context, pollID, voterID string, option int) (*persistence.Poll, error) {
+	if voterID == "" {
+		return nil, fmt.Errorf("%w: voter is required", ErrInvalidVote)
+	}
+	p, err := o.store.GetPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if option < 0 || option >= len(p.Options) {
+		return nil, fmt.Errorf("%w: option must be from 0 to %d", ErrInvalidVote, len(p.Options)-1)
+	}
+	c, err := o.store.GetConversation(ctx, p.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !acceptsMessages(c.Status) {
+		return nil, ErrConversationClosed
+	}
+	if p.Closed(time.Now()) {
+		return nil, persistence.ErrPollClosed
+	}
+	if err := o.store.CastVote(ctx, pollID, voterID, option); err != nil {
+		return nil, err
+	}
+	if p, err = o.store.GetPoll(ctx, pollID); err != nil {
+		return nil, err
+	}
+	o.emitPoll(ctx, events.TypePollVoted, p, userSender(voterID))
+	return p, nil
+}
+
+// ClosePoll closes a poll before its deadline and announces the final tally
+// with poll.closed. Closing a closed poll returns persistence.ErrPollClosed.
+func (o *Orchestrator) ClosePoll(ctx context.Context, id string) (*persistence.Poll, error) {
+	p, err := o.store.ClosePoll(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	o.releasePoll(id)
+	o.emitPoll(ctx, events.TypePollClosed, p, systemSender)
+	return p, nil
+}
+
+// expirePoll closes a poll whose deadline has come, unless it was closed already.
+func (o *Orchestrator) expirePoll(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := o.ClosePoll(ctx, id)
+	if err != nil && !errors.Is(err, persistence.ErrPollClosed) && !errors.Is(err, persistence.ErrNotFound) {
+		log.Printf("orchestrator: close poll %s: %v", id, err)
+	}
+	o.releasePoll(id)
+}
+
+// releasePoll wakes whoever waits for a poll to close.
+func (o *Orchestrator) releasePoll(id string) {
+	o.pollMu.Lock()
+	defer o.pollMu.Unlock()
+	if ch, ok := o.pollClosed[id]; ok {
+		close(ch)
+		delete(o.pollClosed, id)
+	}
+}
+
+// waitPoll blocks until a poll with a deadline has closed.
+func (o *Orchestrator) waitPoll(ctx context.Context, id string) error {
+	o.pollMu.Lock()
+	ch := o.pollClosed[id]
+	o.pollMu.Unlock()
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ch:
+		return nil
+	}
+}
+
+// emitPoll publishes a poll.* event describing p.
+func (o *Orchestrator) emitPoll(ctx context.Context, typ string, p *persistence.Poll, sender *events.Sender) {
+	o.emit(ctx, typ, p.ConversationID, "", sender, events.PollPayload{
+		PollID:   p.ID,
+		Question: p.Question,
+		Options:  p.Options,
+		Counts:   p.Counts,
+		Total:    p.Total(),
+		Deadline: p.Deadline,
+		Closed:   p.Closed(time.Now()),
+		DebateID: p.DebateID,
+		Stage:    p.Stage,
+	})
+}
+
+// userSender attributes an event to a user.
+func userSender(userID string) *events.Sender {
+	return &events.Sender{Type: events.SenderUser, ID: userID, Name: userID}
+}
@@ -0,0 +1,153 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+)
+
+// nextEventOf waits for the next event of type typ on sub, skipping others.
+func nextEventOf(t *testing.T, sub pubsub.Subscription, typ string) *events.Event {
+	t.Helper()
+	for {
+		if evt := nextEvent(t, sub); evt.Type == typ {
+			return evt
+		}
+	}
+}
+
+func TestPollVoting(t *testing.T) {
+	o, _, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	convID, _ := o.CreateConversation(ctx, "polls", nil, nil)
+	sub, _ := broker.Subscribe(ctx, "conversation:"+convID)
+	defer sub.Close()
+
+	if _, err := o.CreatePoll(ctx, convID, "u1", PollOptions{Question: "tea?", Options: []string{"yes"}}); !errors.Is(err, ErrInvalidPoll) {
+		t.Fatalf("expected ErrInvalidPoll for a single option, got %v", err)
+	}
+	p, err := o.CreatePoll(ctx, convID, "u1", PollOptions{Question: "tea or coffee?", Options: []string{"tea", "coffee"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextEventOf(t, sub, events.TypePollCreated)
+
+	if _, err := o.Vote(ctx, p.ID, "", 0); !errors.Is(err, ErrInvalidVote) {
+		t.Fatalf("expected ErrInvalidVote for an anonymous vote, got %v", err)
+	}
+	if _, err := o.Vote(ctx, p.ID, "u1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Vote(ctx, p.ID, "u2", 0); err != nil {
+		t.Fatal(err)
+	}
+	// u2 changes their mind; each voter counts once
+	if p, err = o.Vote(ctx, p.ID, "u2", 1); err != nil || p.Counts[0] != 1 || p.Counts[1] != 1 {
+		t.Fatalf("expected a 1-1 tally, got %+v (%v)", p, err)
+	}
+	var tally events.PollPayload
+	for i := 0; i < 3; i++ {
+		evt := nextEventOf(t, sub, events.TypePollVoted)
+		if err := evt.Decode(&tally); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tally.Total != 2 || tally.Counts[1] != 1 || tally.Closed {
+		t.Fatalf("unexpected live tally %+v", tally)
+	}
+	if _, err := o.Vote(ctx, p.ID, "u3", 2); !errors.Is(err, ErrInvalidVote) {
+		t.Fatalf("expected ErrInvalidVote for a missing option, got %v", err)
+	}
+
+	if _, err := o.ClosePoll(ctx, p.ID); err != nil {
+		t.Fatal(err)
+	}
+	if evt := nextEventOf(t, sub, events.TypePollClosed); evt.Decode(&tally) != nil || !tally.Closed || tally.Total != 2 {
+		t.Fatalf("unexpected final tally %+v", tally)
+	}
+	if _, err := o.Vote(ctx, p.ID, "u3", 0); !errors.Is(err, persistence.ErrPollClosed) {
+		t.Fatalf("expected ErrPollClosed voting in a closed poll, got %v", err)
+	}
+	if _, err := o.ClosePoll(ctx, p.ID); !errors.Is(err, persistence.ErrPollClosed) {
+		t.Fatalf("expected ErrPollClosed closing twice, got %v", err)
+	}
+}
+
+func TestPollClosesAtDeadline(t *testing.T) {
+	o, _, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	convID, _ := o.CreateConversation(ctx, "polls", nil, nil)
+	sub, _ := broker.Subscribe(ctx, "conversation:"+convID)
+	defer sub.Close()
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	p, err := o.CreatePoll(ctx, convID, "u1", PollOptions{Question: "now?", Options: []string{"yes", "no"}, Deadline: &deadline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nextEventOf(t, sub, events.TypePollClosed)
+	if p, _ = o.Poll(ctx, p.ID); p.ClosedAt == nil {
+		t.Fatalf("expected the poll to be closed, got %+v", p)
+	}
+
+	closed := persistence.ConversationClosed
+	if _, err := o.UpdateConversation(ctx, convID, persistence.ConversationPatch{Status: &closed}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.CreatePoll(ctx, convID, "u1", PollOptions{Question: "still?", Options: []string{"yes", "no"}}); !errors.Is(err, ErrConversationClosed) {
+		t.Fatalf("expected ErrConversationClosed, got %v", err)
+	}
+}
+
+func TestAudienceDecidesDebate(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	alice, _ := store.CreateAgent(ctx, "Alice", "cats", nil)
+	bob, _ := store.CreateAgent(ctx, "Bob", "dogs", nil)
+	convID, _ := o.CreateConversation(ctx, "debate", []string{alice, bob}, nil)
+	sub, _ := broker.Subscribe(ctx, "conversation:"+convID)
+	defer sub.Close()
+
+	d, err := o.StartDebateAsync(ctx, convID, DebateOptions{Format: FormatOxford, Topic: "cats beat dogs", AudienceVote: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the audience votes for, against or undecided (options 0, 1, 2), before and after
+	poll := func(stage string, votes ...int) {
+		t.Helper()
+		var p events.PollPayload
+		if err := nextEventOf(t, sub, events.TypePollCreated).Decode(&p); err != nil || p.Stage != stage || p.DebateID != d.ID {
+			t.Fatalf("expected the %s-debate poll, got %+v (%v)", stage, p, err)
+		}
+		if len(p.Options) != 3 || p.Options[0] != SideFor || p.Options[2] != audienceUndecided {
+			t.Fatalf("unexpected audience options %v", p.Options)
+		}
+		for i, v := range votes {
+			if _, err := o.Vote(ctx, p.PollID, string(rune('a'+i)), v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := o.ClosePoll(ctx, p.PollID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	poll(persistence.PollBeforeDebate, 0, 1, 1, 2)
+	poll(persistence.PollAfterDebate, 0, 0, 1, 1)
+
+	var verdict events.DebatePayload
+	if err := nextEventOf(t, sub, events.TypeDebateJudged).Decode(&verdict); err != nil || verdict.WinningSide != SideFor {
+		t.Fatalf("expected the for side to win the audience, got %+v (%v)", verdict, err)
+	}
+	res, err := o.DebateResult(ctx, d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DecidedBy != persistence.DecidedByAudience || res.Audience == nil || res.Audience.Swing[0] != 25 || !res.Final {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"errors"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+)
+
+// ErrInvalidReply is returned for a reply to a message that is not in the conversation.
+var ErrInvalidReply = errors.New("reply_to is not a message of this conversation")
+
+// mentionedFirst puts the participants trigger mentions, in mention order,
+// ahead of the turn policy's picks. Mentioned agents always reply; the picks
+// fill the turn up to as many speakers as the policy chose.
+func mentionedFirst(picks, participants []agent.Agent, trigger *agent.Message) []agent.Agent {
+	mentioned := trigger.Mentioned()
+	if len(mentioned) == 0 {
+		return picks
+	}
+	byID := make(map[string]agent.Agent, len(participants))
+	for _, a := range participants {
+		byID[string(a.ID)] = a
+	}
+	var out []agent.Agent
+	chosen := make(map[agent.AgentID]bool)
+	for _, id := range mentioned {
+		if a, ok := byID[id]; ok {
+			out = append(out, a)
+			chosen[a.ID] = true
+		}
+	}
+	n := max(len(picks), len(out))
+	for _, a := range picks {
+		if len(out) == n {
+			break
+		}
+		if !chosen[a.ID] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+func TestMentionedAgentsReplyFirstInThread(t *testing.T) {
+	o, store, broker := newTestOrchestrator(t)
+	ctx := context.Background()
+	var ids []string
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		id, _ := store.CreateAgent(ctx, name, "talker", nil)
+		ids = append(ids, id)
+	}
+	convID, _ := o.CreateConversation(ctx, "threads", ids, nil)
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+	defer sub.Close()
+
+	userID, err := o.HandleUserMessage(ctx, convID, "u1", "@dave and @Carol, what do you think?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p events.MessagePayload
+	if err := nextEventOf(t, sub, events.TypeMessageCreated).Decode(&p); err != nil || len(p.Mentions) != 2 || p.Mentions[0].AgentID != ids[3] {
+		t.Fatalf("expected the user message to mention Dave and Carol, got %+v (%v)", p, err)
+	}
+	// the mentioned agents answer first; round-robin fills the turn up to three speakers
+	var speakers []string
+	for len(speakers) < 3 {
+		evt := nextEventOf(t, sub, events.TypeMessageCreated)
+		if err := evt.Decode(&p); err != nil || p.ReplyTo != userID || p.ThreadID != userID {
+			t.Fatalf("expected a reply threaded under the user message, got %+v (%v)", p, err)
+		}
+		speakers = append(speakers, evt.Sender.Name)
+	}
+	if speakers[0] != "Dave" || speakers[1] != "Carol" || speakers[2] != "Alice" {
+		t.Fatalf("expected Dave, Carol, Alice, got %v", speakers)
+	}
+
+	// replying to an agent keeps the thread
+	replies, _ := store.ListMessages(ctx, convID, persistence.MessageQuery{SenderID: ids[3]})
+	if _, err := o.HandleUserReply(ctx, convID, "u1", "@Bob agree?", replies[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := nextEventOf(t, sub, events.TypeMessageCreated).Decode(&p); err != nil || p.ReplyTo != replies[0].ID || p.ThreadID != userID {
+		t.Fatalf("expected the reply to stay in the thread, got %+v (%v)", p, err)
+	}
+	thread, _ := store.ListMessages(ctx, convID, persistence.MessageQuery{ThreadID: userID})
+	if len(thread) < 5 || thread[0].ID != userID {
+		t.Fatalf("expected the thread to start with the user message, got %d messages", len(thread))
+	}
+
+	other, _ := o.CreateConversation(ctx, "other", nil, nil)
+	if _, err := o.HandleUserReply(ctx, other, "u1", "hi", userID); !errors.Is(err, ErrInvalidReply) {
+		t.Fatalf("expected ErrInvalidReply across conversations, got %v", err)
+	}
+}
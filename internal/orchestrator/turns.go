@@ -0,0 +1,318 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+)
+
+// TurnContext is what a TurnPolicy sees when choosing who replies.
+type TurnContext struct {
+	ConversationID string
+	Participants   []agent.Agent
+	Messages       []agent.Message
+	// Decider resolves an agent's decider; bid-based policies ask it for a score.
+	Decider func(a *agent.Agent) agent.Decider
+}
+
+// trigger returns the latest message, which the policies react to.
+func (tc *TurnContext) trigger() string {
+	if len(tc.Messages) == 0 {
+		return ""
+	}
+	return tc.Messages[len(tc.Messages)-1].Content
+}
+
+// TurnPolicy selects which participants reply to a message, in speaking order.
+type TurnPolicy interface {
+	Select(ctx context.Context, tc *TurnContext) ([]agent.Agent, error)
+}
+
+// TurnPolicyConfig is read from conversations.metadata["turn_policy"], e.g.
+// {"name": "random", "max_speakers": 2, "delay_ms": 800, "jitter_ms": 1200}.
+type TurnPolicyConfig struct {
+	Name        string  `json:"name"`         // round_robin (default), random, relevance, mentions, bid
+	MaxSpeakers int     `json:"max_speakers"` // upper bound on replies per message, default 3
+	MinSpeakers int     `json:"min_speakers"` // random: lower bound, default 1
+	Threshold   float64 `json:"threshold"`    // bid: minimum score to speak
+	DelayMS     int     `json:"delay_ms"`     // pause between speakers, default responseDelay
+	JitterMS    int     `json:"jitter_ms"`    // random extra pause added to DelayMS
+}
+
+// metadataTurnPolicyKey is the conversations.metadata key holding a TurnPolicyConfig.
+const metadataTurnPolicyKey = "turn_policy"
+
+const defaultMaxSpeakers = 3
+
+// turnPolicyConfigFrom extracts the turn policy config from conversation metadata.
+func turnPolicyConfigFrom(md map[string]interface{}) (TurnPolicyConfig, error) {
+	var cfg TurnPolicyConfig
+	if raw, ok := md[metadataTurnPolicyKey]; ok && raw != nil {
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.Name == "" {
+		cfg.Name = "round_robin"
+	}
+	if cfg.MaxSpeakers <= 0 {
+		cfg.MaxSpeakers = defaultMaxSpeakers
+	}
+	return cfg, nil
+}
+
+// newTurnPolicy builds the policy named by cfg.
+func newTurnPolicy(cfg TurnPolicyConfig, embed embedFunc) (TurnPolicy, error) {
+	switch cfg.Name {
+	case "round_robin":
+		return &RoundRobinPolicy{Max: cfg.MaxSpeakers}, nil
+	case "random":
+		return &RandomSubsetPolicy{Min: cfg.MinSpeakers, Max: cfg.MaxSpeakers}, nil
+	case "relevance":
+		return &RelevancePolicy{Max: cfg.MaxSpeakers, Embed: embed}, nil
+	case "mentions":
+		return &MentionPolicy{Max: cfg.MaxSpeakers}, nil
+	case "bid":
+		return &BidPolicy{Max: cfg.MaxSpeakers, Threshold: cfg.Threshold}, nil
+	default:
+		return nil, fmt.Errorf("unknown turn policy %q", cfg.Name)
+	}
+}
+
+// ErrInvalidTurnPolicy is returned when conversation metadata holds an unusable turn policy.
+var ErrInvalidTurnPolicy = errors.New("invalid turn policy")
+
+// validateTurnPolicy checks the turn policy in conversation metadata, if any.
+func validateTurnPolicy(md map[string]interface{}) error {
+	cfg, err := turnPolicyConfigFrom(md)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTurnPolicy, err)
+	}
+	if _, err := newTurnPolicy(cfg, nil); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTurnPolicy, err)
+	}
+	return nil
+}
+
+// delay returns the pause before the next speaker under cfg.
+func (cfg TurnPolicyConfig) delay(def time.Duration) time.Duration {
+	d := def
+	if cfg.DelayMS > 0 {
+		d = time.Duration(cfg.DelayMS) * time.Millisecond
+	}
+	if cfg.JitterMS > 0 {
+		d += time.Duration(rand.Intn(cfg.JitterMS)) * time.Millisecond
+	}
+	return d
+}
+
+// RoundRobinPolicy lets up to Max participants reply, starting one seat
+// further around the table on every turn of a conversation.
+type RoundRobinPolicy struct {
+	Max int
+
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func (p *RoundRobinPolicy) Select(ctx context.Context, tc *TurnContext) ([]agent.Agent, error) {
+	n := len(tc.Participants)
+	if n == 0 {
+		return nil, nil
+	}
+	p.mu.Lock()
+	if p.next == nil {
+		p.next = make(map[string]int)
+	}
+	start := p.next[tc.ConversationID] % n
+	p.next[tc.ConversationID] = start + 1
+	p.mu.Unlock()
+
+	out := make([]agent.Agent, 0, n)
+	for i := 0; i < n && i < limitOrAll(p.Max, n); i++ {
+		out = append(out, tc.Participants[(start+i)%n])
+	}
+	return out, nil
+}
+
+// RandomSubsetPolicy lets a random number (between Min and Max) of randomly chosen participants reply.
+type RandomSubsetPolicy struct {
+	Min int
+	Max int
+}
+
+func (p *RandomSubsetPolicy) Select(ctx context.Context, tc *TurnContext) ([]agent.Agent, error) {
+	n := len(tc.Participants)
+	if n == 0 {
+		return nil, nil
+	}
+	hi := limitOrAll(p.Max, n)
+	lo := p.Min
+	if lo <= 0 {
+		lo = 1
+	}
+	if lo > hi {
+		lo = hi
+	}
+	k := lo + rand.Intn(hi-lo+1)
+	out := append([]agent.Agent(nil), tc.Participants...)
+	rand.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out[:k], nil
+}
+
+// embedFunc turns text into an embedding vector.
+type embedFunc func(ctx context.Context, text string) (embeddings.Vector, error)
+
+// RelevancePolicy lets the Max participants whose persona is most similar to
+// the latest message reply, most relevant first. Without embeddings it keeps join order.
+type RelevancePolicy struct {
+	Max   int
+	Embed embedFunc
+
+	mu       sync.Mutex
+	personas map[string]embeddings.Vector // agent id + persona -> embedding
+}
+
+func (p *RelevancePolicy) Select(ctx context.Context, tc *TurnContext) ([]agent.Agent, error) {
+	n := len(tc.Participants)
+	limit := limitOrAll(p.Max, n)
+	if n == 0 || p.Embed == nil || tc.trigger() == "" {
+		return tc.Participants[:limit], nil
+	}
+	query, err := p.Embed(ctx, tc.trigger())
+	if err != nil {
+		return tc.Participants[:limit], nil
+	}
+	type scored struct {
+		a     agent.Agent
+		score float64
+	}
+	ranked := make([]scored, 0, n)
+	for _, a := range tc.Participants {
+		vec, err := p.personaVector(ctx, a)
+		if err != nil {
+			return tc.Participants[:limit], nil
+		}
+		ranked = append(ranked, scored{a: a, score: cosine(query, vec)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	out := make([]agent.Agent, 0, limit)
+	for _, s := range ranked[:limit] {
+		out = append(out, s.a)
+	}
+	return out, nil
+}
+
+func (p *RelevancePolicy) personaVector(ctx context.Context, a agent.Agent) (embeddings.Vector, error) {
+	key := string(a.ID) + "|" + a.Name + "|" + a.Persona
+	p.mu.Lock()
+	vec, ok := p.personas[key]
+	p.mu.Unlock()
+	if ok {
+		return vec, nil
+	}
+	vec, err := p.Embed(ctx, a.Name+": "+a.Persona)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	if p.personas == nil {
+		p.personas = make(map[string]embeddings.Vector)
+	}
+	p.personas[key] = vec
+	p.mu.Unlock()
+	return vec, nil
+}
+
+// MentionPolicy lets only participants addressed as "@Name" in the latest message
+// reply, in the order they were mentioned.
+type MentionPolicy struct {
+	Max int
+}
+
+func (p *MentionPolicy) Select(ctx context.Context, tc *TurnContext) ([]agent.Agent, error) {
+	if len(tc.Messages) == 0 {
+		return nil, nil
+	}
+	latest := tc.Messages[len(tc.Messages)-1]
+	if latest.Mentions == nil {
+		latest.Mentions = agent.ParseMentions(latest.Content, tc.Participants)
+	}
+	out := mentionedFirst(nil, tc.Participants, &latest)
+	return out[:limitOrAll(p.Max, len(out))], nil
+}
+
+// BidPolicy asks every participant's decider for a desire-to-speak score and lets
+// the highest bidders at or above Threshold reply. A little noise breaks ties.
+type BidPolicy struct {
+	Max       int
+	Threshold float64
+}
+
+func (p *BidPolicy) Select(ctx context.Context, tc *TurnContext) ([]agent.Agent, error) {
+	state := &agent.ConversationState{ConversationID: tc.ConversationID, Messages: tc.Messages}
+	type bid struct {
+		a     agent.Agent
+		score float64
+	}
+	var bids []bid
+	for i := range tc.Participants {
+		a := &tc.Participants[i]
+		score := agent.DefaultBid(a, state)
+		if tc.Decider != nil {
+			if b, ok := tc.Decider(a).(agent.Bidder); ok {
+				if s, err := b.Bid(ctx, a, state); err == nil {
+					score = s
+				}
+			}
+		}
+		if score < p.Threshold || score <= 0 {
+			continue
+		}
+		bids = append(bids, bid{a: *a, score: score + rand.Float64()*0.1})
+	}
+	sort.SliceStable(bids, func(i, j int) bool { return bids[i].score > bids[j].score })
+	out := make([]agent.Agent, 0, len(bids))
+	for _, b := range bids[:limitOrAll(p.Max, len(bids))] {
+		out = append(out, b.a)
+	}
+	return out, nil
+}
+
+// limitOrAll clamps max to n, treating max <= 0 as "no limit".
+func limitOrAll(max, n int) int {
+	if max <= 0 || max > n {
+		return n
+	}
+	return max
+}
+
+// cosine returns the cosine similarity of two vectors (0 when undefined).
+func cosine(a, b embeddings.Vector) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
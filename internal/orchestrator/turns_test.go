@@ -0,0 +1,111 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/embeddings"
+)
+
+func testParticipants() []agent.Agent {
+	return []agent.Agent{
+		{ID: "a1", Name: "Alice", Persona: "music"},
+		{ID: "a2", Name: "Bob", Persona: "fitness"},
+		{ID: "a3", Name: "Carol", Persona: "cooking"},
+	}
+}
+
+func names(agents []agent.Agent) []string {
+	out := make([]string, 0, len(agents))
+	for _, a := range agents {
+		out = append(out, a.Name)
+	}
+	return out
+}
+
+func TestRoundRobinPolicyRotates(t *testing.T) {
+	p := &RoundRobinPolicy{Max: 2}
+	tc := &TurnContext{ConversationID: "c1", Participants: testParticipants()}
+	want := [][]string{{"Alice", "Bob"}, {"Bob", "Carol"}, {"Carol", "Alice"}}
+	for i, w := range want {
+		got, err := p.Select(context.Background(), tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g := names(got); len(g) != 2 || g[0] != w[0] || g[1] != w[1] {
+			t.Fatalf("turn %d: expected %v, got %v", i, w, g)
+		}
+	}
+}
+
+func TestRandomSubsetPolicyBounds(t *testing.T) {
+	p := &RandomSubsetPolicy{Min: 1, Max: 2}
+	tc := &TurnContext{ConversationID: "c1", Participants: testParticipants()}
+	for i := 0; i < 50; i++ {
+		got, err := p.Select(context.Background(), tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) < 1 || len(got) > 2 {
+			t.Fatalf("expected 1-2 speakers, got %d", len(got))
+		}
+	}
+}
+
+func TestMentionPolicyOnlyMentioned(t *testing.T) {
+	p := &MentionPolicy{}
+	tc := &TurnContext{Participants: testParticipants(), Messages: []agent.Message{{Content: "hey @carol what do you think?"}}}
+	got, _ := p.Select(context.Background(), tc)
+	if g := names(got); len(g) != 1 || g[0] != "Carol" {
+		t.Fatalf("expected [Carol], got %v", g)
+	}
+	tc.Messages = []agent.Message{{Content: "nobody in particular"}}
+	if got, _ := p.Select(context.Background(), tc); len(got) != 0 {
+		t.Fatalf("expected no speakers, got %v", names(got))
+	}
+}
+
+func TestRelevancePolicyRanksBySimilarity(t *testing.T) {
+	embed := func(ctx context.Context, text string) (embeddings.Vector, error) {
+		switch {
+		case text == "Bob: fitness" || text == "let's talk about running":
+			return embeddings.Vector{1, 0}, nil
+		default:
+			return embeddings.Vector{0, 1}, nil
+		}
+	}
+	p := &RelevancePolicy{Max: 1, Embed: embed}
+	tc := &TurnContext{Participants: testParticipants(), Messages: []agent.Message{{Content: "let's talk about running"}}}
+	got, _ := p.Select(context.Background(), tc)
+	if g := names(got); len(g) != 1 || g[0] != "Bob" {
+		t.Fatalf("expected [Bob], got %v", g)
+	}
+}
+
+func TestBidPolicyUsesDeciderScores(t *testing.T) {
+	participants := testParticipants()
+	participants[0].BehaviorProfile = map[string]interface{}{"talkativeness": 0.9}
+	participants[1].BehaviorProfile = map[string]interface{}{"talkativeness": 0.1}
+	participants[2].BehaviorProfile = map[string]interface{}{"talkativeness": 0.6}
+	p := &BidPolicy{Max: 3, Threshold: 0.5}
+	tc := &TurnContext{
+		Participants: participants,
+		Messages:     []agent.Message{{Content: "hello"}},
+		Decider:      func(*agent.Agent) agent.Decider { return &agent.SimpleDecider{} },
+	}
+	got, _ := p.Select(context.Background(), tc)
+	if g := names(got); len(g) != 2 || g[0] != "Alice" || g[1] != "Carol" {
+		t.Fatalf("expected [Alice Carol], got %v", g)
+	}
+}
+
+func TestTurnPolicyConfigValidation(t *testing.T) {
+	if err := validateTurnPolicy(nil); err != nil {
+		t.Fatalf("expected default policy to be valid: %v", err)
+	}
+	bad := map[string]interface{}{"turn_policy": map[string]interface{}{"name": "chaos"}}
+	if err := validateTurnPolicy(bad); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
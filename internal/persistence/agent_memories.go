@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+)
+
+// ScoredMemory is a memory search hit: the memory and its cosine similarity to the query.
+type ScoredMemory struct {
+	agent.Memory
+	Score float64 `json:"score"`
+}
+
+// memoryColumns are the columns scanMemory expects, in order, from agent_memories.
+const memoryColumns = `id::text, agent_id::text, kind, COALESCE(subject, ''), content,
+	COALESCE(conversation_id::text, ''), COALESCE(source_message_id::text, ''), created_at`
+
+// scanMemory reads a row selected with memoryColumns followed by extra columns.
+func scanMemory(row pgx.Row, m *agent.Memory, extra ...interface{}) error {
+	dest := append([]interface{}{&m.ID, &m.AgentID, &m.Kind, &m.Subject, &m.Content, &m.ConversationID, &m.SourceMessageID, &m.CreatedAt}, extra...)
+	return row.Scan(dest...)
+}
+
+// SaveMemory stores m with its embedding and sets its ID and CreatedAt. Like
+// SaveEmbedding it keeps the double precision[] copy and fills the pgvector
+// column when its dimensions match.
+func (s *PostgresStore) SaveMemory(ctx context.Context, m *agent.Memory, vec []float32) error {
+	args := []interface{}{m.AgentID, m.Kind, nullable(m.Subject), m.Content, nullable(m.ConversationID), nullable(m.SourceMessageID), toFloat64s(vec)}
+	query := `INSERT INTO agent_memories
+		(agent_id, kind, subject, content, conversation_id, source_message_id, vector)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id::text, created_at`
+	if s.memANN {
+		var embedding *pgvector.Vector
+		if len(vec) == s.vec.Dimensions {
+			e := pgvector.NewVector(vec)
+			embedding = &e
+		}
+		args = append(args, embedding)
+		query = `INSERT INTO agent_memories
+			(agent_id, kind, subject, content, conversation_id, source_message_id, vector, embedding)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id::text, created_at`
+	}
+	return notFound(s.pool.QueryRow(ctx, query, args...).Scan(&m.ID, &m.CreatedAt))
+}
+
+// ListMemories returns up to limit memories of an agent, newest first; limit <= 0 returns all.
+func (s *PostgresStore) ListMemories(ctx context.Context, agentID string, limit int) ([]agent.Memory, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+memoryColumns+` FROM agent_memories
+		WHERE agent_id=$1 ORDER BY created_at DESC, id LIMIT NULLIF($2::int, 0)`, agentID, limit)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer rows.Close()
+	var out []agent.Memory
+	for rows.Next() {
+		var m agent.Memory
+		if err := scanMemory(rows, &m); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// SearchMemories returns the k memories of an agent most similar to vec by cosine
+// similarity. With pgvector they are ranked in Postgres; otherwise every memory
+// of the agent is scored in Go.
+func (s *PostgresStore) SearchMemories(ctx context.Context, agentID string, vec []float32, k int) ([]ScoredMemory, error) {
+	if s.memANN && len(vec) == s.vec.Dimensions {
+		if k <= 0 {
+			k = 10
+		}
+		// the agent filter would truncate a plain index scan, as in SearchMessages
+		orderBy, iterative := s.nearest("embedding", "$2", MetricCosine, true, true)
+		var hits []ScoredMemory
+		err := s.queryVectors(ctx, iterative, "SELECT "+memoryColumns+`, 1 - (embedding <=> $2) FROM agent_memories
+			WHERE agent_id=$1 AND embedding IS NOT NULL ORDER BY `+orderBy+` LIMIT $3`,
+			[]interface{}{agentID, pgvector.NewVector(vec), k}, func(rows pgx.Rows) error {
+				var h ScoredMemory
+				if err := scanMemory(rows, &h.Memory, &h.Score); err != nil {
+					return err
+				}
+				hits = append(hits, h)
+				return nil
+			})
+		if err != nil {
+			return nil, notFound(err)
+		}
+		return hits, nil
+	}
+	rows, err := s.pool.Query(ctx, "SELECT "+memoryColumns+", vector FROM agent_memories WHERE agent_id=$1", agentID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer rows.Close()
+	var hits []ScoredMemory
+	for rows.Next() {
+		var (
+			h      ScoredMemory
+			stored []float64
+		)
+		if err := scanMemory(rows, &h.Memory, &stored); err != nil {
+			return nil, err
+		}
+		h.Score = cosineSimilarity(vec, toFloat32s(stored))
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return topMemories(hits, k), nil
+}
+
+// topMemories sorts hits by descending score and truncates to k (10 when k <= 0).
+func topMemories(hits []ScoredMemory, k int) []ScoredMemory {
+	if k <= 0 {
+		k = 10
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
@@ -0,0 +1,103 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Autonomous run states. Running and paused runs are live; the others are final.
+const (
+	AutonomousRunning   = "running"
+	AutonomousPaused    = "paused"
+	AutonomousFinished  = "finished"
+	AutonomousCancelled = "cancelled"
+	AutonomousFailed    = "failed"
+)
+
+// Why a finished autonomous run stopped, in AutonomousRun.StopReason.
+const (
+	StopMaxTurns       = "max_turns"
+	StopMaxDuration    = "max_duration"
+	StopConsensus      = "consensus"
+	StopTopicExhausted = "topic_exhausted"
+	StopLeave          = "leave" // fewer than two agents are left
+)
+
+// AutonomousRun is a stretch of conversation the agents hold among themselves
+// on a topic, and how far it got.
+type AutonomousRun struct {
+	ID                 string     `json:"id"`
+	ConversationID     string     `json:"conversation_id"`
+	Topic              string     `json:"topic"`
+	Participants       []string   `json:"participants"` // agent ids taking part
+	MaxTurns           int        `json:"max_turns"`
+	MaxDurationSeconds int        `json:"max_duration_seconds"`
+	StopOn             []string   `json:"stop_on"` // stopping conditions in force: consensus, topic_exhausted, leave
+	State              string     `json:"state"`
+	Turns              int        `json:"turns"`                 // agent messages posted so far
+	SpeakerID          string     `json:"speaker_id,omitempty"`  // participant whose turn it is
+	Left               []string   `json:"left,omitempty"`        // participants that left the run
+	StopReason         string     `json:"stop_reason,omitempty"` // why a finished run stopped
+	Error              string     `json:"error,omitempty"`       // why a failed run stopped
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	EndedAt            *time.Time `json:"ended_at,omitempty"`
+}
+
+// Ended reports whether r is in a final state.
+func (r *AutonomousRun) Ended() bool {
+	return r.State != AutonomousRunning && r.State != AutonomousPaused
+}
+
+// autonomousColumns are the columns scanAutonomousRun reads, in order.
+const autonomousColumns = `id, conversation_id, topic, participants, max_turns, max_duration_seconds, stop_on, state, turns,
+	COALESCE(speaker_id, ''), left_ids, COALESCE(stop_reason, ''), COALESCE(error, ''), created_at, updated_at, ended_at`
+
+func scanAutonomousRun(row pgx.Row) (*AutonomousRun, error) {
+	var r AutonomousRun
+	err := row.Scan(&r.ID, &r.ConversationID, &r.Topic, &r.Participants, &r.MaxTurns, &r.MaxDurationSeconds, &r.StopOn, &r.State, &r.Turns,
+		&r.SpeakerID, &r.Left, &r.StopReason, &r.Error, &r.CreatedAt, &r.UpdatedAt, &r.EndedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Left) == 0 {
+		r.Left = nil
+	}
+	return &r, nil
+}
+
+// CreateAutonomousRun stores r and sets its ID, CreatedAt and UpdatedAt.
+func (s *PostgresStore) CreateAutonomousRun(ctx context.Context, r *AutonomousRun) error {
+	stopOn := r.StopOn
+	if stopOn == nil {
+		stopOn = []string{}
+	}
+	err := s.pool.QueryRow(ctx, `INSERT INTO autonomous_runs (conversation_id, topic, participants, max_turns, max_duration_seconds, stop_on, state)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`,
+		r.ConversationID, r.Topic, r.Participants, r.MaxTurns, r.MaxDurationSeconds, stopOn, r.State).Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+	return notFound(err)
+}
+
+// UpdateAutonomousRun saves the progress of r (state, turns, speaker, who left,
+// stop reason, error, end) and sets UpdatedAt.
+func (s *PostgresStore) UpdateAutonomousRun(ctx context.Context, r *AutonomousRun) error {
+	left := r.Left
+	if left == nil {
+		left = []string{}
+	}
+	err := s.pool.QueryRow(ctx, `UPDATE autonomous_runs SET state=$2, turns=$3, speaker_id=$4, left_ids=$5, stop_reason=$6, error=$7, ended_at=$8, updated_at=now()
+		WHERE id=$1 RETURNING updated_at`,
+		r.ID, r.State, r.Turns, nullable(r.SpeakerID), left, nullable(r.StopReason), nullable(r.Error), r.EndedAt).Scan(&r.UpdatedAt)
+	return notFound(err)
+}
+
+// GetAutonomousRun returns a single autonomous run.
+func (s *PostgresStore) GetAutonomousRun(ctx context.Context, id string) (*AutonomousRun, error) {
+	r, err := scanAutonomousRun(s.pool.QueryRow(ctx, "SELECT "+autonomousColumns+" FROM autonomous_runs WHERE id=$1", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return r, nil
+}
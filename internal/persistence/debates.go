@@ -0,0 +1,208 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Debate states. Running and paused debates are live; the others are final.
+const (
+	DebateRunning   = "running"
+	DebatePaused    = "paused"
+	DebateFinished  = "finished"
+	DebateCancelled = "cancelled"
+	DebateFailed    = "failed"
+)
+
+// Debate is a debate session and how far it got.
+type Debate struct {
+	ID             string            `json:"id"`
+	ConversationID string            `json:"conversation_id"`
+	Format         string            `json:"format"`
+	Topic          string            `json:"topic,omitempty"`
+	Participants   []string          `json:"participants"`    // debater agent ids in speaking order
+	Sides          map[string]string `json:"sides,omitempty"` // debater id -> side, in formats with sides
+	ModeratorID    string            `json:"moderator_id,omitempty"`
+	JudgeID        string            `json:"judge_id,omitempty"`
+	AudienceVote   bool              `json:"audience_vote,omitempty"` // the audience votes before and after
+	Rounds         int               `json:"rounds"`
+	State          string            `json:"state"`
+	Round          int               `json:"round"`                // current (or last) round, from 1; 0 before the first
+	Phase          string            `json:"phase,omitempty"`      // phase of the current round, e.g. "rebuttal"
+	SpeakerID      string            `json:"speaker_id,omitempty"` // participant whose turn it is
+	Error          string            `json:"error,omitempty"`      // why a failed debate stopped
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	EndedAt        *time.Time        `json:"ended_at,omitempty"`
+}
+
+// Ended reports whether d is in a final state.
+func (d *Debate) Ended() bool {
+	return d.State != DebateRunning && d.State != DebatePaused
+}
+
+// Who decided a debate, in DebateResult.DecidedBy.
+const (
+	DecidedByJudge    = "judge"
+	DecidedByAudience = "audience"
+)
+
+// RoundScores are a judge's scores for one debate round.
+type RoundScores struct {
+	Round  int                `json:"round"`
+	Phase  string             `json:"phase,omitempty"`
+	Scores map[string]float64 `json:"scores"` // debater id -> score
+}
+
+// AudienceVote is how the audience voted before and after a debate. Keys
+// are what each poll option stands for: a side, a debater id, or "undecided".
+type AudienceVote struct {
+	PrePollID  string    `json:"pre_poll_id"`
+	PostPollID string    `json:"post_poll_id"`
+	Keys       []string  `json:"keys"`
+	Pre        []int     `json:"pre"`   // votes per option before
+	Post       []int     `json:"post"`  // votes per option after
+	Swing      []float64 `json:"swing"` // change of each option's share of the vote, in percentage points
+}
+
+// DebateResult is the verdict on a debate, by its judge or its audience. It
+// is saved after every judged round; Final is set, with the winner, once the
+// debate has finished.
+type DebateResult struct {
+	DebateID    string             `json:"debate_id"`
+	JudgeID     string             `json:"judge_id,omitempty"`
+	Rounds      []RoundScores      `json:"rounds"`
+	Totals      map[string]float64 `json:"totals"`                // debater id -> sum of round scores
+	SideTotals  map[string]float64 `json:"side_totals,omitempty"` // side -> mean total of its debaters
+	WinnerID    string             `json:"winner_id,omitempty"`   // empty on a draw
+	WinningSide string             `json:"winning_side,omitempty"`
+	Audience    *AudienceVote      `json:"audience,omitempty"`
+	DecidedBy   string             `json:"decided_by,omitempty"` // "judge" or "audience"
+	Final       bool               `json:"final"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// debateColumns are the columns scanDebate reads, in order.
+const debateColumns = `id, conversation_id, format, COALESCE(topic, ''), participants, COALESCE(sides, '{}'::jsonb),
+	COALESCE(moderator_id, ''), COALESCE(judge_id, ''), audience_vote, rounds, state, round, COALESCE(phase, ''),
+	COALESCE(speaker_id, ''), COALESCE(error, ''), created_at, updated_at, ended_at`
+
+func scanDebate(row pgx.Row) (*Debate, error) {
+	var (
+		d     Debate
+		sides []byte
+	)
+	err := row.Scan(&d.ID, &d.ConversationID, &d.Format, &d.Topic, &d.Participants, &sides,
+		&d.ModeratorID, &d.JudgeID, &d.AudienceVote, &d.Rounds, &d.State, &d.Round, &d.Phase,
+		&d.SpeakerID, &d.Error, &d.CreatedAt, &d.UpdatedAt, &d.EndedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(sides, &d.Sides); err != nil {
+		return nil, err
+	}
+	if len(d.Sides) == 0 {
+		d.Sides = nil
+	}
+	return &d, nil
+}
+
+// CreateDebate stores d and sets its ID, CreatedAt and UpdatedAt.
+func (s *PostgresStore) CreateDebate(ctx context.Context, d *Debate) error {
+	var sides []byte
+	if len(d.Sides) > 0 {
+		var err error
+		if sides, err = json.Marshal(d.Sides); err != nil {
+			return err
+		}
+	}
+	err := s.pool.QueryRow(ctx, `INSERT INTO debates (conversation_id, format, topic, participants, sides, moderator_id, judge_id, audience_vote, rounds, state, round, phase, speaker_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at, updated_at`,
+		d.ConversationID, d.Format, nullable(d.Topic), d.Participants, sides, nullable(d.ModeratorID), nullable(d.JudgeID), d.AudienceVote,
+		d.Rounds, d.State, d.Round, nullable(d.Phase), nullable(d.SpeakerID)).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	return notFound(err)
+}
+
+// UpdateDebate saves the progress of d (state, round, phase, speaker, error, end) and sets UpdatedAt.
+func (s *PostgresStore) UpdateDebate(ctx context.Context, d *Debate) error {
+	err := s.pool.QueryRow(ctx, `UPDATE debates SET state=$2, round=$3, phase=$4, speaker_id=$5, error=$6, ended_at=$7, updated_at=now()
+		WHERE id=$1 RETURNING updated_at`,
+		d.ID, d.State, d.Round, nullable(d.Phase), nullable(d.SpeakerID), nullable(d.Error), d.EndedAt).Scan(&d.UpdatedAt)
+	return notFound(err)
+}
+
+// GetDebate returns a single debate.
+func (s *PostgresStore) GetDebate(ctx context.Context, id string) (*Debate, error) {
+	d, err := scanDebate(s.pool.QueryRow(ctx, "SELECT "+debateColumns+" FROM debates WHERE id=$1", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return d, nil
+}
+
+// SaveDebateResult stores r, replacing the debate's previous result, and sets UpdatedAt.
+func (s *PostgresStore) SaveDebateResult(ctx context.Context, r *DebateResult) error {
+	rounds, err := json.Marshal(r.Rounds)
+	if err != nil {
+		return err
+	}
+	totals, err := json.Marshal(r.Totals)
+	if err != nil {
+		return err
+	}
+	var sideTotals, audience []byte
+	if len(r.SideTotals) > 0 {
+		if sideTotals, err = json.Marshal(r.SideTotals); err != nil {
+			return err
+		}
+	}
+	if r.Audience != nil {
+		if audience, err = json.Marshal(r.Audience); err != nil {
+			return err
+		}
+	}
+	err = s.pool.QueryRow(ctx, `INSERT INTO debate_results (debate_id, judge_id, rounds, totals, side_totals, winner_id, winning_side, audience, decided_by, final)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (debate_id) DO UPDATE SET judge_id=EXCLUDED.judge_id, rounds=EXCLUDED.rounds, totals=EXCLUDED.totals,
+			side_totals=EXCLUDED.side_totals, winner_id=EXCLUDED.winner_id, winning_side=EXCLUDED.winning_side,
+			audience=EXCLUDED.audience, decided_by=EXCLUDED.decided_by, final=EXCLUDED.final, updated_at=now()
+		RETURNING updated_at`,
+		r.DebateID, nullable(r.JudgeID), rounds, totals, sideTotals, nullable(r.WinnerID), nullable(r.WinningSide),
+		audience, nullable(r.DecidedBy), r.Final).Scan(&r.UpdatedAt)
+	return notFound(err)
+}
+
+// GetDebateResult returns the judge's result of a debate.
+func (s *PostgresStore) GetDebateResult(ctx context.Context, debateID string) (*DebateResult, error) {
+	var (
+		r                                    DebateResult
+		rounds, totals, sideTotals, audience []byte
+	)
+	err := s.pool.QueryRow(ctx, `SELECT debate_id, COALESCE(judge_id, ''), rounds, totals, side_totals, COALESCE(winner_id, ''), COALESCE(winning_side, ''),
+		audience, COALESCE(decided_by, ''), final, updated_at
+		FROM debate_results WHERE debate_id=$1`, debateID).
+		Scan(&r.DebateID, &r.JudgeID, &rounds, &totals, &sideTotals, &r.WinnerID, &r.WinningSide, &audience, &r.DecidedBy, &r.Final, &r.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if err := json.Unmarshal(rounds, &r.Rounds); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(totals, &r.Totals); err != nil {
+		return nil, err
+	}
+	if len(sideTotals) > 0 {
+		if err := json.Unmarshal(sideTotals, &r.SideTotals); err != nil {
+			return nil, err
+		}
+	}
+	if len(audience) > 0 {
+		if err := json.Unmarshal(audience, &r.Audience); err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/events"
+)
+
+// AppendEvent assigns evt the conversation's next sequence number and appends it
+// to the event log. Numbering is serialized on the conversation row, so it is
+// gap-free and shared by every server writing to the same database.
+func (s *PostgresStore) AppendEvent(ctx context.Context, evt *events.Event) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var seq int64
+	err = tx.QueryRow(ctx, "UPDATE conversations SET last_event_seq = last_event_seq + 1 WHERE id=$1 RETURNING last_event_seq", evt.ConversationID).Scan(&seq)
+	if err != nil {
+		return notFound(err)
+	}
+	var messageID, senderType, senderID, senderName *string
+	if evt.MessageID != "" {
+		messageID = &evt.MessageID
+	}
+	if evt.Sender != nil {
+		senderType, senderID, senderName = &evt.Sender.Type, &evt.Sender.ID, &evt.Sender.Name
+	}
+	var payload []byte
+	if len(evt.Payload) > 0 {
+		payload = evt.Payload
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO conversation_events
+		(conversation_id, seq, type, version, message_id, sender_type, sender_id, sender_name, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		evt.ConversationID, seq, evt.Type, evt.Version, messageID, senderType, senderID, senderName, payload, evt.Timestamp)
+	if err != nil {
+		return notFound(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	evt.Seq = seq
+	return nil
+}
+
+// ListEvents returns up to limit logged events with seq > afterSeq in order;
+// limit <= 0 returns all of them.
+func (s *PostgresStore) ListEvents(ctx context.Context, conversationID string, afterSeq int64, limit int) ([]events.Event, error) {
+	rows, err := s.pool.Query(ctx, `SELECT seq, type, version, message_id::text, sender_type, sender_id, sender_name, payload, created_at
+		FROM conversation_events WHERE conversation_id=$1 AND seq > $2
+		ORDER BY seq ASC LIMIT NULLIF($3::int, 0)`, conversationID, afterSeq, limit)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer rows.Close()
+	var out []events.Event
+	for rows.Next() {
+		var (
+			evt                                         events.Event
+			messageID, senderType, senderID, senderName *string
+			payload                                     []byte
+			ts                                          time.Time
+		)
+		if err := rows.Scan(&evt.Seq, &evt.Type, &evt.Version, &messageID, &senderType, &senderID, &senderName, &payload, &ts); err != nil {
+			return nil, err
+		}
+		evt.ConversationID = conversationID
+		evt.Timestamp = ts.UTC()
+		if messageID != nil {
+			evt.MessageID = *messageID
+		}
+		if senderType != nil {
+			evt.Sender = &events.Sender{Type: *senderType, ID: deref(senderID), Name: deref(senderName)}
+		}
+		if len(payload) > 0 {
+			evt.Payload = payload
+		}
+		out = append(out, evt)
+	}
+	return out, rows.Err()
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// nullable maps "" to SQL NULL.
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// AckEvents records that userID has seen the conversation's events up to seq;
+// an older ack never replaces a newer one.
+func (s *PostgresStore) AckEvents(ctx context.Context, conversationID, userID string, seq int64) error {
+	_, err := s.pool.Exec(ctx, `INSERT INTO conversation_acks (conversation_id, user_id, seq) VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id, user_id) DO UPDATE SET seq = GREATEST(conversation_acks.seq, EXCLUDED.seq), updated_at = now()`,
+		conversationID, userID, seq)
+	return notFound(err)
+}
+
+// EventAck returns the latest seq userID acknowledged in the conversation, 0 when none.
+func (s *PostgresStore) EventAck(ctx context.Context, conversationID, userID string) (int64, error) {
+	var seq int64
+	err := s.pool.QueryRow(ctx, `SELECT COALESCE((SELECT seq FROM conversation_acks WHERE conversation_id=$1 AND user_id=$2), 0)
+		FROM conversations WHERE id=$1`, conversationID, userID).Scan(&seq)
+	return seq, notFound(err)
+}
@@ -0,0 +1,985 @@
+package persistence
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/events"
+)
+
+// MemoryStore is an in-process Store with the same semantics as PostgresStore.
+// It is used by tests and the devserver; nothing survives a restart.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	agents        map[string]agent.Agent
+	agentOrder    []string             // live agents in creation order
+	deletedAgents map[string]time.Time // soft-deleted agents; kept in agents
+	conversations map[string]*memConversation
+	messages      map[string]*agent.Message
+	embeddings    []memEmbedding
+	memories      []memMemory
+	debates       map[string]*Debate
+	debateResults map[string]*DebateResult // debate id -> result
+	autonomous    map[string]*AutonomousRun
+	polls         map[string]*memPoll
+}
+
+type memPoll struct {
+	Poll
+	votes map[string]int // voter id -> option
+}
+
+type memConversation struct {
+	Conversation
+	metadata     map[string]interface{}
+	messages     []string // message ids in insertion order
+	participants []string // agent ids in join order
+	events       []events.Event
+	acks         map[string]int64 // user id -> acknowledged seq
+}
+
+type memEmbedding struct {
+	conversationID string
+	messageID      string
+	vector         []float32
+}
+
+type memMemory struct {
+	agent.Memory
+	vector []float32
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		agents:        make(map[string]agent.Agent),
+		deletedAgents: make(map[string]time.Time),
+		conversations: make(map[string]*memConversation),
+		messages:      make(map[string]*agent.Message),
+		debates:       make(map[string]*Debate),
+		debateResults: make(map[string]*DebateResult),
+		autonomous:    make(map[string]*AutonomousRun),
+		polls:         make(map[string]*memPoll),
+	}
+}
+
+// newID returns a random (version 4) UUID string, matching the Postgres ids.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Close is a no-op for the in-memory store.
+func (s *MemoryStore) Close() {}
+
+// ListAgents returns all agents in creation order.
+func (s *MemoryStore) ListAgents(ctx context.Context) ([]agent.Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]agent.Agent, 0, len(s.agentOrder))
+	for _, id := range s.agentOrder {
+		out = append(out, copyAgent(s.agents[id]))
+	}
+	return out, nil
+}
+
+// CreateAgent adds a new agent and returns its id.
+func (s *MemoryStore) CreateAgent(ctx context.Context, name string, persona string, behaviorProfile map[string]interface{}) (string, error) {
+	id := newID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents[id] = copyAgent(agent.Agent{
+		ID:              agent.AgentID(id),
+		Name:            name,
+		Persona:         persona,
+		BehaviorProfile: behaviorProfile,
+		CreatedAt:       time.Now().UTC(),
+	})
+	s.agentOrder = append(s.agentOrder, id)
+	return id, nil
+}
+
+// CreateConversation creates an empty conversation and returns its id.
+func (s *MemoryStore) CreateConversation(ctx context.Context, title string) (string, error) {
+	id := newID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[id] = &memConversation{Conversation: Conversation{ID: id, Title: title, Status: ConversationActive, CreatedAt: time.Now().UTC()}}
+	return id, nil
+}
+
+// CreateConversationWith checks every agent before creating the conversation, so
+// a missing agent leaves no conversation behind.
+func (s *MemoryStore) CreateConversationWith(ctx context.Context, title string, agentIDs []string, md map[string]interface{}) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := &memConversation{Conversation: Conversation{ID: newID(), Title: title, Status: ConversationActive, CreatedAt: time.Now().UTC()}}
+	for _, agentID := range agentIDs {
+		if _, ok := s.liveAgent(agentID); !ok {
+			return "", fmt.Errorf("add participant %s: %w", agentID, ErrNotFound)
+		}
+		if !containsString(c.participants, agentID) {
+			c.participants = append(c.participants, agentID)
+		}
+	}
+	if md != nil {
+		c.metadata = copyMap(md)
+	}
+	s.conversations[c.ID] = c
+	return c.ID, nil
+}
+
+// ListConversations returns up to 100 conversations, newest first.
+func (s *MemoryStore) ListConversations(ctx context.Context) ([]Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Conversation, 0, len(s.conversations))
+	for _, c := range s.conversations {
+		out = append(out, c.Conversation)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > 100 {
+		out = out[:100]
+	}
+	return out, nil
+}
+
+// GetConversation returns a single conversation.
+func (s *MemoryStore) GetConversation(ctx context.Context, id string) (*Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := c.Conversation
+	return &cp, nil
+}
+
+// UpdateConversation applies patch to a conversation and returns the result.
+func (s *MemoryStore) UpdateConversation(ctx context.Context, id string, patch ConversationPatch) (*Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	patch.apply(&c.Conversation)
+	cp := c.Conversation
+	return &cp, nil
+}
+
+// DeleteConversation removes a conversation with its messages, embeddings and
+// events. Memories formed in it lose their conversation and source message links.
+func (s *MemoryStore) DeleteConversation(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, mid := range c.messages {
+		delete(s.messages, mid)
+	}
+	kept := s.embeddings[:0]
+	for _, e := range s.embeddings {
+		if e.conversationID != id {
+			kept = append(kept, e)
+		}
+	}
+	s.embeddings = kept
+	for i := range s.memories {
+		m := &s.memories[i]
+		if m.ConversationID == id {
+			m.ConversationID, m.SourceMessageID = "", ""
+		}
+	}
+	for did, d := range s.debates {
+		if d.ConversationID == id {
+			delete(s.debates, did)
+			delete(s.debateResults, did)
+		}
+	}
+	for pid, p := range s.polls {
+		if p.ConversationID == id {
+			delete(s.polls, pid)
+		}
+	}
+	for rid, r := range s.autonomous {
+		if r.ConversationID == id {
+			delete(s.autonomous, rid)
+		}
+	}
+	delete(s.conversations, id)
+	return nil
+}
+
+// GetConversationMetadata returns the metadata object of a conversation (nil when unset).
+func (s *MemoryStore) GetConversationMetadata(ctx context.Context, conversationID string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyMap(c.metadata), nil
+}
+
+// SetConversationMetadata replaces the metadata object of a conversation.
+func (s *MemoryStore) SetConversationMetadata(ctx context.Context, conversationID string, md map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return ErrNotFound
+	}
+	c.metadata = copyMap(md)
+	return nil
+}
+
+// InsertMessage appends m to its conversation and sets its ID and CreatedAt.
+func (s *MemoryStore) InsertMessage(ctx context.Context, m *agent.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[m.ConversationID]
+	if !ok {
+		return ErrNotFound
+	}
+	if m.ReplyTo != "" {
+		parent, ok := s.messages[m.ReplyTo]
+		if !ok || parent.ConversationID != m.ConversationID {
+			return ErrNotFound
+		}
+		m.ThreadID = parent.ThreadID
+		if m.ThreadID == "" {
+			m.ThreadID = parent.ID
+		}
+	}
+	m.ID = newID()
+	m.CreatedAt = time.Now().UTC()
+	stored := copyMessage(*m)
+	s.messages[m.ID] = &stored
+	c.messages = append(c.messages, m.ID)
+	return nil
+}
+
+// GetConversationMessages returns the messages of a conversation in insertion order.
+func (s *MemoryStore) GetConversationMessages(ctx context.Context, conversationID string) ([]agent.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return nil, nil
+	}
+	var out []agent.Message
+	for _, id := range c.messages {
+		out = append(out, copyMessage(*s.messages[id]))
+	}
+	return out, nil
+}
+
+// ListMessages returns the page of a conversation's messages selected by q.
+func (s *MemoryStore) ListMessages(ctx context.Context, conversationID string, q MessageQuery) ([]agent.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return nil, nil
+	}
+	cursor := func(id string, at time.Time) (*messageCursor, error) {
+		if id != "" {
+			m, ok := s.messages[id]
+			if !ok || m.ConversationID != conversationID {
+				return nil, ErrNotFound
+			}
+			return &messageCursor{at: m.CreatedAt, id: id}, nil
+		}
+		if !at.IsZero() {
+			return &messageCursor{at: at}, nil
+		}
+		return nil, nil
+	}
+	before, err := cursor(q.BeforeID, q.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := cursor(q.AfterID, q.After)
+	if err != nil {
+		return nil, err
+	}
+	var out []agent.Message
+	for _, id := range c.messages {
+		m := s.messages[id]
+		if (before != nil && !before.before(m)) || (after != nil && !after.after(m)) {
+			continue
+		}
+		if q.SenderID != "" && m.SenderID != q.SenderID {
+			continue
+		}
+		if q.ThreadID != "" && m.ID != q.ThreadID && m.ThreadID != q.ThreadID {
+			continue
+		}
+		if q.MentionID != "" && !slices.Contains(m.Mentioned(), q.MentionID) {
+			continue
+		}
+		out = append(out, copyMessage(*m))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt) != q.Desc
+		}
+		return (out[i].ID < out[j].ID) != q.Desc
+	})
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out, nil
+}
+
+// AppendEvent assigns evt the conversation's next sequence number and appends it to the log.
+func (s *MemoryStore) AppendEvent(ctx context.Context, evt *events.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[evt.ConversationID]
+	if !ok {
+		return ErrNotFound
+	}
+	evt.Seq = int64(len(c.events)) + 1
+	c.events = append(c.events, copyEvent(*evt))
+	c.LastEventSeq = evt.Seq
+	return nil
+}
+
+// AckEvents records that userID has seen the conversation's events up to seq.
+func (s *MemoryStore) AckEvents(ctx context.Context, conversationID, userID string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return ErrNotFound
+	}
+	if c.acks == nil {
+		c.acks = make(map[string]int64)
+	}
+	c.acks[userID] = max(c.acks[userID], seq)
+	return nil
+}
+
+// EventAck returns the latest seq userID acknowledged in the conversation, 0 when none.
+func (s *MemoryStore) EventAck(ctx context.Context, conversationID, userID string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return c.acks[userID], nil
+}
+
+// ListEvents returns up to limit logged events with seq > afterSeq in order;
+// limit <= 0 returns all of them.
+func (s *MemoryStore) ListEvents(ctx context.Context, conversationID string, afterSeq int64, limit int) ([]events.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[conversationID]
+	if !ok || afterSeq >= int64(len(c.events)) {
+		return nil, nil
+	}
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+	tail := c.events[afterSeq:] // seq n is stored at index n-1
+	if limit > 0 && len(tail) > limit {
+		tail = tail[:limit]
+	}
+	out := make([]events.Event, len(tail))
+	for i, e := range tail {
+		out[i] = copyEvent(e)
+	}
+	return out, nil
+}
+
+// AddParticipants adds agents to a conversation; agents already present are left unchanged.
+func (s *MemoryStore) AddParticipants(ctx context.Context, conversationID string, agentIDs ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return fmt.Errorf("conversation %s: %w", conversationID, ErrNotFound)
+	}
+	for _, agentID := range agentIDs {
+		if _, ok := s.liveAgent(agentID); !ok {
+			return fmt.Errorf("add participant %s: %w", agentID, ErrNotFound)
+		}
+		if !containsString(c.participants, agentID) {
+			c.participants = append(c.participants, agentID)
+		}
+	}
+	return nil
+}
+
+// RemoveParticipant removes an agent from a conversation.
+func (s *MemoryStore) RemoveParticipant(ctx context.Context, conversationID, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return ErrNotFound
+	}
+	for i, id := range c.participants {
+		if id == agentID {
+			c.participants = append(c.participants[:i], c.participants[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListParticipants returns the agents in a conversation in join order.
+func (s *MemoryStore) ListParticipants(ctx context.Context, conversationID string) ([]agent.Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[conversationID]
+	if !ok {
+		return nil, nil
+	}
+	var out []agent.Agent
+	for _, id := range c.participants {
+		if a, ok := s.agents[id]; ok {
+			out = append(out, copyAgent(a))
+		}
+	}
+	return out, nil
+}
+
+// SaveEmbedding stores the vector of a message.
+func (s *MemoryStore) SaveEmbedding(ctx context.Context, conversationID, messageID string, vec []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conversations[conversationID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.messages[messageID]; !ok {
+		return ErrNotFound
+	}
+	s.embeddings = append(s.embeddings, memEmbedding{
+		conversationID: conversationID,
+		messageID:      messageID,
+		vector:         append([]float32(nil), vec...),
+	})
+	return nil
+}
+
+// QuerySimilarMessages returns up to k message ids closest to vec by L2 distance.
+func (s *MemoryStore) QuerySimilarMessages(ctx context.Context, vec []float32, k int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type scored struct {
+		id   string
+		dist float64
+	}
+	all := make([]scored, 0, len(s.embeddings))
+	for _, e := range s.embeddings {
+		all = append(all, scored{id: e.messageID, dist: l2Distance(vec, e.vector)})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].dist < all[j].dist })
+	if k >= 0 && len(all) > k {
+		all = all[:k]
+	}
+	out := make([]string, 0, len(all))
+	for _, sc := range all {
+		out = append(out, sc.id)
+	}
+	return out, nil
+}
+
+// SearchMessages scores every embedded message matching opts against vec and returns the best opts.K.
+func (s *MemoryStore) SearchMessages(ctx context.Context, vec []float32, opts SearchOptions) ([]ScoredMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var hits []ScoredMessage
+	for _, e := range s.embeddings {
+		m, ok := s.messages[e.messageID]
+		if !ok || !matchesSearch(m, opts) {
+			continue
+		}
+		hits = append(hits, ScoredMessage{Message: copyMessage(*m), Score: cosineSimilarity(vec, e.vector)})
+	}
+	return topScored(hits, opts.limit()), nil
+}
+
+func matchesSearch(m *agent.Message, opts SearchOptions) bool {
+	switch {
+	case opts.ConversationID != "" && m.ConversationID != opts.ConversationID,
+		opts.SenderType != "" && m.SenderType != opts.SenderType,
+		opts.SenderID != "" && m.SenderID != opts.SenderID,
+		!opts.Since.IsZero() && m.CreatedAt.Before(opts.Since),
+		!opts.Until.IsZero() && m.CreatedAt.After(opts.Until):
+		return false
+	}
+	return true
+}
+
+// l2Distance returns the euclidean distance between a and b; vectors of
+// different lengths are infinitely far apart.
+func l2Distance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func copyAgent(a agent.Agent) agent.Agent {
+	a.BehaviorProfile = copyMap(a.BehaviorProfile)
+	return a
+}
+
+// copyMap deep-copies m through JSON, as a jsonb round trip would, so callers
+// cannot mutate stored maps and numbers come back as float64 like in Postgres.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	var out map[string]interface{}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// liveAgent reports whether id names an agent that has not been deleted.
+// Callers hold s.mu.
+func (s *MemoryStore) liveAgent(id string) (agent.Agent, bool) {
+	a, ok := s.agents[id]
+	if !ok {
+		return agent.Agent{}, false
+	}
+	if _, deleted := s.deletedAgents[id]; deleted {
+		return agent.Agent{}, false
+	}
+	return a, true
+}
+
+// GetAgent returns a single agent; deleted agents are not found.
+func (s *MemoryStore) GetAgent(ctx context.Context, id string) (*agent.Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.liveAgent(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := copyAgent(a)
+	return &cp, nil
+}
+
+// UpdateAgent applies patch to an agent and returns the result.
+func (s *MemoryStore) UpdateAgent(ctx context.Context, id string, patch AgentPatch) (*agent.Agent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.liveAgent(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	patch.apply(&a)
+	s.agents[id] = copyAgent(a)
+	cp := copyAgent(a)
+	return &cp, nil
+}
+
+// DeleteAgent soft-deletes an agent: it is no longer listed or found and
+// leaves its conversations, while its messages and memories are kept.
+func (s *MemoryStore) DeleteAgent(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.liveAgent(id); !ok {
+		return ErrNotFound
+	}
+	s.deletedAgents[id] = time.Now().UTC()
+	for i, aid := range s.agentOrder {
+		if aid == id {
+			s.agentOrder = append(s.agentOrder[:i], s.agentOrder[i+1:]...)
+			break
+		}
+	}
+	for _, c := range s.conversations {
+		for i, aid := range c.participants {
+			if aid == id {
+				c.participants = append(c.participants[:i], c.participants[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func copyEvent(e events.Event) events.Event {
+	if e.Sender != nil {
+		sender := *e.Sender
+		e.Sender = &sender
+	}
+	e.Payload = append([]byte(nil), e.Payload...)
+	return e
+}
+
+func copyMessage(m agent.Message) agent.Message {
+	m.Mentions = slices.Clone(m.Mentions)
+	m.Metadata = copyMap(m.Metadata)
+	return m
+}
+
+// SaveMemory stores m with its embedding and sets its ID and CreatedAt.
+func (s *MemoryStore) SaveMemory(ctx context.Context, m *agent.Memory, vec []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.agents[m.AgentID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.conversations[m.ConversationID]; m.ConversationID != "" && !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.messages[m.SourceMessageID]; m.SourceMessageID != "" && !ok {
+		return ErrNotFound
+	}
+	m.ID = newID()
+	m.CreatedAt = time.Now().UTC()
+	s.memories = append(s.memories, memMemory{Memory: *m, vector: append([]float32(nil), vec...)})
+	return nil
+}
+
+// ListMemories returns up to limit memories of an agent, newest first; limit <= 0 returns all.
+func (s *MemoryStore) ListMemories(ctx context.Context, agentID string, limit int) ([]agent.Memory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []agent.Memory
+	for i := len(s.memories) - 1; i >= 0; i-- {
+		if limit > 0 && len(out) == limit {
+			break
+		}
+		if s.memories[i].AgentID == agentID {
+			out = append(out, s.memories[i].Memory)
+		}
+	}
+	return out, nil
+}
+
+// SearchMemories scores every memory of an agent against vec and returns the best k.
+func (s *MemoryStore) SearchMemories(ctx context.Context, agentID string, vec []float32, k int) ([]ScoredMemory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var hits []ScoredMemory
+	for _, m := range s.memories {
+		if m.AgentID == agentID {
+			hits = append(hits, ScoredMemory{Memory: m.Memory, Score: cosineSimilarity(vec, m.vector)})
+		}
+	}
+	return topMemories(hits, k), nil
+}
+
+// CreateDebate stores d and sets its ID, CreatedAt and UpdatedAt.
+func (s *MemoryStore) CreateDebate(ctx context.Context, d *Debate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conversations[d.ConversationID]; !ok {
+		return ErrNotFound
+	}
+	d.ID = newID()
+	d.CreatedAt = time.Now().UTC()
+	d.UpdatedAt = d.CreatedAt
+	s.debates[d.ID] = copyDebate(d)
+	return nil
+}
+
+// UpdateDebate saves the progress of d (state, round, phase, speaker, error, end) and sets UpdatedAt.
+func (s *MemoryStore) UpdateDebate(ctx context.Context, d *Debate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.debates[d.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	d.UpdatedAt = time.Now().UTC()
+	cur.State, cur.Round, cur.Phase, cur.SpeakerID, cur.Error, cur.UpdatedAt = d.State, d.Round, d.Phase, d.SpeakerID, d.Error, d.UpdatedAt
+	cur.EndedAt = nil
+	if d.EndedAt != nil {
+		at := *d.EndedAt
+		cur.EndedAt = &at
+	}
+	return nil
+}
+
+// GetDebate returns a single debate.
+func (s *MemoryStore) GetDebate(ctx context.Context, id string) (*Debate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.debates[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyDebate(d), nil
+}
+
+// SaveDebateResult stores r, replacing the debate's previous result, and sets UpdatedAt.
+func (s *MemoryStore) SaveDebateResult(ctx context.Context, r *DebateResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.debates[r.DebateID]; !ok {
+		return ErrNotFound
+	}
+	r.UpdatedAt = time.Now().UTC()
+	s.debateResults[r.DebateID] = copyDebateResult(r)
+	return nil
+}
+
+// GetDebateResult returns the judge's result of a debate.
+func (s *MemoryStore) GetDebateResult(ctx context.Context, debateID string) (*DebateResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.debateResults[debateID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyDebateResult(r), nil
+}
+
+func copyDebateResult(r *DebateResult) *DebateResult {
+	cp := *r
+	cp.Rounds = make([]RoundScores, len(r.Rounds))
+	for i, rs := range r.Rounds {
+		rs.Scores = copyScores(rs.Scores)
+		cp.Rounds[i] = rs
+	}
+	cp.Totals = copyScores(r.Totals)
+	cp.SideTotals = copyScores(r.SideTotals)
+	if r.Audience != nil {
+		a := *r.Audience
+		a.Keys = append([]string(nil), a.Keys...)
+		a.Pre = append([]int(nil), a.Pre...)
+		a.Post = append([]int(nil), a.Post...)
+		a.Swing = append([]float64(nil), a.Swing...)
+		cp.Audience = &a
+	}
+	return &cp
+}
+
+func copyScores(m map[string]float64) map[string]float64 {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]float64, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
+
+func copyDebate(d *Debate) *Debate {
+	cp := *d
+	cp.Participants = append([]string(nil), d.Participants...)
+	if d.Sides != nil {
+		cp.Sides = make(map[string]string, len(d.Sides))
+		for k, v := range d.Sides {
+			cp.Sides[k] = v
+		}
+	}
+	if d.EndedAt != nil {
+		at := *d.EndedAt
+		cp.EndedAt = &at
+	}
+	return &cp
+}
+
+// CreateAutonomousRun stores r and sets its ID, CreatedAt and UpdatedAt.
+func (s *MemoryStore) CreateAutonomousRun(ctx context.Context, r *AutonomousRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conversations[r.ConversationID]; !ok {
+		return ErrNotFound
+	}
+	r.ID = newID()
+	r.CreatedAt = time.Now().UTC()
+	r.UpdatedAt = r.CreatedAt
+	s.autonomous[r.ID] = copyAutonomousRun(r)
+	return nil
+}
+
+// UpdateAutonomousRun saves the progress of r (state, turns, speaker, who left,
+// stop reason, error, end) and sets UpdatedAt.
+func (s *MemoryStore) UpdateAutonomousRun(ctx context.Context, r *AutonomousRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.autonomous[r.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	r.UpdatedAt = time.Now().UTC()
+	cp := copyAutonomousRun(r)
+	cur.State, cur.Turns, cur.SpeakerID, cur.Left = cp.State, cp.Turns, cp.SpeakerID, cp.Left
+	cur.StopReason, cur.Error, cur.EndedAt, cur.UpdatedAt = cp.StopReason, cp.Error, cp.EndedAt, cp.UpdatedAt
+	return nil
+}
+
+// GetAutonomousRun returns a single autonomous run.
+func (s *MemoryStore) GetAutonomousRun(ctx context.Context, id string) (*AutonomousRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.autonomous[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyAutonomousRun(r), nil
+}
+
+func copyAutonomousRun(r *AutonomousRun) *AutonomousRun {
+	cp := *r
+	cp.Participants = slices.Clone(r.Participants)
+	cp.StopOn = slices.Clone(r.StopOn)
+	cp.Left = slices.Clone(r.Left)
+	if r.EndedAt != nil {
+		at := *r.EndedAt
+		cp.EndedAt = &at
+	}
+	return &cp
+}
+
+// CreatePoll stores p and sets its ID, CreatedAt and zero Counts.
+func (s *MemoryStore) CreatePoll(ctx context.Context, p *Poll) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conversations[p.ConversationID]; !ok {
+		return ErrNotFound
+	}
+	if p.DebateID != "" {
+		if _, ok := s.debates[p.DebateID]; !ok {
+			return ErrNotFound
+		}
+	}
+	p.ID = newID()
+	p.CreatedAt = time.Now().UTC()
+	p.Counts = make([]int, len(p.Options))
+	s.polls[p.ID] = &memPoll{Poll: *copyPoll(p), votes: make(map[string]int)}
+	return nil
+}
+
+// GetPoll returns a poll with its vote counts.
+func (s *MemoryStore) GetPoll(ctx context.Context, id string) (*Poll, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.polls[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return p.counted(), nil
+}
+
+// ListPolls returns a conversation's polls with their vote counts, newest first.
+func (s *MemoryStore) ListPolls(ctx context.Context, conversationID string) ([]Poll, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.conversations[conversationID]; !ok {
+		return nil, ErrNotFound
+	}
+	out := []Poll{}
+	for _, p := range s.polls {
+		if p.ConversationID == conversationID {
+			out = append(out, *p.counted())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out, nil
+}
+
+// CastVote records voterID's choice of option in an open poll, replacing the
+// voter's earlier vote. It returns ErrPollClosed once the poll is closed or
+// past its deadline. The option index is not checked.
+func (s *MemoryStore) CastVote(ctx context.Context, pollID, voterID string, option int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.Closed(time.Now()) {
+		return ErrPollClosed
+	}
+	p.votes[voterID] = option
+	return nil
+}
+
+// ClosePoll closes a poll and returns it. Polls closed before return
+// ErrPollClosed; a poll past its deadline closes at the deadline.
+func (s *MemoryStore) ClosePoll(ctx context.Context, id string) (*Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.polls[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if p.ClosedAt != nil {
+		return nil, ErrPollClosed
+	}
+	at := time.Now().UTC()
+	if p.Deadline != nil && p.Deadline.Before(at) {
+		at = *p.Deadline
+	}
+	p.ClosedAt = &at
+	return p.counted(), nil
+}
+
+// counted returns a copy of the poll with its vote counts.
+func (p *memPoll) counted() *Poll {
+	cp := copyPoll(&p.Poll)
+	cp.Counts = make([]int, len(p.Options))
+	for _, o := range p.votes {
+		if o >= 0 && o < len(cp.Counts) {
+			cp.Counts[o]++
+		}
+	}
+	return cp
+}
+
+func copyPoll(p *Poll) *Poll {
+	cp := *p
+	cp.Options = append([]string(nil), p.Options...)
+	cp.Counts = append([]int(nil), p.Counts...)
+	if p.Deadline != nil {
+		at := *p.Deadline
+		cp.Deadline = &at
+	}
+	if p.ClosedAt != nil {
+		at := *p.ClosedAt
+		cp.ClosedAt = &at
+	}
+	return &cp
+}
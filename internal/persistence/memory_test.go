@@ -0,0 +1,14 @@
+package persistence_test
+
+import (
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/persistence/storetest"
+)
+
+func TestMemoryStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) persistence.Store {
+		return persistence.NewMemoryStore()
+	})
+}
@@ -0,0 +1,99 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+)
+
+// MessageQuery selects a page of a conversation's messages. Messages are ordered
+// by (created_at, id); a cursor is a message id or a timestamp, and the page
+// holds the messages strictly before or after it. Zero fields do not filter.
+type MessageQuery struct {
+	BeforeID, AfterID string    // cursor messages; unknown ids give ErrNotFound
+	Before, After     time.Time // cursor timestamps
+	SenderID          string
+	ThreadID          string // the thread's first message and its replies
+	MentionID         string // messages mentioning this agent
+	Limit             int    // <= 0 returns every match
+	Desc              bool   // newest first
+}
+
+// messageCursor is a position in (created_at, id) order; with an empty id it
+// excludes every message created at exactly that time, for Before and After alike.
+type messageCursor struct {
+	at time.Time
+	id string
+}
+
+// before reports whether m sorts strictly before c.
+func (c *messageCursor) before(m *agent.Message) bool {
+	if !m.CreatedAt.Equal(c.at) {
+		return m.CreatedAt.Before(c.at)
+	}
+	return c.id != "" && m.ID < c.id
+}
+
+// after reports whether m sorts strictly after c.
+func (c *messageCursor) after(m *agent.Message) bool {
+	if !m.CreatedAt.Equal(c.at) {
+		return m.CreatedAt.After(c.at)
+	}
+	return c.id != "" && m.ID > c.id
+}
+
+// ListMessages returns the page of a conversation's messages selected by q.
+func (s *PostgresStore) ListMessages(ctx context.Context, conversationID string, q MessageQuery) ([]agent.Message, error) {
+	cursor := func(id string, at time.Time) (*time.Time, *string, error) {
+		if id != "" {
+			var ts time.Time
+			err := s.pool.QueryRow(ctx, "SELECT created_at FROM messages WHERE id=$1 AND conversation_id=$2", id, conversationID).Scan(&ts)
+			if err != nil {
+				return nil, nil, notFound(err)
+			}
+			return &ts, &id, nil
+		}
+		if !at.IsZero() {
+			return &at, nil, nil
+		}
+		return nil, nil, nil
+	}
+	beforeAt, beforeID, err := cursor(q.BeforeID, q.Before)
+	if err != nil {
+		return nil, err
+	}
+	afterAt, afterID, err := cursor(q.AfterID, q.After)
+	if err != nil {
+		return nil, err
+	}
+	var senderID *string
+	if q.SenderID != "" {
+		senderID = &q.SenderID
+	}
+	var threadID, mentionID *string
+	if q.ThreadID != "" {
+		threadID = &q.ThreadID
+	}
+	if q.MentionID != "" {
+		mentionID = &q.MentionID
+	}
+	order := "ASC"
+	if q.Desc {
+		order = "DESC"
+	}
+	rows, err := s.pool.Query(ctx, "SELECT "+messageColumns+` FROM messages m
+		WHERE m.conversation_id = $1
+		  AND ($2::timestamptz IS NULL OR m.created_at < $2 OR (m.created_at = $2 AND m.id < $3::uuid))
+		  AND ($4::timestamptz IS NULL OR m.created_at > $4 OR (m.created_at = $4 AND m.id > $5::uuid))
+		  AND ($6::text IS NULL OR m.sender_id = $6)
+		  AND ($8::uuid IS NULL OR m.id = $8 OR m.thread_id = $8)
+		  AND ($9::text IS NULL OR m.mentions @> jsonb_build_array(jsonb_build_object('agent_id', $9::text)))
+		ORDER BY m.created_at `+order+`, m.id `+order+`
+		LIMIT NULLIF($7::int, 0)`,
+		conversationID, beforeAt, beforeID, afterAt, afterID, senderID, max(q.Limit, 0), threadID, mentionID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return scanMessages(rows)
+}
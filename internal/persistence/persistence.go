@@ -3,22 +3,68 @@ package persistence
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	pgxvec "github.com/pgvector/pgvector-go/pgx"
 
 	"github.com/yourname/multiagent-social/internal/agent"
 )
 
+// Postgres SQLSTATEs that mean a referenced row does not exist.
+const (
+	foreignKeyViolation       = "23503"
+	invalidTextRepresentation = "22P02" // malformed uuid: no such row can exist
+)
+
+// notFound maps missing-row errors onto ErrNotFound and passes others through.
+func notFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == foreignKeyViolation || pgErr.Code == invalidTextRepresentation) {
+		return fmt.Errorf("%s: %w", pgErr.Message, ErrNotFound)
+	}
+	return err
+}
+
 // PostgresStore is a thin wrapper around pgxpool for this project.
+// It implements Store.
 type PostgresStore struct {
 	pool *pgxpool.Pool
+
+	// vec configures similarity search; ann is set when the pgvector column
+	// is usable, otherwise searches fall back to brute force. memANN is the same
+	// for agent_memories, indexed is set when embeddings has an HNSW index for
+	// the metric, and iterative is set when pgvector has iterative scans.
+	vec       VectorConfig
+	ann       bool
+	indexed   bool
+	memANN    bool
+	iterative bool
 }
 
-// NewPostgresStore creates and verifies the DB connection.
+// NewPostgresStore creates and verifies the DB connection. Similarity search
+// uses pgvector when migrations created the vector column; see ConfigureVectors.
 func NewPostgresStore(ctx context.Context, dsn string) (*PostgresStore, error) {
-	pool, err := pgxpool.New(ctx, dsn)
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		// fails when pgvector is not installed; the brute-force path needs no types
+		_ = pgxvec.RegisterTypes(ctx, conn)
+		return nil
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +75,9 @@ func NewPostgresStore(ctx context.Context, dsn string) (*PostgresStore, error) {
 		pool.Close()
 		return nil, fmt.Errorf("ping: %w", err)
 	}
-	return &PostgresStore{pool: pool}, nil
+	s := &PostgresStore{pool: pool}
+	s.detectVectors(ctx)
+	return s, nil
 }
 
 func (s *PostgresStore) Close() {
@@ -43,12 +91,17 @@ func (s *PostgresStore) Pool() *pgxpool.Pool {
 	return s.pool
 }
 
-// ListAgents returns all agents for MVP (lightweight).
+// ListAgents returns all live agents for MVP (lightweight).
 func (s *PostgresStore) ListAgents(ctx context.Context) ([]agent.Agent, error) {
-	rows, err := s.pool.Query(ctx, "SELECT id, name, persona, behavior_profile, created_at FROM agents")
+	rows, err := s.pool.Query(ctx, "SELECT id, name, persona, behavior_profile, created_at FROM agents WHERE deleted_at IS NULL ORDER BY created_at ASC")
 	if err != nil {
 		return nil, err
 	}
+	return scanAgents(rows)
+}
+
+// scanAgents reads id, name, persona, behavior_profile, created_at rows.
+func scanAgents(rows pgx.Rows) ([]agent.Agent, error) {
 	defer rows.Close()
 	var out []agent.Agent
 	for rows.Next() {
@@ -60,7 +113,6 @@ func (s *PostgresStore) ListAgents(ctx context.Context) ([]agent.Agent, error) {
 		if err := rows.Scan(&id, &name, &personaBytes, &behaviorBytes, &createdAt); err != nil {
 			return nil, err
 		}
-		persona := string(personaBytes)
 		var behaviorProfile map[string]interface{}
 		if len(behaviorBytes) > 0 {
 			_ = json.Unmarshal(behaviorBytes, &behaviorProfile)
@@ -68,12 +120,22 @@ func (s *PostgresStore) ListAgents(ctx context.Context) ([]agent.Agent, error) {
 		out = append(out, agent.Agent{
 			ID:              agent.AgentID(id),
 			Name:            name,
-			Persona:         persona,
+			Persona:         decodePersona(personaBytes),
 			BehaviorProfile: behaviorProfile,
 			CreatedAt:       createdAt,
 		})
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+// decodePersona reads the jsonb persona column: a JSON string is unquoted,
+// any other JSON document is returned as its text.
+func decodePersona(raw []byte) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
 }
 
 // CreateAgent inserts a new agent row and returns its id.
@@ -87,10 +149,88 @@ func (s *PostgresStore) CreateAgent(ctx context.Context, name string, persona st
 			return "", err
 		}
 	}
-	err := s.pool.QueryRow(ctx, "INSERT INTO agents (name, persona, behavior_profile) VALUES ($1, $2, $3) RETURNING id", name, persona, behaviorBytes).Scan(&id)
+	// persona is a jsonb column; store the text as a JSON string
+	personaBytes, err := json.Marshal(persona)
+	if err != nil {
+		return "", err
+	}
+	err = s.pool.QueryRow(ctx, "INSERT INTO agents (name, persona, behavior_profile) VALUES ($1, $2, $3) RETURNING id", name, personaBytes, behaviorBytes).Scan(&id)
 	return id, err
 }
 
+// GetAgent returns a single agent; deleted agents are not found.
+func (s *PostgresStore) GetAgent(ctx context.Context, id string) (*agent.Agent, error) {
+	rows, err := s.pool.Query(ctx, "SELECT id, name, persona, behavior_profile, created_at FROM agents WHERE id=$1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	agents, err := scanAgents(rows)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if len(agents) == 0 {
+		return nil, ErrNotFound
+	}
+	return &agents[0], nil
+}
+
+// UpdateAgent applies patch to an agent and returns the result. The row is
+// locked while the behavior profile is merged, so concurrent patches compose.
+func (s *PostgresStore) UpdateAgent(ctx context.Context, id string, patch AgentPatch) (*agent.Agent, error) {
+	var out *agent.Agent
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "SELECT id, name, persona, behavior_profile, created_at FROM agents WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", id)
+		if err != nil {
+			return err
+		}
+		agents, err := scanAgents(rows)
+		if err != nil {
+			return err
+		}
+		if len(agents) == 0 {
+			return ErrNotFound
+		}
+		a := agents[0]
+		patch.apply(&a)
+		personaBytes, err := json.Marshal(a.Persona)
+		if err != nil {
+			return err
+		}
+		var behaviorBytes []byte
+		if a.BehaviorProfile != nil {
+			if behaviorBytes, err = json.Marshal(a.BehaviorProfile); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, "UPDATE agents SET name=$2, persona=$3, behavior_profile=$4 WHERE id=$1", id, a.Name, personaBytes, behaviorBytes); err != nil {
+			return err
+		}
+		out = &a
+		return nil
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return out, nil
+}
+
+// DeleteAgent soft-deletes an agent and removes it from its conversations.
+// The row stays so messages and memories keep their attribution.
+func (s *PostgresStore) DeleteAgent(ctx context.Context, id string) error {
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE agents SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM conversation_participants WHERE agent_id=$1", id)
+		return err
+	})
+	return notFound(err)
+}
+
 // CreateConversation creates an empty conversation row and returns id.
 func (s *PostgresStore) CreateConversation(ctx context.Context, title string) (string, error) {
 	var id string
@@ -98,56 +238,272 @@ func (s *PostgresStore) CreateConversation(ctx context.Context, title string) (s
 	return id, err
 }
 
-// InsertMessage persists a message to messages table.
-func (s *PostgresStore) InsertMessage(ctx context.Context, conversationID, senderType, senderID, content string) (string, error) {
+// CreateConversationWith creates a conversation with its participants and metadata
+// in one transaction, so a missing agent leaves no conversation behind.
+func (s *PostgresStore) CreateConversationWith(ctx context.Context, title string, agentIDs []string, md map[string]interface{}) (string, error) {
+	var raw []byte
+	if md != nil {
+		var err error
+		if raw, err = json.Marshal(md); err != nil {
+			return "", err
+		}
+	}
 	var id string
-	err := s.pool.QueryRow(ctx, "INSERT INTO messages (conversation_id, sender_type, sender_id, content) VALUES ($1, $2, $3, $4) RETURNING id", conversationID, senderType, senderID, content).Scan(&id)
-	return id, err
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, "INSERT INTO conversations (title, metadata) VALUES ($1, $2) RETURNING id", title, raw).Scan(&id); err != nil {
+			return err
+		}
+		for _, agentID := range agentIDs {
+			var live bool
+			err := tx.QueryRow(ctx, addParticipantSQL, id, agentID).Scan(&live)
+			if err == nil && !live {
+				err = ErrNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("add participant %s: %w", agentID, notFound(err))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
 }
 
-// GetConversationMessages returns messages content for a conversation.
-func (s *PostgresStore) GetConversationMessages(ctx context.Context, conversationID string) ([]string, error) {
-	rows, err := s.pool.Query(ctx, "SELECT content FROM messages WHERE conversation_id=$1 ORDER BY created_at ASC", conversationID)
+// InsertMessage persists a message to messages table and sets its ID, CreatedAt
+// and, for replies, ThreadID. A reply to a message of another conversation
+// returns ErrNotFound.
+func (s *PostgresStore) InsertMessage(ctx context.Context, m *agent.Message) error {
+	var replyTo, threadID *string
+	if m.ReplyTo != "" {
+		var thread string
+		err := s.pool.QueryRow(ctx, "SELECT COALESCE(thread_id, id)::text FROM messages WHERE id=$1 AND conversation_id=$2",
+			m.ReplyTo, m.ConversationID).Scan(&thread)
+		if err != nil {
+			return notFound(err)
+		}
+		replyTo, threadID = &m.ReplyTo, &thread
+	}
+	mentions, err := json.Marshal(m.Mentions)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if m.Mentions == nil {
+		mentions = []byte("[]")
+	}
+	var md []byte
+	if m.Metadata != nil {
+		var err error
+		if md, err = json.Marshal(m.Metadata); err != nil {
+			return err
+		}
+	}
+	err = s.pool.QueryRow(ctx, `INSERT INTO messages (conversation_id, sender_type, sender_id, sender_name, content, reply_to, thread_id, mentions, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`,
+		m.ConversationID, m.SenderType, m.SenderID, m.SenderName, m.Content, replyTo, threadID, mentions, md).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return notFound(err)
+	}
+	if threadID != nil {
+		m.ThreadID = *threadID
 	}
+	return nil
+}
+
+// messageColumns are the columns scanMessages expects, in order, from messages aliased m.
+const messageColumns = `m.id, m.conversation_id, COALESCE(m.sender_type, ''), COALESCE(m.sender_id, ''), COALESCE(m.sender_name, ''),
+	COALESCE(m.content, ''), m.created_at, COALESCE(m.reply_to::text, ''), COALESCE(m.thread_id::text, ''), m.mentions, m.metadata`
+
+// scanMessages reads rows selected with messageColumns.
+func scanMessages(rows pgx.Rows) ([]agent.Message, error) {
 	defer rows.Close()
-	var out []string
+	var out []agent.Message
 	for rows.Next() {
-		var content string
-		if err := rows.Scan(&content); err != nil {
+		var m agent.Message
+		if err := scanMessage(rows, &m); err != nil {
 			return nil, err
 		}
-		out = append(out, content)
+		out = append(out, m)
 	}
-	return out, nil
+	return out, rows.Err()
 }
 
-// ListConversations returns id and title for recent conversations.
-func (s *PostgresStore) ListConversations(ctx context.Context) ([]struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-}, error) {
-	rows, err := s.pool.Query(ctx, "SELECT id, title FROM conversations ORDER BY created_at DESC LIMIT 100")
+// scanMessage scans one row selected with messageColumns followed by extra columns.
+func scanMessage(row pgx.Row, m *agent.Message, extra ...interface{}) error {
+	var md, mentions []byte
+	dest := append([]interface{}{&m.ID, &m.ConversationID, &m.SenderType, &m.SenderID, &m.SenderName, &m.Content, &m.CreatedAt,
+		&m.ReplyTo, &m.ThreadID, &mentions, &md}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	// messages without mentions store [], read back as nil
+	if len(mentions) > 0 {
+		if err := json.Unmarshal(mentions, &m.Mentions); err != nil {
+			return err
+		}
+		if len(m.Mentions) == 0 {
+			m.Mentions = nil
+		}
+	}
+	if len(md) > 0 {
+		return json.Unmarshal(md, &m.Metadata)
+	}
+	return nil
+}
+
+// GetConversationMessages returns the messages of a conversation, oldest first.
+func (s *PostgresStore) GetConversationMessages(ctx context.Context, conversationID string) ([]agent.Message, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+messageColumns+" FROM messages m WHERE m.conversation_id=$1 ORDER BY m.created_at ASC, m.id ASC", conversationID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return scanMessages(rows)
+}
+
+// conversationColumns are the columns scanConversation reads, in order.
+const conversationColumns = "id, COALESCE(title, ''), status, created_at, last_event_seq"
+
+func scanConversation(row pgx.Row) (Conversation, error) {
+	var c Conversation
+	err := row.Scan(&c.ID, &c.Title, &c.Status, &c.CreatedAt, &c.LastEventSeq)
+	return c, err
+}
+
+// ListConversations returns id, title and status for recent conversations.
+func (s *PostgresStore) ListConversations(ctx context.Context) ([]Conversation, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+conversationColumns+" FROM conversations ORDER BY created_at DESC LIMIT 100")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var out []struct {
-		ID    string `json:"id"`
-		Title string `json:"title"`
-	}
+	var out []Conversation
 	for rows.Next() {
-		var id string
-		var title string
-		if err := rows.Scan(&id, &title); err != nil {
+		c, err := scanConversation(rows)
+		if err != nil {
 			return nil, err
 		}
-		out = append(out, struct {
-			ID    string `json:"id"`
-			Title string `json:"title"`
-		}{ID: id, Title: title})
+		out = append(out, c)
 	}
-	return out, nil
+	return out, rows.Err()
+}
+
+// GetConversation returns a single conversation.
+func (s *PostgresStore) GetConversation(ctx context.Context, id string) (*Conversation, error) {
+	c, err := scanConversation(s.pool.QueryRow(ctx, "SELECT "+conversationColumns+" FROM conversations WHERE id=$1", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &c, nil
+}
+
+// UpdateConversation applies patch to a conversation and returns the result.
+func (s *PostgresStore) UpdateConversation(ctx context.Context, id string, patch ConversationPatch) (*Conversation, error) {
+	c, err := scanConversation(s.pool.QueryRow(ctx, `UPDATE conversations SET title=COALESCE($2, title), status=COALESCE($3, status)
+		WHERE id=$1 RETURNING `+conversationColumns, id, patch.Title, patch.Status))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &c, nil
+}
+
+// DeleteConversation removes a conversation and everything stored under it.
+// Memories formed in it lose their conversation and source message links.
+func (s *PostgresStore) DeleteConversation(ctx context.Context, id string) error {
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// messages and embeddings reference the conversation without ON DELETE CASCADE
+		for _, q := range []string{
+			"DELETE FROM embeddings WHERE conversation_id=$1 OR message_id IN (SELECT id FROM messages WHERE conversation_id=$1)",
+			"DELETE FROM messages WHERE conversation_id=$1",
+		} {
+			if _, err := tx.Exec(ctx, q, id); err != nil {
+				return err
+			}
+		}
+		tag, err := tx.Exec(ctx, "DELETE FROM conversations WHERE id=$1", id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	return notFound(err)
 }
 
+// addParticipantSQL adds agent $2 to conversation $1 and selects whether the agent
+// is live: missing or deleted agents cannot join.
+const addParticipantSQL = `WITH a AS (SELECT id FROM agents WHERE id=$2 AND deleted_at IS NULL),
+	ins AS (INSERT INTO conversation_participants (conversation_id, agent_id) SELECT $1, id FROM a ON CONFLICT DO NOTHING)
+	SELECT EXISTS (SELECT 1 FROM a)`
+
+// AddParticipants adds agents to a conversation; agents already present are left unchanged.
+func (s *PostgresStore) AddParticipants(ctx context.Context, conversationID string, agentIDs ...string) error {
+	for _, agentID := range agentIDs {
+		var live bool
+		err := s.pool.QueryRow(ctx, addParticipantSQL, conversationID, agentID).Scan(&live)
+		if err == nil && !live {
+			err = ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("add participant %s: %w", agentID, notFound(err))
+		}
+	}
+	return nil
+}
+
+// RemoveParticipant removes an agent from a conversation.
+func (s *PostgresStore) RemoveParticipant(ctx context.Context, conversationID, agentID string) error {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM conversation_participants WHERE conversation_id=$1 AND agent_id=$2", conversationID, agentID)
+	if err != nil {
+		return notFound(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListParticipants returns the agents in a conversation in join order.
+func (s *PostgresStore) ListParticipants(ctx context.Context, conversationID string) ([]agent.Agent, error) {
+	rows, err := s.pool.Query(ctx, `SELECT a.id, a.name, a.persona, a.behavior_profile, a.created_at
+		FROM conversation_participants p JOIN agents a ON a.id = p.agent_id
+		WHERE p.conversation_id=$1 AND a.deleted_at IS NULL ORDER BY p.joined_at ASC, a.name ASC`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAgents(rows)
+}
+
+// GetConversationMetadata returns the metadata object of a conversation (nil when unset).
+func (s *PostgresStore) GetConversationMetadata(ctx context.Context, conversationID string) (map[string]interface{}, error) {
+	var raw []byte
+	err := s.pool.QueryRow(ctx, "SELECT metadata FROM conversations WHERE id=$1", conversationID).Scan(&raw)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	var md map[string]interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &md); err != nil {
+			return nil, err
+		}
+	}
+	return md, nil
+}
+
+// SetConversationMetadata replaces the metadata object of a conversation.
+func (s *PostgresStore) SetConversationMetadata(ctx context.Context, conversationID string, md map[string]interface{}) error {
+	raw, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	tag, err := s.pool.Exec(ctx, "UPDATE conversations SET metadata=$2 WHERE id=$1", conversationID, raw)
+	if err != nil {
+		return notFound(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
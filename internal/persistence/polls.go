@@ -0,0 +1,141 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrPollClosed is returned when voting in, or closing, a poll that has closed.
+var ErrPollClosed = errors.New("poll is closed")
+
+// Debate stages of polls held by debates.
+const (
+	PollBeforeDebate = "pre"
+	PollAfterDebate  = "post"
+)
+
+// Poll is a question put to a conversation with a fixed list of options.
+type Poll struct {
+	ID             string     `json:"id"`
+	ConversationID string     `json:"conversation_id"`
+	Question       string     `json:"question"`
+	Options        []string   `json:"options"`
+	CreatedBy      string     `json:"created_by"`
+	DebateID       string     `json:"debate_id,omitempty"` // set for a debate's audience votes
+	Stage          string     `json:"stage,omitempty"`     // PollBeforeDebate or PollAfterDebate
+	Deadline       *time.Time `json:"deadline,omitempty"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	// Counts holds the votes per option, in option order.
+	Counts []int `json:"counts"`
+}
+
+// Closed reports whether p no longer takes votes at now: it was closed or its deadline passed.
+func (p *Poll) Closed(now time.Time) bool {
+	return p.ClosedAt != nil || (p.Deadline != nil && !now.Before(*p.Deadline))
+}
+
+// Total returns the number of votes cast.
+func (p *Poll) Total() int {
+	n := 0
+	for _, c := range p.Counts {
+		n += c
+	}
+	return n
+}
+
+// pollColumns are the columns scanPoll reads, in order; the last one counts
+// votes per option and needs polls aliased as p.
+const pollColumns = `p.id, p.conversation_id, p.question, p.options, p.created_by, COALESCE(p.debate_id::text, ''), COALESCE(p.stage, ''),
+	p.deadline, p.closed_at, p.created_at,
+	ARRAY(SELECT count(v.voter_id)::int FROM generate_subscripts(p.options, 1) AS o(i)
+		LEFT JOIN poll_votes v ON v.poll_id = p.id AND v.option = o.i - 1 GROUP BY o.i ORDER BY o.i)`
+
+func scanPoll(row pgx.Row) (*Poll, error) {
+	var p Poll
+	err := row.Scan(&p.ID, &p.ConversationID, &p.Question, &p.Options, &p.CreatedBy, &p.DebateID, &p.Stage,
+		&p.Deadline, &p.ClosedAt, &p.CreatedAt, &p.Counts)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePoll stores p and sets its ID, CreatedAt and zero Counts.
+func (s *PostgresStore) CreatePoll(ctx context.Context, p *Poll) error {
+	err := s.pool.QueryRow(ctx, `INSERT INTO polls (conversation_id, question, options, created_by, debate_id, stage, deadline)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		p.ConversationID, p.Question, p.Options, p.CreatedBy, nullable(p.DebateID), nullable(p.Stage), p.Deadline).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return notFound(err)
+	}
+	p.Counts = make([]int, len(p.Options))
+	return nil
+}
+
+// GetPoll returns a poll with its vote counts.
+func (s *PostgresStore) GetPoll(ctx context.Context, id string) (*Poll, error) {
+	p, err := scanPoll(s.pool.QueryRow(ctx, "SELECT "+pollColumns+" FROM polls p WHERE p.id=$1", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return p, nil
+}
+
+// ListPolls returns a conversation's polls with their vote counts, newest first.
+func (s *PostgresStore) ListPolls(ctx context.Context, conversationID string) ([]Poll, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+pollColumns+" FROM polls p WHERE p.conversation_id=$1 ORDER BY p.created_at DESC, p.id DESC", conversationID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer rows.Close()
+	out := []Poll{}
+	for rows.Next() {
+		p, err := scanPoll(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+// CastVote records voterID's choice of option in an open poll, replacing the
+// voter's earlier vote. It returns ErrPollClosed once the poll is closed or
+// past its deadline. The option index is not checked.
+func (s *PostgresStore) CastVote(ctx context.Context, pollID, voterID string, option int) error {
+	tag, err := s.pool.Exec(ctx, `INSERT INTO poll_votes (poll_id, voter_id, option)
+		SELECT id, $2, $3 FROM polls WHERE id=$1 AND closed_at IS NULL AND (deadline IS NULL OR deadline > now())
+		ON CONFLICT (poll_id, voter_id) DO UPDATE SET option=EXCLUDED.option, updated_at=now()`,
+		pollID, voterID, option)
+	if err != nil {
+		return notFound(err)
+	}
+	if tag.RowsAffected() == 0 {
+		if _, err := s.GetPoll(ctx, pollID); err != nil {
+			return err
+		}
+		return ErrPollClosed
+	}
+	return nil
+}
+
+// ClosePoll closes a poll and returns it. Polls closed before return
+// ErrPollClosed; a poll past its deadline closes at the deadline.
+func (s *PostgresStore) ClosePoll(ctx context.Context, id string) (*Poll, error) {
+	tag, err := s.pool.Exec(ctx, `UPDATE polls SET closed_at=LEAST(now(), COALESCE(deadline, now())) WHERE id=$1 AND closed_at IS NULL`, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	p, err := s.GetPoll(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrPollClosed
+	}
+	return p, nil
+}
@@ -0,0 +1,26 @@
+package persistence_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/persistence/storetest"
+)
+
+// This integration test requires a running, migrated Postgres and PG_DSN env set.
+func TestPostgresStoreConformance(t *testing.T) {
+	dsn := os.Getenv("PG_DSN")
+	if dsn == "" {
+		t.Skip("PG_DSN not set; skipping integration test")
+	}
+	storetest.Run(t, func(t *testing.T) persistence.Store {
+		store, err := persistence.NewPostgresStore(context.Background(), dsn)
+		if err != nil {
+			t.Skipf("db not available: %v", err)
+		}
+		t.Cleanup(store.Close)
+		return store
+	})
+}
@@ -0,0 +1,79 @@
+package persistence
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+)
+
+// SearchOptions filters SearchMessages; zero fields do not filter.
+type SearchOptions struct {
+	ConversationID string
+	SenderType     string
+	SenderID       string
+	Since, Until   time.Time // created_at range, inclusive
+	K              int       // maximum results; <= 0 means 10
+}
+
+// filtered reports whether o narrows the candidates beyond the vector itself.
+func (o SearchOptions) filtered() bool {
+	return o.ConversationID != "" || o.SenderType != "" || o.SenderID != "" || !o.Since.IsZero() || !o.Until.IsZero()
+}
+
+func (o SearchOptions) limit() int {
+	if o.K <= 0 {
+		return 10
+	}
+	return o.K
+}
+
+// ScoredMessage is a search hit: the message and its cosine similarity to the query (1 = identical).
+type ScoredMessage struct {
+	agent.Message
+	Score float64 `json:"score"`
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b; vectors of
+// different lengths or zero vectors score -1 so they rank last.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return -1
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return -1
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// topScored sorts hits by descending score, keeps the best hit per message and
+// truncates to k.
+func topScored(hits []ScoredMessage, k int) []ScoredMessage {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return dedupeScored(hits, k)
+}
+
+// dedupeScored keeps the first hit per message of hits, which are best first,
+// and truncates to k.
+func dedupeScored(hits []ScoredMessage, k int) []ScoredMessage {
+	seen := make(map[string]bool, len(hits))
+	out := make([]ScoredMessage, 0, k)
+	for _, h := range hits {
+		if len(out) == k {
+			break
+		}
+		if seen[h.ID] {
+			continue
+		}
+		seen[h.ID] = true
+		out = append(out, h)
+	}
+	return out
+}
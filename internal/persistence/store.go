@@ -0,0 +1,174 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/events"
+)
+
+// ErrNotFound is returned when a referenced row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Conversation statuses. Active conversations take messages and agent turns,
+// paused ones take messages only, and closed and archived ones take neither.
+const (
+	ConversationActive   = "active"
+	ConversationPaused   = "paused"
+	ConversationClosed   = "closed"
+	ConversationArchived = "archived"
+)
+
+// Conversation is a conversation row.
+type Conversation struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	// LastEventSeq is the seq of the latest logged event (0 when none).
+	LastEventSeq int64 `json:"last_event_seq"`
+}
+
+// ConversationPatch lists conversation fields to change; nil fields are left as they are.
+type ConversationPatch struct {
+	Title  *string
+	Status *string
+}
+
+// apply changes c according to p.
+func (p ConversationPatch) apply(c *Conversation) {
+	if p.Title != nil {
+		c.Title = *p.Title
+	}
+	if p.Status != nil {
+		c.Status = *p.Status
+	}
+}
+
+// AgentPatch lists agent fields to change; nil fields are left as they are.
+// BehaviorProfile is merged key by key into the current profile, and a key
+// set to nil is removed.
+type AgentPatch struct {
+	Name            *string
+	Persona         *string
+	BehaviorProfile map[string]interface{}
+}
+
+// apply changes a according to p.
+func (p AgentPatch) apply(a *agent.Agent) {
+	if p.Name != nil {
+		a.Name = *p.Name
+	}
+	if p.Persona != nil {
+		a.Persona = *p.Persona
+	}
+	if p.BehaviorProfile == nil {
+		return
+	}
+	profile := copyMap(a.BehaviorProfile)
+	if profile == nil {
+		profile = make(map[string]interface{})
+	}
+	for k, v := range p.BehaviorProfile {
+		if v == nil {
+			delete(profile, k)
+			continue
+		}
+		profile[k] = v
+	}
+	a.BehaviorProfile = profile
+}
+
+// Store is the storage used by the orchestrator, the ws handler and embeddings.
+// PostgresStore is the production implementation; MemoryStore backs tests and the devserver.
+type Store interface {
+	// agents
+	ListAgents(ctx context.Context) ([]agent.Agent, error)
+	CreateAgent(ctx context.Context, name string, persona string, behaviorProfile map[string]interface{}) (string, error)
+	GetAgent(ctx context.Context, id string) (*agent.Agent, error)
+	// UpdateAgent applies patch to an agent and returns the result.
+	UpdateAgent(ctx context.Context, id string, patch AgentPatch) (*agent.Agent, error)
+	// DeleteAgent soft-deletes an agent: it disappears from listings, lookups and
+	// conversations, while messages keep their attribution and memories are kept.
+	DeleteAgent(ctx context.Context, id string) error
+
+	// conversations
+	CreateConversation(ctx context.Context, title string) (string, error)
+	// CreateConversationWith creates a conversation with its participants and
+	// metadata at once; an unknown or deleted agent creates nothing and returns ErrNotFound.
+	CreateConversationWith(ctx context.Context, title string, agentIDs []string, md map[string]interface{}) (string, error)
+	ListConversations(ctx context.Context) ([]Conversation, error)
+	GetConversation(ctx context.Context, id string) (*Conversation, error)
+	// UpdateConversation applies patch to a conversation and returns the result.
+	UpdateConversation(ctx context.Context, id string, patch ConversationPatch) (*Conversation, error)
+	// DeleteConversation removes a conversation with its messages, embeddings,
+	// participants and event log; memories formed in it are kept.
+	DeleteConversation(ctx context.Context, id string) error
+	GetConversationMetadata(ctx context.Context, conversationID string) (map[string]interface{}, error)
+	SetConversationMetadata(ctx context.Context, conversationID string, md map[string]interface{}) error
+
+	// messages
+	// InsertMessage stores m in m.ConversationID and sets its ID and CreatedAt.
+	InsertMessage(ctx context.Context, m *agent.Message) error
+	GetConversationMessages(ctx context.Context, conversationID string) ([]agent.Message, error)
+	// ListMessages returns a page of a conversation's messages; see MessageQuery.
+	ListMessages(ctx context.Context, conversationID string, q MessageQuery) ([]agent.Message, error)
+
+	// participants
+	AddParticipants(ctx context.Context, conversationID string, agentIDs ...string) error
+	RemoveParticipant(ctx context.Context, conversationID, agentID string) error
+	ListParticipants(ctx context.Context, conversationID string) ([]agent.Agent, error)
+
+	// event log; AppendEvent assigns evt.Seq
+	AppendEvent(ctx context.Context, evt *events.Event) error
+	ListEvents(ctx context.Context, conversationID string, afterSeq int64, limit int) ([]events.Event, error)
+	// AckEvents records that userID has seen the conversation's events up to seq;
+	// the acknowledged seq only moves forward. EventAck returns it, 0 when none.
+	AckEvents(ctx context.Context, conversationID, userID string, seq int64) error
+	EventAck(ctx context.Context, conversationID, userID string) (int64, error)
+
+	// embeddings
+	SaveEmbedding(ctx context.Context, conversationID, messageID string, vec []float32) error
+	QuerySimilarMessages(ctx context.Context, vec []float32, k int) ([]string, error)
+	// SearchMessages returns the embedded messages most similar to vec that match opts.
+	SearchMessages(ctx context.Context, vec []float32, opts SearchOptions) ([]ScoredMessage, error)
+
+	// debates; CreateDebate sets d.ID, d.CreatedAt and d.UpdatedAt
+	CreateDebate(ctx context.Context, d *Debate) error
+	// UpdateDebate saves the progress of d and sets d.UpdatedAt.
+	UpdateDebate(ctx context.Context, d *Debate) error
+	GetDebate(ctx context.Context, id string) (*Debate, error)
+	// SaveDebateResult stores a judge's result, replacing the debate's previous one.
+	SaveDebateResult(ctx context.Context, r *DebateResult) error
+	GetDebateResult(ctx context.Context, debateID string) (*DebateResult, error)
+
+	// polls; CreatePoll sets p.ID, p.CreatedAt and zero p.Counts, and polls are read with their counts
+	CreatePoll(ctx context.Context, p *Poll) error
+	GetPoll(ctx context.Context, id string) (*Poll, error)
+	ListPolls(ctx context.Context, conversationID string) ([]Poll, error)
+	// CastVote records or replaces a voter's vote; ErrPollClosed once the poll has closed.
+	CastVote(ctx context.Context, pollID, voterID string, option int) error
+	// ClosePoll closes an open poll; ErrPollClosed if it was closed already.
+	ClosePoll(ctx context.Context, id string) (*Poll, error)
+
+	// autonomous runs; CreateAutonomousRun sets r.ID, r.CreatedAt and r.UpdatedAt
+	CreateAutonomousRun(ctx context.Context, r *AutonomousRun) error
+	// UpdateAutonomousRun saves the progress of r and sets r.UpdatedAt.
+	UpdateAutonomousRun(ctx context.Context, r *AutonomousRun) error
+	GetAutonomousRun(ctx context.Context, id string) (*AutonomousRun, error)
+
+	// agent memories; SaveMemory sets m.ID and m.CreatedAt
+	SaveMemory(ctx context.Context, m *agent.Memory, vec []float32) error
+	ListMemories(ctx context.Context, agentID string, limit int) ([]agent.Memory, error)
+	// SearchMemories returns the k memories of an agent most similar to vec.
+	SearchMemories(ctx context.Context, agentID string, vec []float32, k int) ([]ScoredMemory, error)
+
+	Close()
+}
+
+var (
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,960 @@
+// Package storetest is a conformance suite run against every persistence.Store implementation.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/agent"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+// unknownID is a well-formed id that no store will have issued.
+const unknownID = "00000000-0000-4000-8000-000000000000"
+
+// Run exercises newStore's Store against the behavior the orchestrator relies on.
+// newStore is called once per subtest and should return an isolated store when possible.
+func Run(t *testing.T, newStore func(t *testing.T) persistence.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s persistence.Store)
+	}{
+		{"Agents", testAgents},
+		{"AgentUpdateDelete", testAgentUpdateDelete},
+		{"Conversations", testConversations},
+		{"ConversationLifecycle", testConversationLifecycle},
+		{"Metadata", testMetadata},
+		{"Messages", testMessages},
+		{"MessagePages", testMessagePages},
+		{"Threads", testThreads},
+		{"Participants", testParticipants},
+		{"Events", testEvents},
+		{"Embeddings", testEmbeddings},
+		{"Search", testSearch},
+		{"FilteredSearch", testFilteredSearch},
+		{"Memories", testMemories},
+		{"Debates", testDebates},
+		{"Polls", testPolls},
+		{"AutonomousRuns", testAutonomousRuns},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testAgents(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	id, err := s.CreateAgent(ctx, "Alice", "music, literature", map[string]interface{}{"talkativeness": 0.7})
+	if err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	agents, err := s.ListAgents(ctx)
+	if err != nil {
+		t.Fatalf("list agents: %v", err)
+	}
+	for _, a := range agents {
+		if string(a.ID) != id {
+			continue
+		}
+		if a.Name != "Alice" || a.Persona != "music, literature" {
+			t.Fatalf("unexpected agent %+v", a)
+		}
+		if a.BehaviorProfile["talkativeness"] != 0.7 {
+			t.Fatalf("behavior profile not round-tripped: %+v", a.BehaviorProfile)
+		}
+		if a.CreatedAt.IsZero() {
+			t.Fatal("expected created_at to be set")
+		}
+		return
+	}
+	t.Fatalf("created agent %s not listed", id)
+}
+
+func testAgentUpdateDelete(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	id, err := s.CreateAgent(ctx, "Bob", "chess", map[string]interface{}{"talkativeness": 0.4, "tone": "dry"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetAgent(ctx, unknownID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown agent, got %v", err)
+	}
+	name := "Robert"
+	got, err := s.UpdateAgent(ctx, id, persistence.AgentPatch{
+		Name:            &name,
+		BehaviorProfile: map[string]interface{}{"talkativeness": 0.9, "tone": nil, "humor": "high"},
+	})
+	if err != nil {
+		t.Fatalf("update agent: %v", err)
+	}
+	if got.Name != "Robert" || got.Persona != "chess" {
+		t.Fatalf("unexpected agent after update %+v", got)
+	}
+	want := map[string]interface{}{"talkativeness": 0.9, "humor": "high"}
+	if len(got.BehaviorProfile) != len(want) || got.BehaviorProfile["talkativeness"] != 0.9 || got.BehaviorProfile["humor"] != "high" {
+		t.Fatalf("behavior profile not merged: %+v", got.BehaviorProfile)
+	}
+	stored, err := s.GetAgent(ctx, id)
+	if err != nil {
+		t.Fatalf("get agent: %v", err)
+	}
+	if stored.Name != "Robert" || len(stored.BehaviorProfile) != len(want) {
+		t.Fatalf("update not stored: %+v", stored)
+	}
+
+	conv, _ := s.CreateConversation(ctx, "soft delete")
+	if err := s.AddParticipants(ctx, conv, id); err != nil {
+		t.Fatal(err)
+	}
+	msg := &agent.Message{ConversationID: conv, SenderType: agent.SenderAgent, SenderID: id, SenderName: "Robert", Content: "check"}
+	if err := s.InsertMessage(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAgent(ctx, id); err != nil {
+		t.Fatalf("delete agent: %v", err)
+	}
+	if err := s.DeleteAgent(ctx, id); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+	if _, err := s.GetAgent(ctx, id); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected deleted agent to be gone, got %v", err)
+	}
+	if _, err := s.UpdateAgent(ctx, id, persistence.AgentPatch{Name: &name}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating a deleted agent, got %v", err)
+	}
+	agents, _ := s.ListAgents(ctx)
+	for _, a := range agents {
+		if string(a.ID) == id {
+			t.Fatal("deleted agent still listed")
+		}
+	}
+	if parts, _ := s.ListParticipants(ctx, conv); len(parts) != 0 {
+		t.Fatalf("deleted agent still a participant: %+v", parts)
+	}
+	if err := s.AddParticipants(ctx, conv, id); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound adding a deleted agent, got %v", err)
+	}
+	msgs, _ := s.GetConversationMessages(ctx, conv)
+	if len(msgs) != 1 || msgs[0].SenderID != id || msgs[0].SenderName != "Robert" {
+		t.Fatalf("message attribution lost: %+v", msgs)
+	}
+}
+
+func testConversations(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	first, err := s.CreateConversation(ctx, "first")
+	if err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	second, err := s.CreateConversation(ctx, "second")
+	if err != nil {
+		t.Fatalf("create conversation: %v", err)
+	}
+	list, err := s.ListConversations(ctx)
+	if err != nil {
+		t.Fatalf("list conversations: %v", err)
+	}
+	pos := map[string]int{}
+	for i, c := range list {
+		pos[c.ID] = i
+		if c.ID == first && c.Title != "first" {
+			t.Fatalf("unexpected title %q", c.Title)
+		}
+	}
+	i1, ok1 := pos[first]
+	i2, ok2 := pos[second]
+	if !ok1 || !ok2 {
+		t.Fatalf("created conversations not listed: %+v", list)
+	}
+	if i2 > i1 {
+		t.Fatal("expected newest conversation first")
+	}
+}
+
+func testConversationLifecycle(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.GetConversation(ctx, conv)
+	if err != nil {
+		t.Fatalf("get conversation: %v", err)
+	}
+	if c.Title != "draft" || c.Status != persistence.ConversationActive || c.CreatedAt.IsZero() {
+		t.Fatalf("unexpected conversation %+v", c)
+	}
+	title, status := "final", persistence.ConversationClosed
+	if c, err = s.UpdateConversation(ctx, conv, persistence.ConversationPatch{Title: &title}); err != nil {
+		t.Fatalf("rename conversation: %v", err)
+	}
+	if c.Title != "final" || c.Status != persistence.ConversationActive {
+		t.Fatalf("unexpected conversation after rename %+v", c)
+	}
+	if c, err = s.UpdateConversation(ctx, conv, persistence.ConversationPatch{Status: &status}); err != nil {
+		t.Fatalf("close conversation: %v", err)
+	}
+	if c.Title != "final" || c.Status != persistence.ConversationClosed {
+		t.Fatalf("unexpected conversation after close %+v", c)
+	}
+	if _, err := s.UpdateConversation(ctx, unknownID, persistence.ConversationPatch{Title: &title}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating unknown conversation, got %v", err)
+	}
+
+	a, _ := s.CreateAgent(ctx, "Keeper", "p", nil)
+	if err := s.AddParticipants(ctx, conv, a); err != nil {
+		t.Fatal(err)
+	}
+	msg := insertMessage(t, s, conv, "to be deleted")
+	if err := s.SaveEmbedding(ctx, conv, msg, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	evt, _ := events.New(events.TypeConversationUpdated, conv, nil, nil)
+	if err := s.AppendEvent(ctx, evt); err != nil {
+		t.Fatal(err)
+	}
+	mem := &agent.Memory{AgentID: a, Kind: agent.MemoryFact, Content: "kept", ConversationID: conv, SourceMessageID: msg}
+	if err := s.SaveMemory(ctx, mem, []float32{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteConversation(ctx, conv); err != nil {
+		t.Fatalf("delete conversation: %v", err)
+	}
+	if _, err := s.GetConversation(ctx, conv); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected deleted conversation to be gone, got %v", err)
+	}
+	if err := s.DeleteConversation(ctx, conv); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+	if hits, _ := s.SearchMessages(ctx, []float32{1, 0}, persistence.SearchOptions{ConversationID: conv, K: 5}); len(hits) != 0 {
+		t.Fatalf("messages of deleted conversation still searchable: %+v", hits)
+	}
+	mems, _ := s.ListMemories(ctx, a, 10)
+	if len(mems) != 1 || mems[0].ConversationID != "" || mems[0].SourceMessageID != "" {
+		t.Fatalf("expected the memory to stay without its conversation, got %+v", mems)
+	}
+}
+
+func testMetadata(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	id, err := s.CreateConversation(ctx, "md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := s.GetConversationMetadata(ctx, id)
+	if err != nil {
+		t.Fatalf("get metadata: %v", err)
+	}
+	if len(md) != 0 {
+		t.Fatalf("expected empty metadata, got %+v", md)
+	}
+	want := map[string]interface{}{"turn_policy": map[string]interface{}{"name": "random", "max_speakers": 2}}
+	if err := s.SetConversationMetadata(ctx, id, want); err != nil {
+		t.Fatalf("set metadata: %v", err)
+	}
+	md, err = s.GetConversationMetadata(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp, _ := md["turn_policy"].(map[string]interface{})
+	if tp["name"] != "random" || tp["max_speakers"] != float64(2) {
+		t.Fatalf("metadata not round-tripped: %+v", md)
+	}
+	if _, err := s.GetConversationMetadata(ctx, unknownID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.SetConversationMetadata(ctx, unknownID, want); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func testMessages(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "msgs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first string
+	for _, c := range []string{"one", "two", "three"} {
+		id := insertMessage(t, s, conv, c)
+		if first == "" {
+			first = id
+		}
+	}
+	reply := &agent.Message{
+		ConversationID: conv,
+		SenderType:     agent.SenderAgent,
+		SenderID:       "a1",
+		SenderName:     "Alice",
+		Content:        "four",
+		ReplyTo:        first,
+		Metadata:       map[string]interface{}{"mood": "curious"},
+	}
+	if err := s.InsertMessage(ctx, reply); err != nil {
+		t.Fatalf("insert reply: %v", err)
+	}
+	msgs, err := s.GetConversationMessages(ctx, conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 4 || msgs[0].Content != "one" || msgs[2].Content != "three" {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+	if m := msgs[0]; m.ID != first || m.ConversationID != conv || m.SenderType != agent.SenderUser || m.SenderID != "u1" || m.CreatedAt.IsZero() {
+		t.Fatalf("message not round-tripped: %+v", m)
+	}
+	if m := msgs[3]; m.ID != reply.ID || m.SenderName != "Alice" || m.ReplyTo != first || m.Metadata["mood"] != "curious" {
+		t.Fatalf("reply not round-tripped: %+v", m)
+	}
+	if msgs, err := s.GetConversationMessages(ctx, unknownID); err != nil || len(msgs) != 0 {
+		t.Fatalf("expected no messages for unknown conversation, got %v, %v", msgs, err)
+	}
+	if err := s.InsertMessage(ctx, &agent.Message{ConversationID: unknownID, SenderType: agent.SenderUser, Content: "x"}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.InsertMessage(ctx, &agent.Message{ConversationID: conv, Content: "x", ReplyTo: unknownID}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown reply_to, got %v", err)
+	}
+}
+
+func testThreads(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "threads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := insertMessage(t, s, conv, "who likes jazz?")
+	insertMessage(t, s, conv, "unrelated")
+	mention := agent.Mention{AgentID: "a1", Name: "Alice", Offset: 0, Length: 6}
+	reply := &agent.Message{ConversationID: conv, SenderType: agent.SenderUser, SenderID: "u1", Content: "@Alice do you?", ReplyTo: root, Mentions: []agent.Mention{mention}}
+	if err := s.InsertMessage(ctx, reply); err != nil {
+		t.Fatalf("insert reply: %v", err)
+	}
+	answer := &agent.Message{ConversationID: conv, SenderType: agent.SenderAgent, SenderID: "a1", Content: "I do", ReplyTo: reply.ID}
+	if err := s.InsertMessage(ctx, answer); err != nil {
+		t.Fatalf("insert nested reply: %v", err)
+	}
+	if reply.ThreadID != root || answer.ThreadID != root {
+		t.Fatalf("expected replies in the thread of %s, got %q and %q", root, reply.ThreadID, answer.ThreadID)
+	}
+
+	thread, err := s.ListMessages(ctx, conv, persistence.MessageQuery{ThreadID: root})
+	if err != nil {
+		t.Fatalf("list thread: %v", err)
+	}
+	if len(thread) != 3 || thread[0].ID != root || thread[0].ThreadID != "" || thread[2].ID != answer.ID || thread[2].ReplyTo != reply.ID {
+		t.Fatalf("unexpected thread %+v", thread)
+	}
+	if m := thread[1]; len(m.Mentions) != 1 || m.Mentions[0] != mention || m.ThreadID != root {
+		t.Fatalf("mentions not round-tripped: %+v", m)
+	}
+	mentioning, err := s.ListMessages(ctx, conv, persistence.MessageQuery{MentionID: "a1"})
+	if err != nil || len(mentioning) != 1 || mentioning[0].ID != reply.ID {
+		t.Fatalf("expected only the reply to mention a1, got %+v (%v)", mentioning, err)
+	}
+	if msgs, err := s.ListMessages(ctx, conv, persistence.MessageQuery{ThreadID: unknownID}); err != nil || len(msgs) != 0 {
+		t.Fatalf("expected no messages in an unknown thread, got %v, %v", msgs, err)
+	}
+
+	other, _ := s.CreateConversation(ctx, "other")
+	if err := s.InsertMessage(ctx, &agent.Message{ConversationID: other, SenderType: agent.SenderUser, Content: "x", ReplyTo: root}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound replying across conversations, got %v", err)
+	}
+	if err := s.DeleteConversation(ctx, conv); err != nil {
+		t.Fatalf("delete threaded conversation: %v", err)
+	}
+}
+
+// insertMessage stores a user message and returns its id.
+func insertMessage(t *testing.T, s persistence.Store, conv, content string) string {
+	t.Helper()
+	m := &agent.Message{ConversationID: conv, SenderType: agent.SenderUser, SenderID: "u1", SenderName: "u1", Content: content}
+	if err := s.InsertMessage(context.Background(), m); err != nil {
+		t.Fatalf("insert message: %v", err)
+	}
+	if m.ID == "" {
+		t.Fatal("expected message id")
+	}
+	return m.ID
+}
+
+func testEvents(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := s.CreateConversation(ctx, "other")
+	msgID := insertMessage(t, s, conv, "hi")
+	for i, content := range []string{"hi", "two", "three"} {
+		evt, _ := events.New(events.TypeMessageCreated, conv, &events.Sender{Type: events.SenderUser, ID: "u1", Name: "u1"}, events.MessagePayload{Content: content})
+		if i == 0 {
+			evt.MessageID = msgID
+		}
+		if err := s.AppendEvent(ctx, evt); err != nil {
+			t.Fatalf("append event: %v", err)
+		}
+		if evt.Seq != int64(i+1) {
+			t.Fatalf("expected seq %d, got %d", i+1, evt.Seq)
+		}
+	}
+	evt, _ := events.New(events.TypeParticipantJoined, other, nil, events.ParticipantPayload{AgentID: "a1"})
+	if err := s.AppendEvent(ctx, evt); err != nil || evt.Seq != 1 {
+		t.Fatalf("expected conversations to be numbered independently, got seq %d, %v", evt.Seq, err)
+	}
+
+	all, err := s.ListEvents(ctx, conv, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(all))
+	}
+	first := all[0]
+	var p events.MessagePayload
+	if first.Type != events.TypeMessageCreated || first.Version != events.SchemaVersion || first.ConversationID != conv ||
+		first.MessageID != msgID || first.Sender == nil || first.Sender.ID != "u1" || first.Timestamp.IsZero() {
+		t.Fatalf("event not round-tripped: %+v", first)
+	}
+	if err := first.Decode(&p); err != nil || p.Content != "hi" {
+		t.Fatalf("payload not round-tripped: %s, %v", first.Payload, err)
+	}
+	page, err := s.ListEvents(ctx, conv, 1, 1)
+	if err != nil || len(page) != 1 || page[0].Seq != 2 {
+		t.Fatalf("expected only seq 2 after 1 with limit 1, got %+v, %v", page, err)
+	}
+	if rest, err := s.ListEvents(ctx, conv, 3, 10); err != nil || len(rest) != 0 {
+		t.Fatalf("expected no events after the head, got %+v, %v", rest, err)
+	}
+	if evts, err := s.ListEvents(ctx, unknownID, 0, 0); err != nil || len(evts) != 0 {
+		t.Fatalf("expected no events for unknown conversation, got %v, %v", evts, err)
+	}
+	evt, _ = events.New(events.TypeMessageCreated, unknownID, nil, nil)
+	if err := s.AppendEvent(ctx, evt); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if seq, err := s.EventAck(ctx, conv, "u1"); err != nil || seq != 0 {
+		t.Fatalf("expected no ack yet, got %d, %v", seq, err)
+	}
+	for _, seq := range []int64{2, 1} {
+		if err := s.AckEvents(ctx, conv, "u1", seq); err != nil {
+			t.Fatalf("ack: %v", err)
+		}
+	}
+	if seq, err := s.EventAck(ctx, conv, "u1"); err != nil || seq != 2 {
+		t.Fatalf("expected the ack to stay at 2, got %d, %v", seq, err)
+	}
+	if seq, _ := s.EventAck(ctx, conv, "u2"); seq != 0 {
+		t.Fatalf("expected acks per user, got %d for u2", seq)
+	}
+	if err := s.AckEvents(ctx, unknownID, "u1", 1); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound acking an unknown conversation, got %v", err)
+	}
+}
+
+func testMessagePages(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, _ := s.CreateConversation(ctx, "pages")
+	other, _ := s.CreateConversation(ctx, "other")
+	var ids []string
+	for i := 0; i < 5; i++ {
+		m := &agent.Message{ConversationID: conv, SenderType: agent.SenderUser, SenderID: "u1", Content: "m"}
+		if i%2 == 1 {
+			m.SenderID = "u2"
+		}
+		if err := s.InsertMessage(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, m.ID)
+		time.Sleep(2 * time.Millisecond) // distinct created_at
+	}
+	foreign := insertMessage(t, s, other, "elsewhere")
+	page := func(q persistence.MessageQuery) []string {
+		t.Helper()
+		msgs, err := s.ListMessages(ctx, conv, q)
+		if err != nil {
+			t.Fatalf("list messages %+v: %v", q, err)
+		}
+		out := make([]string, len(msgs))
+		for i, m := range msgs {
+			out[i] = m.ID
+		}
+		return out
+	}
+	expect := func(got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+		}
+	}
+	expect(page(persistence.MessageQuery{}), ids...)
+	expect(page(persistence.MessageQuery{Limit: 2, Desc: true}), ids[4], ids[3])
+	expect(page(persistence.MessageQuery{Limit: 2, Desc: true, BeforeID: ids[3]}), ids[2], ids[1])
+	expect(page(persistence.MessageQuery{AfterID: ids[1], BeforeID: ids[4]}), ids[2], ids[3])
+	expect(page(persistence.MessageQuery{SenderID: "u2"}), ids[1], ids[3])
+	msgs, _ := s.ListMessages(ctx, conv, persistence.MessageQuery{})
+	expect(page(persistence.MessageQuery{After: msgs[2].CreatedAt}), ids[3], ids[4])
+	expect(page(persistence.MessageQuery{Before: msgs[2].CreatedAt, Desc: true}), ids[1], ids[0])
+	if _, err := s.ListMessages(ctx, conv, persistence.MessageQuery{BeforeID: foreign}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a cursor from another conversation, got %v", err)
+	}
+}
+
+func testParticipants(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "participants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a1, _ := s.CreateAgent(ctx, "A1", "p1", nil)
+	a2, _ := s.CreateAgent(ctx, "A2", "p2", nil)
+	if err := s.AddParticipants(ctx, conv, a1, a2, a1); err != nil {
+		t.Fatalf("add participants: %v", err)
+	}
+	got, err := s.ListParticipants(ctx, conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || string(got[0].ID) != a1 || string(got[1].ID) != a2 {
+		t.Fatalf("unexpected participants %+v", got)
+	}
+	if err := s.RemoveParticipant(ctx, conv, a1); err != nil {
+		t.Fatalf("remove participant: %v", err)
+	}
+	if err := s.RemoveParticipant(ctx, conv, a1); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound removing twice, got %v", err)
+	}
+	got, _ = s.ListParticipants(ctx, conv)
+	if len(got) != 1 || string(got[0].ID) != a2 {
+		t.Fatalf("unexpected participants after removal %+v", got)
+	}
+	if err := s.AddParticipants(ctx, conv, unknownID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown agent, got %v", err)
+	}
+
+	// a conversation created with its participants and metadata is all or nothing
+	with, err := s.CreateConversationWith(ctx, "with", []string{a1, a2}, map[string]interface{}{"topic": "tea"})
+	if err != nil {
+		t.Fatalf("create conversation with participants: %v", err)
+	}
+	got, _ = s.ListParticipants(ctx, with)
+	if len(got) != 2 {
+		t.Fatalf("unexpected participants %+v", got)
+	}
+	if md, err := s.GetConversationMetadata(ctx, with); err != nil || md["topic"] != "tea" {
+		t.Fatalf("unexpected metadata %v, %v", md, err)
+	}
+	before, _ := s.ListConversations(ctx)
+	if _, err := s.CreateConversationWith(ctx, "orphan", []string{a1, unknownID}, nil); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown agent, got %v", err)
+	}
+	if after, _ := s.ListConversations(ctx); len(after) != len(before) {
+		t.Fatalf("a failed create left a conversation behind: %d -> %d", len(before), len(after))
+	}
+}
+
+func testEmbeddings(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "embeddings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := insertMessage(t, s, conv, "near")
+	far := insertMessage(t, s, conv, "far")
+	if err := s.SaveEmbedding(ctx, conv, near, []float32{1, 0, 0}); err != nil {
+		t.Fatalf("save embedding: %v", err)
+	}
+	if err := s.SaveEmbedding(ctx, conv, far, []float32{0, 0, 1}); err != nil {
+		t.Fatalf("save embedding: %v", err)
+	}
+	ids, err := s.QuerySimilarMessages(ctx, []float32{0.9, 0.1, 0}, 1)
+	if err != nil {
+		t.Fatalf("query similar: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != near {
+		t.Fatalf("expected [%s], got %v", near, ids)
+	}
+}
+
+func testSearch(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, _ := s.CreateConversation(ctx, "search")
+	other, _ := s.CreateConversation(ctx, "other")
+	post := func(conv, senderID, content string, vec []float32) string {
+		m := &agent.Message{ConversationID: conv, SenderType: agent.SenderAgent, SenderID: senderID, SenderName: senderID, Content: content}
+		if err := s.InsertMessage(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveEmbedding(ctx, conv, m.ID, vec); err != nil {
+			t.Fatal(err)
+		}
+		return m.ID
+	}
+	jazz := post(conv, "a1", "jazz", []float32{1, 0, 0})
+	blues := post(conv, "a2", "blues", []float32{0.8, 0.6, 0})
+	post(conv, "a1", "taxes", []float32{0, 0, 1})
+	elsewhere := post(other, "a1", "jazz again", []float32{1, 0, 0})
+
+	hits, err := s.SearchMessages(ctx, []float32{1, 0, 0}, persistence.SearchOptions{ConversationID: conv, K: 2})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != jazz || hits[1].ID != blues {
+		t.Fatalf("expected [jazz blues], got %+v", hits)
+	}
+	if hits[0].Content != "jazz" || hits[0].SenderName != "a1" || hits[0].Score < 0.99 || hits[1].Score > hits[0].Score {
+		t.Fatalf("unexpected hit %+v", hits[0])
+	}
+
+	hits, _ = s.SearchMessages(ctx, []float32{1, 0, 0}, persistence.SearchOptions{SenderID: "a1", K: 10})
+	if len(hits) != 3 || (hits[0].ID != jazz && hits[0].ID != elsewhere) {
+		t.Fatalf("expected a1's three messages across conversations, got %+v", hits)
+	}
+	hits, _ = s.SearchMessages(ctx, []float32{1, 0, 0}, persistence.SearchOptions{Since: time.Now().Add(time.Hour)})
+	if len(hits) != 0 {
+		t.Fatalf("expected no messages from the future, got %+v", hits)
+	}
+}
+
+// vectorDims is the size of the pgvector columns the migrations create, so the
+// Postgres store searches these vectors through its index.
+const vectorDims = 1536
+
+// testFilteredSearch crowds the query's neighbourhood with more messages and
+// memories than an index scan visits (hnsw.ef_search is 40), none of which match
+// the filter, so a search that filters the index scan's rows comes back short.
+func testFilteredSearch(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	alice, _ := s.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := s.CreateAgent(ctx, "Bob", "fitness", nil)
+	conv, _ := s.CreateConversation(ctx, "quiet")
+	crowded, _ := s.CreateConversation(ctx, "crowded")
+	// vec points mostly along the first axis and a little along another
+	vec := func(first float32, axis int, weight float32) []float32 {
+		v := make([]float32, vectorDims)
+		v[0], v[axis] = first, weight
+		return v
+	}
+	post := func(conv, senderID string, v []float32) string {
+		m := &agent.Message{ConversationID: conv, SenderType: agent.SenderAgent, SenderID: senderID, SenderName: senderID, Content: "x"}
+		if err := s.InsertMessage(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SaveEmbedding(ctx, conv, m.ID, v); err != nil {
+			t.Fatal(err)
+		}
+		return m.ID
+	}
+	for i := range 100 {
+		post(crowded, "loud", vec(1, 2+i, 0.01))
+		if err := s.SaveMemory(ctx, &agent.Memory{AgentID: bob, Kind: agent.MemoryFact, Content: "crowd"}, vec(1, 2+i, 0.01)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want []string
+	for i := range 3 {
+		want = append(want, post(conv, "quiet", vec(1, 1, float32(i+1))))
+		if err := s.SaveMemory(ctx, &agent.Memory{AgentID: alice, Kind: agent.MemoryFact, Content: "quiet"}, vec(1, 1, float32(i+1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query := vec(1, 0, 0)
+
+	for _, opts := range []persistence.SearchOptions{{ConversationID: conv, K: 3}, {SenderID: "quiet", K: 3}} {
+		hits, err := s.SearchMessages(ctx, query, opts)
+		if err != nil {
+			t.Fatalf("search %+v: %v", opts, err)
+		}
+		if len(hits) != 3 || hits[0].ID != want[0] || hits[1].ID != want[1] || hits[2].ID != want[2] {
+			t.Fatalf("search %+v: expected the three quiet messages nearest first, got %d hits %+v", opts, len(hits), hits)
+		}
+	}
+	mems, err := s.SearchMemories(ctx, alice, query, 3)
+	if err != nil {
+		t.Fatalf("search memories: %v", err)
+	}
+	if len(mems) != 3 || mems[0].Score < mems[1].Score || mems[1].Score < mems[2].Score {
+		t.Fatalf("expected Alice's three memories nearest first, got %+v", mems)
+	}
+	for _, m := range mems {
+		if m.AgentID != alice {
+			t.Fatalf("expected only Alice's memories, got %+v", m)
+		}
+	}
+}
+
+func testMemories(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	alice, _ := s.CreateAgent(ctx, "Alice", "music", nil)
+	bob, _ := s.CreateAgent(ctx, "Bob", "fitness", nil)
+	conv, _ := s.CreateConversation(ctx, "memories")
+	src := insertMessage(t, s, conv, "I have three cats")
+
+	cats := &agent.Memory{AgentID: alice, Kind: agent.MemoryFact, Subject: "u1", Content: "u1 has three cats", ConversationID: conv, SourceMessageID: src}
+	if err := s.SaveMemory(ctx, cats, []float32{1, 0, 0}); err != nil {
+		t.Fatalf("save memory: %v", err)
+	}
+	if cats.ID == "" || cats.CreatedAt.IsZero() {
+		t.Fatalf("expected id and created_at to be set, got %+v", cats)
+	}
+	jazz := &agent.Memory{AgentID: alice, Kind: agent.MemoryOpinion, Content: "jazz is underrated"}
+	if err := s.SaveMemory(ctx, jazz, []float32{0, 1, 0}); err != nil {
+		t.Fatalf("save memory: %v", err)
+	}
+	if err := s.SaveMemory(ctx, &agent.Memory{AgentID: bob, Kind: agent.MemoryFact, Content: "u1 has three cats"}, []float32{1, 0, 0}); err != nil {
+		t.Fatalf("save memory: %v", err)
+	}
+	if err := s.SaveMemory(ctx, &agent.Memory{AgentID: unknownID, Kind: agent.MemoryFact, Content: "x"}, nil); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown agent, got %v", err)
+	}
+
+	mems, err := s.ListMemories(ctx, alice, 0)
+	if err != nil {
+		t.Fatalf("list memories: %v", err)
+	}
+	if len(mems) != 2 || mems[0].ID != jazz.ID || mems[1].ID != cats.ID {
+		t.Fatalf("expected alice's memories newest first, got %+v", mems)
+	}
+	if got := mems[1]; got.Subject != "u1" || got.ConversationID != conv || got.SourceMessageID != src || got.Kind != agent.MemoryFact {
+		t.Fatalf("memory not round-tripped: %+v", got)
+	}
+	if mems, _ := s.ListMemories(ctx, alice, 1); len(mems) != 1 {
+		t.Fatalf("expected limit to apply, got %d memories", len(mems))
+	}
+
+	hits, err := s.SearchMemories(ctx, alice, []float32{0.9, 0.1, 0}, 1)
+	if err != nil {
+		t.Fatalf("search memories: %v", err)
+	}
+	if len(hits) != 1 || hits[0].ID != cats.ID || hits[0].Score < 0.9 {
+		t.Fatalf("expected alice's cat memory, got %+v", hits)
+	}
+}
+
+func testDebates(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "debate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &persistence.Debate{
+		ConversationID: conv,
+		Format:         "oxford",
+		Topic:          "cats beat dogs",
+		Participants:   []string{"a1", "a2"},
+		Sides:          map[string]string{"a1": "for", "a2": "against"},
+		JudgeID:        "j1",
+		Rounds:         2,
+		State:          persistence.DebateRunning,
+	}
+	if err := s.CreateDebate(ctx, d); err != nil {
+		t.Fatalf("create debate: %v", err)
+	}
+	if d.ID == "" || d.CreatedAt.IsZero() {
+		t.Fatalf("create debate did not set id and time: %+v", d)
+	}
+	now := time.Now()
+	d.Round, d.Phase, d.SpeakerID = 2, "closing", "a2"
+	d.State, d.EndedAt = persistence.DebateFinished, &now
+	if err := s.UpdateDebate(ctx, d); err != nil {
+		t.Fatalf("update debate: %v", err)
+	}
+	got, err := s.GetDebate(ctx, d.ID)
+	if err != nil {
+		t.Fatalf("get debate: %v", err)
+	}
+	if got.ConversationID != conv || len(got.Participants) != 2 || got.Participants[1] != "a2" ||
+		got.Rounds != 2 || got.Round != 2 || got.SpeakerID != "a2" || !got.Ended() || got.EndedAt == nil {
+		t.Fatalf("unexpected debate %+v", got)
+	}
+	if got.Format != "oxford" || got.Topic != "cats beat dogs" || got.Sides["a2"] != "against" || got.JudgeID != "j1" || got.Phase != "closing" {
+		t.Fatalf("unexpected debate format fields %+v", got)
+	}
+
+	if _, err := s.GetDebateResult(ctx, d.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before the first score, got %v", err)
+	}
+	res := &persistence.DebateResult{
+		DebateID: d.ID,
+		JudgeID:  "j1",
+		Rounds:   []persistence.RoundScores{{Round: 1, Phase: "opening", Scores: map[string]float64{"a1": 7, "a2": 6}}},
+		Totals:   map[string]float64{"a1": 7, "a2": 6},
+	}
+	if err := s.SaveDebateResult(ctx, res); err != nil {
+		t.Fatalf("save debate result: %v", err)
+	}
+	res.Final, res.WinnerID, res.WinningSide = true, "a1", "for"
+	res.SideTotals = map[string]float64{"for": 7, "against": 6}
+	res.DecidedBy = persistence.DecidedByAudience
+	res.Audience = &persistence.AudienceVote{Keys: []string{"for", "against", "undecided"}, Pre: []int{1, 1, 2}, Post: []int{3, 1, 0}, Swing: []float64{50, 0, -50}}
+	if err := s.SaveDebateResult(ctx, res); err != nil {
+		t.Fatalf("replace debate result: %v", err)
+	}
+	gotRes, err := s.GetDebateResult(ctx, d.ID)
+	if err != nil {
+		t.Fatalf("get debate result: %v", err)
+	}
+	if !gotRes.Final || gotRes.WinnerID != "a1" || gotRes.WinningSide != "for" || len(gotRes.Rounds) != 1 ||
+		gotRes.Rounds[0].Scores["a2"] != 6 || gotRes.Totals["a1"] != 7 || gotRes.SideTotals["against"] != 6 {
+		t.Fatalf("unexpected debate result %+v", gotRes)
+	}
+	if a := gotRes.Audience; gotRes.DecidedBy != persistence.DecidedByAudience || a == nil || a.Keys[2] != "undecided" || a.Post[0] != 3 || a.Swing[0] != 50 {
+		t.Fatalf("unexpected audience vote %+v", gotRes)
+	}
+	if _, err := s.GetDebate(ctx, unknownID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown debate, got %v", err)
+	}
+	if err := s.DeleteConversation(ctx, conv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetDebate(ctx, d.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected debate to go with its conversation, got %v", err)
+	}
+	if _, err := s.GetDebateResult(ctx, d.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected debate result to go with its conversation, got %v", err)
+	}
+}
+
+func testPolls(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "polls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &persistence.Poll{ConversationID: conv, Question: "tea or coffee?", Options: []string{"tea", "coffee", "water"}, CreatedBy: "u1"}
+	if err := s.CreatePoll(ctx, p); err != nil {
+		t.Fatalf("create poll: %v", err)
+	}
+	if p.ID == "" || p.CreatedAt.IsZero() || len(p.Counts) != 3 {
+		t.Fatalf("create poll did not set id, time and counts: %+v", p)
+	}
+	for _, v := range []struct {
+		voter  string
+		option int
+	}{{"u1", 0}, {"u2", 0}, {"u3", 1}, {"u2", 2}} {
+		if err := s.CastVote(ctx, p.ID, v.voter, v.option); err != nil {
+			t.Fatalf("vote %+v: %v", v, err)
+		}
+	}
+	got, err := s.GetPoll(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("get poll: %v", err)
+	}
+	// u2's second vote replaces the first
+	if got.Question != p.Question || got.CreatedBy != "u1" || got.Counts[0] != 1 || got.Counts[1] != 1 || got.Counts[2] != 1 || got.Total() != 3 {
+		t.Fatalf("unexpected poll %+v", got)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired := &persistence.Poll{ConversationID: conv, Question: "too late?", Options: []string{"yes", "no"}, Deadline: &past}
+	if err := s.CreatePoll(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CastVote(ctx, expired.ID, "u1", 0); !errors.Is(err, persistence.ErrPollClosed) {
+		t.Fatalf("expected ErrPollClosed past the deadline, got %v", err)
+	}
+	list, err := s.ListPolls(ctx, conv)
+	if err != nil {
+		t.Fatalf("list polls: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != expired.ID || list[1].Counts[0] != 1 {
+		t.Fatalf("expected both polls newest first, got %+v", list)
+	}
+
+	closed, err := s.ClosePoll(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("close poll: %v", err)
+	}
+	if closed.ClosedAt == nil || !closed.Closed(time.Now()) || closed.Total() != 3 {
+		t.Fatalf("unexpected closed poll %+v", closed)
+	}
+	if err := s.CastVote(ctx, p.ID, "u4", 0); !errors.Is(err, persistence.ErrPollClosed) {
+		t.Fatalf("expected ErrPollClosed after closing, got %v", err)
+	}
+	if _, err := s.ClosePoll(ctx, p.ID); !errors.Is(err, persistence.ErrPollClosed) {
+		t.Fatalf("expected ErrPollClosed closing twice, got %v", err)
+	}
+	if _, err := s.GetPoll(ctx, unknownID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown poll, got %v", err)
+	}
+	if err := s.CastVote(ctx, unknownID, "u1", 0); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound voting in unknown poll, got %v", err)
+	}
+	if err := s.DeleteConversation(ctx, conv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetPoll(ctx, p.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected poll to go with its conversation, got %v", err)
+	}
+}
+
+func testAutonomousRuns(t *testing.T, s persistence.Store) {
+	ctx := context.Background()
+	conv, err := s.CreateConversation(ctx, "autonomous")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &persistence.AutonomousRun{
+		ConversationID:     conv,
+		Topic:              "is tea better than coffee?",
+		Participants:       []string{"a1", "a2", "a3"},
+		MaxTurns:           10,
+		MaxDurationSeconds: 60,
+		StopOn:             []string{persistence.StopConsensus, persistence.StopLeave},
+		State:              persistence.AutonomousRunning,
+	}
+	if err := s.CreateAutonomousRun(ctx, r); err != nil {
+		t.Fatalf("create autonomous run: %v", err)
+	}
+	if r.ID == "" || r.CreatedAt.IsZero() {
+		t.Fatalf("create autonomous run did not set id and time: %+v", r)
+	}
+	got, err := s.GetAutonomousRun(ctx, r.ID)
+	if err != nil {
+		t.Fatalf("get autonomous run: %v", err)
+	}
+	if got.Ended() || got.Turns != 0 || got.Left != nil || got.StopOn[1] != persistence.StopLeave {
+		t.Fatalf("unexpected new autonomous run %+v", got)
+	}
+
+	now := time.Now()
+	r.Turns, r.SpeakerID, r.Left = 4, "a2", []string{"a3"}
+	r.State, r.StopReason, r.EndedAt = persistence.AutonomousFinished, persistence.StopConsensus, &now
+	if err := s.UpdateAutonomousRun(ctx, r); err != nil {
+		t.Fatalf("update autonomous run: %v", err)
+	}
+	if got, err = s.GetAutonomousRun(ctx, r.ID); err != nil {
+		t.Fatalf("get autonomous run: %v", err)
+	}
+	if got.ConversationID != conv || got.Topic != r.Topic || len(got.Participants) != 3 || got.MaxTurns != 10 || got.MaxDurationSeconds != 60 {
+		t.Fatalf("unexpected autonomous run options %+v", got)
+	}
+	if got.Turns != 4 || got.SpeakerID != "a2" || len(got.Left) != 1 || got.Left[0] != "a3" ||
+		!got.Ended() || got.StopReason != persistence.StopConsensus || got.EndedAt == nil {
+		t.Fatalf("unexpected autonomous run progress %+v", got)
+	}
+
+	if _, err := s.GetAutonomousRun(ctx, unknownID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown autonomous run, got %v", err)
+	}
+	if err := s.UpdateAutonomousRun(ctx, &persistence.AutonomousRun{ID: unknownID, State: persistence.AutonomousFailed}); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound updating unknown autonomous run, got %v", err)
+	}
+	if err := s.DeleteConversation(ctx, conv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetAutonomousRun(ctx, r.ID); !errors.Is(err, persistence.ErrNotFound) {
+		t.Fatalf("expected autonomous run to go with its conversation, got %v", err)
+	}
+}
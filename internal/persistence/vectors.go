@@ -0,0 +1,460 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+)
+
+// DistanceMetric selects how embeddings are compared when ranking neighbours.
+type DistanceMetric string
+
+// Supported distance metrics.
+const (
+	MetricCosine       DistanceMetric = "cosine"
+	MetricL2           DistanceMetric = "l2"
+	MetricInnerProduct DistanceMetric = "inner_product"
+)
+
+// operator returns the pgvector distance operator for m.
+func (m DistanceMetric) operator() string {
+	switch m {
+	case MetricL2:
+		return "<->"
+	case MetricInnerProduct:
+		return "<#>"
+	default:
+		return "<=>"
+	}
+}
+
+// opclass returns the pgvector index operator class for m.
+func (m DistanceMetric) opclass() string {
+	switch m {
+	case MetricL2:
+		return "vector_l2_ops"
+	case MetricInnerProduct:
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// function returns the pgvector function computing the distance of operator.
+// The planner serves only the operator form from an index.
+func (m DistanceMetric) function() string {
+	switch m {
+	case MetricL2:
+		return "l2_distance"
+	case MetricInnerProduct:
+		return "vector_negative_inner_product"
+	default:
+		return "cosine_distance"
+	}
+}
+
+// distance computes m between a and b in Go, smaller meaning closer, for the
+// brute-force path. Vectors of different lengths are infinitely far apart.
+func (m DistanceMetric) distance(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	switch m {
+	case MetricL2:
+		return l2Distance(a, b)
+	case MetricInnerProduct:
+		var dot float64
+		for i := range a {
+			dot += float64(a[i]) * float64(b[i])
+		}
+		return -dot
+	default:
+		return 1 - cosineSimilarity(a, b)
+	}
+}
+
+// VectorConfig configures pgvector similarity search; zero fields take the defaults noted.
+type VectorConfig struct {
+	Dimensions int            // length of the vector column, 1536 (embeddings.DefaultDimensions)
+	Metric     DistanceMetric // cosine (default), l2 or inner_product
+}
+
+const defaultVectorDimensions = 1536
+
+func (c VectorConfig) withDefaults() VectorConfig {
+	if c.Dimensions <= 0 {
+		c.Dimensions = defaultVectorDimensions
+	}
+	if c.Metric == "" {
+		c.Metric = MetricCosine
+	}
+	return c
+}
+
+func (c VectorConfig) validate() error {
+	switch c.Metric {
+	case MetricCosine, MetricL2, MetricInnerProduct:
+	default:
+		return fmt.Errorf("unknown vector metric %q", c.Metric)
+	}
+	return nil
+}
+
+// VectorConfigFromEnv reads EMBEDDING_DIMENSIONS and VECTOR_METRIC.
+func VectorConfigFromEnv() (VectorConfig, error) {
+	var cfg VectorConfig
+	if v := os.Getenv("EMBEDDING_DIMENSIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid EMBEDDING_DIMENSIONS %q", v)
+		}
+		cfg.Dimensions = n
+	}
+	cfg.Metric = DistanceMetric(os.Getenv("VECTOR_METRIC"))
+	cfg = cfg.withDefaults()
+	return cfg, cfg.validate()
+}
+
+// ErrNoPgvector is returned by ConfigureVectors when the pgvector extension is
+// not installed; similarity search then uses the brute-force fallback.
+var ErrNoPgvector = errors.New("pgvector extension not installed")
+
+// vectorColumnDims returns the dimensions of the embedding vector column of
+// table, or 0 when pgvector or the column is absent.
+func (s *PostgresStore) vectorColumnDims(ctx context.Context, table string) (int, error) {
+	var typ string
+	err := s.pool.QueryRow(ctx, `SELECT format_type(a.atttypid, a.atttypmod) FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attname = 'embedding' AND NOT a.attisdropped`, table).Scan(&typ)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var dims int
+	if _, err := fmt.Sscanf(typ, "vector(%d)", &dims); err != nil {
+		return 0, fmt.Errorf("%s.embedding has type %s, want vector(n)", table, typ)
+	}
+	return dims, nil
+}
+
+// vectorIndexExists reports whether embeddings.embedding has an HNSW index with
+// the given operator class.
+func (s *PostgresStore) vectorIndexExists(ctx context.Context, opclass string) (bool, error) {
+	var ok bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		JOIN pg_am am ON am.oid = c.relam
+		JOIN pg_opclass oc ON oc.oid = i.indclass[0]
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+		WHERE i.indrelid = to_regclass('embeddings') AND a.attname = 'embedding' AND am.amname = 'hnsw' AND oc.opcname = $1)`,
+		opclass).Scan(&ok)
+	return ok, err
+}
+
+// iterativeScans reports whether pgvector version v (e.g. "0.8.0") supports
+// hnsw.iterative_scan, added in 0.8.
+func iterativeScans(v string) bool {
+	var major, minor int
+	if _, err := fmt.Sscanf(v, "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	return major > 0 || minor >= 8
+}
+
+// detectVectors enables the pgvector paths for the vector columns migrations created.
+func (s *PostgresStore) detectVectors(ctx context.Context) {
+	s.vec = VectorConfig{}.withDefaults()
+	if dims, err := s.vectorColumnDims(ctx, "embeddings"); err == nil && dims > 0 {
+		s.vec.Dimensions = dims
+		s.ann, s.indexed = true, true
+	}
+	s.detectVectorFeatures(ctx)
+}
+
+// detectVectorFeatures enables the pgvector path for memories when
+// agent_memories.embedding matches the configured dimensions, and notes whether
+// pgvector supports iterative scans.
+func (s *PostgresStore) detectVectorFeatures(ctx context.Context) {
+	dims, err := s.vectorColumnDims(ctx, "agent_memories")
+	s.memANN = err == nil && dims == s.vec.Dimensions
+	var version string
+	if err := s.pool.QueryRow(ctx, "SELECT extversion FROM pg_extension WHERE extname = 'vector'").Scan(&version); err == nil {
+		s.iterative = iterativeScans(version)
+	}
+}
+
+// ConfigureVectors checks the vector columns and the HNSW index for cfg.Metric
+// created by the migrations (006 and 017) against cfg and searches with
+// cfg.Metric. It does not change the schema: a different size needs a migration
+// of its own. Without the extension it returns ErrNoPgvector and searches keep
+// using the brute-force fallback. Call it before serving.
+func (s *PostgresStore) ConfigureVectors(ctx context.Context, cfg VectorConfig) error {
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	s.vec, s.ann, s.indexed = cfg, false, false
+	s.detectVectorFeatures(ctx)
+	var installed bool
+	if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector')").Scan(&installed); err != nil {
+		return err
+	}
+	if !installed {
+		return ErrNoPgvector
+	}
+	dims, err := s.vectorColumnDims(ctx, "embeddings")
+	if err != nil {
+		return err
+	}
+	switch {
+	case dims == 0:
+		return errors.New("embeddings.embedding is missing; pgvector was installed after migrating, so add a migration that repeats 006_pgvector_embeddings")
+	case dims != cfg.Dimensions:
+		return fmt.Errorf("embeddings.embedding is vector(%d) but %d dimensions are configured", dims, cfg.Dimensions)
+	}
+	s.ann = true
+	ok, err := s.vectorIndexExists(ctx, cfg.Metric.opclass())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no HNSW index with %s on embeddings.embedding; migration 017 creates it", cfg.Metric.opclass())
+	}
+	s.indexed = true
+	return nil
+}
+
+// VectorSearch describes how similarity search runs: the pgvector metric and
+// index in use, or "brute force" when pgvector is unavailable.
+func (s *PostgresStore) VectorSearch() string {
+	if !s.ann {
+		return "brute force"
+	}
+	index := "HNSW index"
+	if !s.indexed {
+		index = "no index"
+	}
+	return fmt.Sprintf("pgvector vector(%d), %s distance, %s", s.vec.Dimensions, s.vec.Metric, index)
+}
+
+// useANN reports whether vec can be searched through the pgvector column.
+func (s *PostgresStore) useANN(vec []float32) bool {
+	return s.ann && len(vec) == s.vec.Dimensions
+}
+
+// nearest returns the ORDER BY expression ranking column by distance m to param,
+// and whether the query must run with iterative scans. An index scan stops after
+// hnsw.ef_search candidates, so with a filter it would return
+// only the few of them that match. Filtered searches therefore use iterative HNSW
+// scans where pgvector supports them, and otherwise the distance function, which
+// the planner cannot serve from the index, so the matching rows are ranked exactly.
+func (s *PostgresStore) nearest(column, param string, m DistanceMetric, indexed, filtered bool) (string, bool) {
+	if filtered && !(s.iterative && indexed) {
+		return m.function() + "(" + column + ", " + param + ")", false
+	}
+	return column + " " + m.operator() + " " + param, filtered
+}
+
+// queryVectors runs query and calls scan for each row. Iterative queries run in a
+// transaction with hnsw.iterative_scan set, so a filtered HNSW scan keeps going
+// until enough rows match (up to hnsw.max_scan_tuples).
+func (s *PostgresStore) queryVectors(ctx context.Context, iterative bool, query string, args []interface{}, scan func(pgx.Rows) error) error {
+	run := func(rows pgx.Rows, err error) error {
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+	if !iterative {
+		return run(s.pool.Query(ctx, query, args...))
+	}
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SET LOCAL hnsw.iterative_scan = strict_order"); err != nil {
+			return err
+		}
+		return run(tx.Query(ctx, query, args...))
+	})
+}
+
+// SaveEmbedding stores the vector of a message into the embeddings table. The
+// double precision[] copy is always kept for the brute-force fallback; the
+// pgvector column is filled when its dimensions match.
+func (s *PostgresStore) SaveEmbedding(ctx context.Context, conversationID, messageID string, v []float32) error {
+	if !s.ann {
+		// without pgvector there is no embedding column
+		_, err := s.pool.Exec(ctx, "INSERT INTO embeddings (conversation_id, message_id, vector) VALUES ($1, $2, $3)",
+			conversationID, messageID, toFloat64s(v))
+		return notFound(err)
+	}
+	var embedding *pgvector.Vector
+	if s.useANN(v) {
+		e := pgvector.NewVector(v)
+		embedding = &e
+	}
+	_, err := s.pool.Exec(ctx, "INSERT INTO embeddings (conversation_id, message_id, vector, embedding) VALUES ($1, $2, $3, $4)",
+		conversationID, messageID, toFloat64s(v), embedding)
+	return notFound(err)
+}
+
+// QuerySimilarMessages returns up to k message IDs closest to vector under the
+// configured metric, through the pgvector index when available.
+func (s *PostgresStore) QuerySimilarMessages(ctx context.Context, vector []float32, k int) ([]string, error) {
+	if s.useANN(vector) {
+		rows, err := s.pool.Query(ctx, "SELECT message_id::text FROM embeddings WHERE embedding IS NOT NULL ORDER BY embedding "+
+			s.vec.Metric.operator()+" $1 LIMIT $2", pgvector.NewVector(vector), k)
+		if err != nil {
+			return nil, err
+		}
+		return pgx.CollectRows(rows, pgx.RowTo[string])
+	}
+	rows, err := s.pool.Query(ctx, "SELECT message_id::text, vector FROM embeddings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	type scored struct {
+		id   string
+		dist float64
+	}
+	var all []scored
+	for rows.Next() {
+		var (
+			id     string
+			stored []float64
+		)
+		if err := rows.Scan(&id, &stored); err != nil {
+			return nil, err
+		}
+		all = append(all, scored{id: id, dist: s.vec.Metric.distance(vector, toFloat32s(stored))})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].dist < all[j].dist })
+	if k >= 0 && len(all) > k {
+		all = all[:k]
+	}
+	out := make([]string, 0, len(all))
+	for _, sc := range all {
+		out = append(out, sc.id)
+	}
+	return out, nil
+}
+
+// SearchMessages returns the best opts.K embedded messages matching opts. With
+// pgvector they are ranked by the configured metric in Postgres, through the index
+// unless a filter would truncate the index scan; otherwise every candidate is
+// scored in Go. Scores are cosine similarities either way.
+func (s *PostgresStore) SearchMessages(ctx context.Context, vec []float32, opts SearchOptions) ([]ScoredMessage, error) {
+	var conv, senderType, senderID *string
+	if opts.ConversationID != "" {
+		conv = &opts.ConversationID
+	}
+	if opts.SenderType != "" {
+		senderType = &opts.SenderType
+	}
+	if opts.SenderID != "" {
+		senderID = &opts.SenderID
+	}
+	var since, until *time.Time
+	if !opts.Since.IsZero() {
+		since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		until = &opts.Until
+	}
+	const filters = `
+		WHERE ($1::uuid IS NULL OR m.conversation_id = $1)
+		  AND ($2::text IS NULL OR m.sender_type = $2)
+		  AND ($3::text IS NULL OR m.sender_id = $3)
+		  AND ($4::timestamptz IS NULL OR m.created_at >= $4)
+		  AND ($5::timestamptz IS NULL OR m.created_at <= $5)`
+	args := []interface{}{conv, senderType, senderID, since, until}
+	ann := s.useANN(vec)
+	var query string
+	iterative := false
+	if ann {
+		var orderBy string
+		orderBy, iterative = s.nearest("e.embedding", "$6", s.vec.Metric, s.indexed, opts.filtered())
+		// fetch extra rows so messages embedded twice do not crowd out others
+		query = "SELECT " + messageColumns + `, 1 - (e.embedding <=> $6)
+			FROM embeddings e JOIN messages m ON m.id = e.message_id` + filters + `
+			  AND e.embedding IS NOT NULL
+			ORDER BY ` + orderBy + ` LIMIT $7`
+		args = append(args, pgvector.NewVector(vec), 2*opts.limit())
+	} else {
+		query = "SELECT " + messageColumns + `, e.vector
+			FROM embeddings e JOIN messages m ON m.id = e.message_id` + filters
+	}
+	var (
+		hits []ScoredMessage
+		dist []float64 // brute force: distance of each hit under the configured metric
+	)
+	err := s.queryVectors(ctx, iterative, query, args, func(rows pgx.Rows) error {
+		var h ScoredMessage
+		if ann {
+			if err := scanMessage(rows, &h.Message, &h.Score); err != nil {
+				return err
+			}
+		} else {
+			var stored []float64
+			if err := scanMessage(rows, &h.Message, &stored); err != nil {
+				return err
+			}
+			v := toFloat32s(stored)
+			h.Score = cosineSimilarity(vec, v)
+			dist = append(dist, s.vec.Metric.distance(vec, v))
+		}
+		hits = append(hits, h)
+		return nil
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if !ann {
+		order := make([]int, len(hits))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return dist[order[i]] < dist[order[j]] })
+		ranked := make([]ScoredMessage, len(hits))
+		for i, idx := range order {
+			ranked[i] = hits[idx]
+		}
+		hits = ranked
+	}
+	return dedupeScored(hits, opts.limit()), nil
+}
+
+// toFloat64s converts v for the double precision[] column.
+func toFloat64s(v []float32) []float64 {
+	out := make([]float64, len(v))
+	for i, f := range v {
+		out[i] = float64(f)
+	}
+	return out
+}
+
+// toFloat32s converts a double precision[] column value back to a vector.
+func toFloat32s(v []float64) []float32 {
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = float32(f)
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+package persistence_test
+
+import (
+	"testing"
+
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+func TestVectorConfigFromEnv(t *testing.T) {
+	t.Setenv("EMBEDDING_DIMENSIONS", "")
+	t.Setenv("VECTOR_METRIC", "")
+	cfg, err := persistence.VectorConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dimensions != 1536 || cfg.Metric != persistence.MetricCosine {
+		t.Fatalf("unexpected defaults %+v", cfg)
+	}
+
+	t.Setenv("EMBEDDING_DIMENSIONS", "256")
+	t.Setenv("VECTOR_METRIC", "inner_product")
+	if cfg, err = persistence.VectorConfigFromEnv(); err != nil || cfg.Dimensions != 256 || cfg.Metric != persistence.MetricInnerProduct {
+		t.Fatalf("unexpected config %+v (%v)", cfg, err)
+	}
+
+	t.Setenv("VECTOR_METRIC", "manhattan")
+	if _, err := persistence.VectorConfigFromEnv(); err == nil {
+		t.Fatal("expected error for unknown metric")
+	}
+}
@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// memorySubscriptionBuffer is how many payloads a slow subscriber may lag
+// behind before new payloads are dropped for it.
+const memorySubscriptionBuffer = 256
+
+// MemoryBroker is an in-process Broker for single-node deployments and tests.
+type MemoryBroker struct {
+	mu     sync.Mutex
+	subs   map[string]map[*memorySubscription]struct{}
+	closed bool
+}
+
+// NewMemoryBroker returns an empty in-process broker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[string]map[*memorySubscription]struct{})}
+}
+
+// Publish JSON-encodes v and delivers it to every current subscriber of channel.
+func (b *MemoryBroker) Publish(ctx context.Context, channel string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	for sub := range b.subs[channel] {
+		select {
+		case sub.ch <- string(payload):
+		default:
+			log.Printf("pubsub: subscriber on %s is full; dropping message", channel)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscription that receives payloads published after it returns.
+func (b *MemoryBroker) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	sub := &memorySubscription{
+		broker:  b,
+		channel: channel,
+		ch:      make(chan string, memorySubscriptionBuffer),
+	}
+	if b.subs[channel] == nil {
+		b.subs[channel] = make(map[*memorySubscription]struct{})
+	}
+	b.subs[channel][sub] = struct{}{}
+	return sub, nil
+}
+
+// Close closes every subscription; later Publish and Subscribe calls fail with ErrClosed.
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			close(sub.ch)
+		}
+	}
+	b.subs = nil
+	return nil
+}
+
+// memorySubscription satisfies Subscription (and ws.Subscriber).
+type memorySubscription struct {
+	broker  *MemoryBroker
+	channel string
+	ch      chan string
+}
+
+func (s *memorySubscription) Channel() <-chan string {
+	return s.ch
+}
+
+func (s *memorySubscription) Close() error {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if subs, ok := b.subs[s.channel]; ok {
+		if _, ok := subs[s]; ok {
+			delete(subs, s)
+			close(s.ch)
+			if len(subs) == 0 {
+				delete(b.subs, s.channel)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerFanOut(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx := context.Background()
+	s1, err := b.Subscribe(ctx, "conversation:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, _ := b.Subscribe(ctx, "conversation:1")
+	other, _ := b.Subscribe(ctx, "conversation:2")
+
+	if err := b.Publish(ctx, "conversation:1", map[string]string{"event": "message.created"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []Subscription{s1, s2} {
+		select {
+		case got := <-s.Channel():
+			if got != `{"event":"message.created"}` {
+				t.Fatalf("unexpected payload %s", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for payload")
+		}
+	}
+	select {
+	case got := <-other.Channel():
+		t.Fatalf("unexpected payload on other channel: %s", got)
+	default:
+	}
+
+	_ = s1.Close()
+	if _, ok := <-s1.Channel(); ok {
+		t.Fatal("expected closed channel after Close")
+	}
+	_ = s1.Close() // idempotent
+}
+
+func TestMemoryBrokerClose(t *testing.T) {
+	b := NewMemoryBroker()
+	s, _ := b.Subscribe(context.Background(), "c")
+	_ = b.Close()
+	if _, ok := <-s.Channel(); ok {
+		t.Fatal("expected subscription closed with broker")
+	}
+	_ = s.Close()
+	if err := b.Publish(context.Background(), "c", "x"); err != ErrClosed {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
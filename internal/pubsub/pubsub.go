@@ -3,11 +3,36 @@ package pubsub
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrClosed is returned when using a broker after Close.
+var ErrClosed = errors.New("pubsub: broker closed")
+
+// Subscription delivers the JSON payloads published on one channel.
+// Channel is closed once the subscription is closed.
+type Subscription interface {
+	Channel() <-chan string
+	Close() error
+}
+
+// Broker publishes JSON-encoded values on named channels and fans them out to subscribers.
+// RedisPubSub spans processes; MemoryBroker serves a single process.
+type Broker interface {
+	Publish(ctx context.Context, channel string, v interface{}) error
+	Subscribe(ctx context.Context, channel string) (Subscription, error)
+	Close() error
+}
+
+var (
+	_ Broker = (*RedisPubSub)(nil)
+	_ Broker = (*MemoryBroker)(nil)
+)
+
 // RedisPubSub is a minimal wrapper for publish/subscribe.
 type RedisPubSub struct {
 	client *redis.Client
@@ -34,9 +59,26 @@ func (r *RedisPubSub) Publish(ctx context.Context, channel string, v interface{}
 	return r.client.Publish(ctx, channel, b).Err()
 }
 
-// Subscribe returns a go-redis PubSub for the given channel.
-func (r *RedisPubSub) Subscribe(ctx context.Context, channel string) *redis.PubSub {
-	return r.client.Subscribe(ctx, channel)
+// Subscribe returns a Subscription for the given channel. It returns once
+// redis has confirmed the subscription, so no later publish is missed.
+func (r *RedisPubSub) Subscribe(ctx context.Context, channel string) (Subscription, error) {
+	ps := r.client.Subscribe(ctx, channel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("redis subscribe: %w", err)
+	}
+	sub := &redisSubscription{
+		pubsub: ps,
+		ch:     make(chan string, 16),
+		done:   make(chan struct{}),
+	}
+	go sub.forward()
+	return sub, nil
+}
+
+// Close closes the redis client.
+func (r *RedisPubSub) Close() error {
+	return r.client.Close()
 }
 
 // Client returns the underlying redis client for advanced usage.
@@ -44,47 +86,44 @@ func (r *RedisPubSub) Client() *redis.Client {
 	return r.client
 }
 
-// ------- adapter to provide simple string-channel Subscriber for ws package -------
-type redisSubscriberAdapter struct {
+// redisSubscription adapts a go-redis PubSub to Subscription.
+type redisSubscription struct {
 	pubsub *redis.PubSub
 	ch     chan string
 	done   chan struct{}
+	once   sync.Once
 }
 
-func (a *redisSubscriberAdapter) Channel() <-chan string {
-	return a.ch
+func (s *redisSubscription) Channel() <-chan string {
+	return s.ch
 }
 
-func (a *redisSubscriberAdapter) Close() error {
-	close(a.done)
-	_ = a.pubsub.Close()
-	close(a.ch)
-	return nil
+func (s *redisSubscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+	})
+	return err
 }
 
-// SubscribeAdapter returns a Subscriber that yields string payloads for the given channel.
-func (r *RedisPubSub) SubscribeAdapter(ctx context.Context, channel string) (interface{ Channel() <-chan string; Close() error }, error) {
-	ps := r.client.Subscribe(ctx, channel)
-	// create adapter
-	adapter := &redisSubscriberAdapter{
-		pubsub: ps,
-		ch:     make(chan string, 16),
-		done:   make(chan struct{}),
-	}
-	// forward messages
-	go func() {
-		ch := ps.Channel()
-		for {
+// forward copies payloads until the subscription is closed; it owns s.ch.
+func (s *redisSubscription) forward() {
+	defer close(s.ch)
+	ch := s.pubsub.Channel()
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
 			select {
-			case <-adapter.done:
+			case s.ch <- msg.Payload:
+			case <-s.done:
 				return
-			case msg, ok := <-ch:
-				if !ok {
-					return
-				}
-				adapter.ch <- msg.Payload
 			}
 		}
-	}()
-	return adapter, nil
+	}
 }
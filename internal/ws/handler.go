@@ -2,8 +2,6 @@ package ws
 
 import (
 	"context"
-	"encoding/json"
-	"log"
 	"strings"
 	"net/http"
 	"os"
@@ -11,14 +9,46 @@ import (
 	"nhooyr.io/websocket"
 	"nhooyr.io/websocket/wsjson"
 
+	"github.com/yourname/multiagent-social/internal/api"
+	"github.com/yourname/multiagent-social/internal/events"
 	"github.com/yourname/multiagent-social/internal/orchestrator"
 	"github.com/yourname/multiagent-social/internal/persistence"
 	"github.com/yourname/multiagent-social/internal/pubsub"
 )
 
+// socketUser authenticates a socket request and returns who it acts for. A
+// signed token (Authorization bearer, X-WS-Token header or token query
+// parameter) names the user by its subject. When AUTH_TOKEN is set, the
+// shared token admits anonymous sockets; without AUTH_TOKEN sockets may
+// connect anonymously. Anonymous sockets only follow the conversation. ok is
+// false for a token that is neither.
+func socketUser(r *http.Request) (userID string, ok bool) {
+	token := r.URL.Query().Get("token")
+	if h := r.Header.Get("X-WS-Token"); h != "" {
+		// header takes precedence
+		token = h
+	}
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		token = h[7:]
+	}
+	required := os.Getenv("AUTH_TOKEN")
+	switch {
+	case token == "":
+		return "", required == ""
+	case required != "" && token == required:
+		return "", true
+	}
+	sub, err := api.VerifyToken(token)
+	return sub, err == nil
+}
+
 // HandleConversationWS returns an HTTP handler that upgrades to WebSocket
-// and subscribes to Redis pubsub for conversation events. It also replays recent history.
-func HandleConversationWS(orch *orchestrator.Orchestrator, ps *pubsub.RedisPubSub, store *persistence.PostgresStore) http.HandlerFunc {
+// and subscribes to the broker for conversation events. Logged events after the
+// last_event_id (or since) query parameter are replayed first; without one a
+// signed-in user resumes after the last event it acknowledged, and otherwise
+// the latest page of the log is replayed (see InitialPageSize). Clients send Command
+// frames over the same socket.
+func HandleConversationWS(orch *orchestrator.Orchestrator, broker pubsub.Broker, store persistence.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Expect path: /ws/conversations/{id}
 		convID := strings.TrimPrefix(r.URL.Path, "/ws/conversations/")
@@ -27,17 +57,16 @@ func HandleConversationWS(orch *orchestrator.Orchestrator, ps *pubsub.RedisPubSu
 			http.Error(w, "missing conversation id", http.StatusBadRequest)
 			return
 		}
-		// simple token auth (optional)
-		token := r.URL.Query().Get("token")
-		if expected := r.Header.Get("X-WS-Token"); expected != "" {
-			// header takes precedence
-			token = expected
+		userID, ok := socketUser(r)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
 		}
-		if required := os.Getenv("AUTH_TOKEN"); required != "" {
-			if token == "" || token != required {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
-				return
-			}
+
+		since, err := startPoint(r.Context(), r, store, convID, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		c, err := websocket.Accept(w, r, &websocket.AcceptOptions{
@@ -49,69 +78,54 @@ func HandleConversationWS(orch *orchestrator.Orchestrator, ps *pubsub.RedisPubSu
 		}
 		defer c.Close(websocket.StatusNormalClosure, "")
 
-		// subscribe to redis events for this conversation
-		redisClient := ps.Client()
-		ch := redisClient.Subscribe(r.Context(), "conversation:"+convID)
-		defer ch.Close()
-
-		// send recent history to the client
-		if store != nil {
-			if msgs, err := store.GetConversationMessages(r.Context(), convID); err == nil {
-				for _, m := range msgs {
-					_ = wsjson.Write(r.Context(), c, map[string]interface{}{
-						"event":   "history",
-						"content": m,
-					})
-				}
-			}
+		// subscribe before replaying so nothing published meanwhile is missed
+		sub, err := broker.Subscribe(r.Context(), events.Channel(convID))
+		if err != nil {
+			c.Close(websocket.StatusInternalError, "subscribe failed")
+			return
 		}
+		defer sub.Close()
 
-		// read incoming (optional)
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		sess := &session{orch: orch, broker: broker, store: store, convID: convID, userID: userID}
+
+		// read client commands in order and answer each with a reply or error frame
 		go func() {
+			defer cancel()
 			for {
-				var v interface{}
-				if err := wsjson.Read(r.Context(), c, &v); err != nil {
+				_, frame, err := c.Read(ctx)
+				if err != nil {
+					return
+				}
+				if err := wsjson.Write(ctx, c, sess.handleFrame(ctx, frame)); err != nil {
 					return
 				}
-				// ignore incoming messages in this MVP
-				_ = v
 			}
 		}()
 
 		// heartbeat: send ping events periodically
-		pingCtx, pingCancel := context.WithCancel(r.Context())
 		go func() {
 			t := time.NewTicker(30 * time.Second)
 			defer t.Stop()
 			for {
 				select {
-				case <-pingCtx.Done():
+				case <-ctx.Done():
 					return
 				case <-t.C:
-					_ = wsjson.Write(r.Context(), c, map[string]interface{}{
-						"event": "ping",
-						"ts":    time.Now().UTC().String(),
-					})
+					evt, _ := events.New(events.TypePing, convID, nil, nil)
+					_ = wsjson.Write(ctx, c, evt)
 				}
 			}
 		}()
-		defer pingCancel()
 
-		// forward redis messages to websocket
-		for {
-			msg, ok := <-ch.Channel()
-			if !ok {
-				return
+		// replay the event log after the client's resume point, then forward live events
+		_ = ForwardEvents(ctx, store, sub, convID, since, func(evt *events.Event) error {
+			// recorded first, so the client cannot ack an event before it counts as sent
+			if evt.Seq > 0 {
+				sess.sent.Store(evt.Seq)
 			}
-			var evt map[string]interface{}
-			if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
-				log.Printf("ws: unmarshal err: %v", err)
-				continue
-			}
-			if err := wsjson.Write(r.Context(), c, evt); err != nil {
-				return
-			}
-		}
+			return wsjson.Write(ctx, c, evt)
+		})
 	}
 }
-
@@ -4,21 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/pubsub"
 )
 
-// MessageEvent is a generic event structure used over pubsub.
-type MessageEvent struct {
-	Event   string `json:"event"`
-	Sender  string `json:"sender,omitempty"`
-	Content string `json:"content,omitempty"`
-}
+// MessageEvent is the event envelope carried over pubsub; see package events.
+type MessageEvent = events.Event
 
-// StartSubscriber listens on Redis pubsub channel and sends to provided handler.
-// Subscriber is a minimal interface for pubsub subscribers used by ws package.
-type Subscriber interface {
-	Channel() <-chan string
-	Close() error
-}
+// Subscriber is a minimal interface for pubsub subscribers used by ws package;
+// every pubsub.Broker subscription satisfies it.
+type Subscriber = pubsub.Subscription
 
 // StartSubscriber listens on a generic Subscriber (string payloads) and sends parsed events to handler.
 func StartSubscriber(ctx context.Context, sub Subscriber, handler func(MessageEvent)) {
@@ -0,0 +1,319 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/orchestrator"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+)
+
+// Commands a client may send over the conversation socket.
+const (
+	CommandPostMessage = "post_message"
+	CommandStartDebate = "start_debate"
+	CommandStopDebate  = "stop_debate"
+	CommandTyping      = "typing"
+	CommandHistory     = "history"
+	CommandAck         = "ack"
+	CommandCreatePoll  = "create_poll"
+	CommandVote        = "vote"
+
+	CommandStartAutonomous = "start_autonomous"
+	CommandStopAutonomous  = "stop_autonomous"
+)
+
+// Reply frame events and error codes.
+const (
+	EventReply = "reply"
+	EventError = "error"
+
+	CodeBadRequest     = "bad_request"
+	CodeUnknownCommand = "unknown_command"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeUnauthorized   = "unauthorized"
+	CodeInternal       = "internal"
+)
+
+// Page sizes for the history command.
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = replayPageSize
+)
+
+// Command is a client-to-server frame. ID is echoed in the reply so clients can
+// correlate it; Data holds the command's arguments.
+type Command struct {
+	ID      string          `json:"id"`
+	Command string          `json:"command"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Reply answers one Command: Event is "reply" with Data on success and "error"
+// with Error otherwise.
+type Reply struct {
+	Event string      `json:"event"`
+	ID    string      `json:"id"`
+	Data  interface{} `json:"data,omitempty"`
+	Error *ReplyError `json:"error,omitempty"`
+}
+
+// ReplyError describes why a command failed.
+type ReplyError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ReplyError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func badRequest(format string, args ...interface{}) *ReplyError {
+	return &ReplyError{Code: CodeBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+// userCommands act on behalf of the socket's user or change the conversation,
+// so anonymous sockets, which only follow it, may not send them.
+var userCommands = map[string]bool{
+	CommandPostMessage:     true,
+	CommandTyping:          true,
+	CommandCreatePoll:      true,
+	CommandVote:            true,
+	CommandAck:             true,
+	CommandStartDebate:     true,
+	CommandStopDebate:      true,
+	CommandStartAutonomous: true,
+	CommandStopAutonomous:  true,
+}
+
+// session is the server side of one conversation socket.
+type session struct {
+	orch   *orchestrator.Orchestrator
+	broker pubsub.Broker
+	store  persistence.Store
+	convID string
+	userID string       // subject of the socket's token; empty on anonymous sockets
+	sent   atomic.Int64 // highest seq delivered to the client
+}
+
+// handleFrame decodes a client frame, runs it and returns the reply to send.
+func (s *session) handleFrame(ctx context.Context, frame []byte) *Reply {
+	var cmd Command
+	if err := json.Unmarshal(frame, &cmd); err != nil {
+		return &Reply{Event: EventError, Error: badRequest("invalid frame: %v", err)}
+	}
+	data, err := s.handle(ctx, cmd)
+	if err != nil {
+		var re *ReplyError
+		if !errors.As(err, &re) {
+			re = &ReplyError{Code: CodeInternal, Message: err.Error()}
+		}
+		return &Reply{Event: EventError, ID: cmd.ID, Error: re}
+	}
+	return &Reply{Event: EventReply, ID: cmd.ID, Data: data}
+}
+
+func (s *session) handle(ctx context.Context, cmd Command) (interface{}, error) {
+	// commands speaking for the user need to know who that is
+	if s.userID == "" && userCommands[cmd.Command] {
+		return nil, &ReplyError{Code: CodeUnauthorized, Message: cmd.Command + " needs a signed-in user"}
+	}
+	switch cmd.Command {
+	case CommandPostMessage:
+		var args struct {
+			Content string `json:"content"`
+			ReplyTo string `json:"reply_to"`
+		}
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(args.Content) == "" {
+			return nil, badRequest("content is required")
+		}
+		id, err := s.orch.HandleUserReply(ctx, s.convID, s.userID, args.Content, args.ReplyTo)
+		if errors.Is(err, orchestrator.ErrInvalidReply) {
+			return nil, badRequest("%v", err)
+		}
+		if errors.Is(err, persistence.ErrNotFound) {
+			return nil, &ReplyError{Code: CodeNotFound, Message: "conversation not found"}
+		}
+		if errors.Is(err, orchestrator.ErrConversationClosed) {
+			return nil, &ReplyError{Code: CodeConflict, Message: err.Error()}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"message_id": id}, nil
+
+	case CommandStartDebate:
+		var args orchestrator.DebateOptions
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		d, err := s.orch.StartDebateAsync(ctx, s.convID, args)
+		if errors.Is(err, orchestrator.ErrDebateRunning) || errors.Is(err, orchestrator.ErrAutonomousRunning) || errors.Is(err, orchestrator.ErrConversationInactive) {
+			return nil, &ReplyError{Code: CodeConflict, Message: err.Error()}
+		}
+		if err != nil {
+			return nil, badRequest("%v", err)
+		}
+		return map[string]interface{}{"started": true, "debate_id": d.ID}, nil
+
+	case CommandStopDebate:
+		if err := s.orch.StopDebate(s.convID); err != nil {
+			return nil, &ReplyError{Code: CodeNotFound, Message: err.Error()}
+		}
+		return map[string]bool{"stopped": true}, nil
+
+	case CommandStartAutonomous:
+		var args orchestrator.AutonomousOptions
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		run, err := s.orch.StartAutonomous(ctx, s.convID, args)
+		if errors.Is(err, orchestrator.ErrDebateRunning) || errors.Is(err, orchestrator.ErrAutonomousRunning) || errors.Is(err, orchestrator.ErrConversationInactive) {
+			return nil, &ReplyError{Code: CodeConflict, Message: err.Error()}
+		}
+		if err != nil {
+			return nil, badRequest("%v", err)
+		}
+		return map[string]interface{}{"started": true, "run_id": run.ID}, nil
+
+	case CommandStopAutonomous:
+		if err := s.orch.StopAutonomous(s.convID); err != nil {
+			return nil, &ReplyError{Code: CodeNotFound, Message: err.Error()}
+		}
+		return map[string]bool{"stopped": true}, nil
+
+	case CommandTyping:
+		var args events.TypingPayload
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		// typing indicators are ephemeral: published to live subscribers, never logged
+		evt, err := events.New(events.TypeTyping, s.convID, &events.Sender{Type: events.SenderUser, ID: s.userID, Name: s.userID}, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.broker.Publish(ctx, events.Channel(s.convID), evt); err != nil {
+			return nil, err
+		}
+		return map[string]bool{"typing": args.Typing}, nil
+
+	case CommandHistory:
+		var args struct {
+			BeforeSeq int64 `json:"before_seq"`
+			Limit     int   `json:"limit"`
+		}
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		return s.history(ctx, args.BeforeSeq, args.Limit)
+
+	case CommandAck:
+		var args struct {
+			Seq int64 `json:"seq"`
+		}
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		if args.Seq <= 0 || args.Seq > s.sent.Load() {
+			return nil, badRequest("seq %d has not been delivered", args.Seq)
+		}
+		// the next socket of this user resumes after the acknowledged event
+		if err := s.store.AckEvents(ctx, s.convID, s.userID, args.Seq); err != nil {
+			return nil, err
+		}
+		return map[string]int64{"acked": args.Seq}, nil
+
+	case CommandCreatePoll:
+		var args orchestrator.PollOptions
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		p, err := s.orch.CreatePoll(ctx, s.convID, s.userID, args)
+		return p, pollError(err)
+
+	case CommandVote:
+		var args struct {
+			PollID string `json:"poll_id"`
+			Option *int   `json:"option"`
+		}
+		if err := decodeArgs(cmd, &args); err != nil {
+			return nil, err
+		}
+		if args.PollID == "" || args.Option == nil {
+			return nil, badRequest("poll_id and option are required")
+		}
+		// only polls of this socket's conversation can be voted in
+		if p, err := s.orch.Poll(ctx, args.PollID); err != nil || p.ConversationID != s.convID {
+			return nil, &ReplyError{Code: CodeNotFound, Message: "poll not found"}
+		}
+		p, err := s.orch.Vote(ctx, args.PollID, s.userID, *args.Option)
+		return p, pollError(err)
+	}
+	return nil, &ReplyError{Code: CodeUnknownCommand, Message: fmt.Sprintf("unknown command %q", cmd.Command)}
+}
+
+// pollError maps poll errors onto reply codes; nil stays nil.
+func pollError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, orchestrator.ErrInvalidPoll), errors.Is(err, orchestrator.ErrInvalidVote):
+		return badRequest("%v", err)
+	case errors.Is(err, persistence.ErrPollClosed), errors.Is(err, orchestrator.ErrConversationClosed):
+		return &ReplyError{Code: CodeConflict, Message: err.Error()}
+	case errors.Is(err, persistence.ErrNotFound):
+		return &ReplyError{Code: CodeNotFound, Message: "not found"}
+	}
+	return err
+}
+
+// history returns up to limit logged events immediately before beforeSeq, oldest first.
+// Sequence numbers are gap-free, so the page is simply the range just below beforeSeq.
+func (s *session) history(ctx context.Context, beforeSeq int64, limit int) (interface{}, error) {
+	if beforeSeq <= 0 {
+		return nil, badRequest("before_seq is required")
+	}
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	after := beforeSeq - 1 - int64(limit)
+	if after < 0 {
+		after = 0
+	}
+	page := []events.Event{}
+	if n := int(beforeSeq - 1 - after); n > 0 && s.store != nil {
+		evts, err := s.store.ListEvents(ctx, s.convID, after, n)
+		if err != nil {
+			return nil, err
+		}
+		page = append(page, evts...)
+	}
+	return map[string]interface{}{
+		"events":   page,
+		"has_more": after > 0,
+	}, nil
+}
+
+// decodeArgs unmarshals cmd.Data into v; absent data leaves v zero.
+func decodeArgs(cmd Command, v interface{}) error {
+	if len(cmd.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(cmd.Data, v); err != nil {
+		return badRequest("invalid %s data: %v", cmd.Command, err)
+	}
+	return nil
+}
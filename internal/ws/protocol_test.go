@@ -0,0 +1,301 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+
+	"github.com/yourname/multiagent-social/internal/api"
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/orchestrator"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+)
+
+// frame is any server-to-client frame: an event envelope or a reply.
+type frame struct {
+	Event string          `json:"event"`
+	ID    string          `json:"id"`
+	Seq   int64           `json:"seq"`
+	Data  json.RawMessage `json:"data"`
+	// Payload is set on event frames.
+	Payload json.RawMessage `json:"payload"`
+	Sender  *events.Sender  `json:"sender"`
+	Error   *ReplyError     `json:"error"`
+}
+
+// dialConversation opens a socket on a new conversation signed in as u1.
+func dialConversation(t *testing.T, ctx context.Context) *websocket.Conn {
+	t.Helper()
+	c, _ := dialWith(t, ctx, "?token="+userToken(t, "u1"))
+	return c
+}
+
+// userToken signs a token for subject.
+func userToken(t *testing.T, subject string) string {
+	t.Helper()
+	token, err := api.GenerateToken(subject, "user", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// dialWith opens a socket on a new conversation, adding query to the URL, and
+// returns it with the orchestrator serving it.
+func dialWith(t *testing.T, ctx context.Context, query string) (*websocket.Conn, *orchestrator.Orchestrator) {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("AUTH_TOKEN", "")
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	t.Cleanup(func() { _ = broker.Close() })
+	orch := orchestrator.NewOrchestrator(store, broker, nil)
+	convID, _ := orch.CreateConversation(ctx, "ws", nil, nil)
+	srv := httptest.NewServer(HandleConversationWS(orch, broker, store))
+	t.Cleanup(srv.Close)
+	c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/conversations/"+convID+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close(websocket.StatusNormalClosure, "") })
+	return c, orch
+}
+
+func send(t *testing.T, ctx context.Context, c *websocket.Conn, raw string) {
+	t.Helper()
+	if err := c.Write(ctx, websocket.MessageText, []byte(raw)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readUntil reads frames until one matches, failing after the context deadline.
+func readUntil(t *testing.T, ctx context.Context, c *websocket.Conn, match func(frame) bool) frame {
+	t.Helper()
+	for {
+		_, b, err := c.Read(ctx)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		var f frame
+		if err := json.Unmarshal(b, &f); err != nil {
+			t.Fatalf("bad frame %s: %v", b, err)
+		}
+		if match(f) {
+			return f
+		}
+	}
+}
+
+func replyTo(id string) func(frame) bool {
+	return func(f frame) bool { return (f.Event == EventReply || f.Event == EventError) && f.ID == id }
+}
+
+func TestPostMessageCommand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	c := dialConversation(t, ctx)
+
+	send(t, ctx, c, `{"id":"r1","command":"post_message","data":{"content":"hello"}}`)
+	reply := readUntil(t, ctx, c, replyTo("r1"))
+	if reply.Event != EventReply || !strings.Contains(string(reply.Data), "message_id") {
+		t.Fatalf("unexpected reply %+v", reply)
+	}
+	readUntil(t, ctx, c, func(f frame) bool { return f.Event == events.TypeMessageCreated })
+
+	send(t, ctx, c, `{"id":"r2","command":"post_message","data":{"content":"  "}}`)
+	if f := readUntil(t, ctx, c, replyTo("r2")); f.Error == nil || f.Error.Code != CodeBadRequest {
+		t.Fatalf("expected bad_request, got %+v", f)
+	}
+	send(t, ctx, c, `{"id":"r4","command":"post_message","data":{"content":"re","reply_to":"00000000-0000-0000-0000-000000000000"}}`)
+	if f := readUntil(t, ctx, c, replyTo("r4")); f.Error == nil || f.Error.Code != CodeBadRequest {
+		t.Fatalf("expected bad_request for an unknown reply_to, got %+v", f)
+	}
+	send(t, ctx, c, `{"id":"r3","command":"dance"}`)
+	if f := readUntil(t, ctx, c, replyTo("r3")); f.Error == nil || f.Error.Code != CodeUnknownCommand {
+		t.Fatalf("expected unknown_command, got %+v", f)
+	}
+	send(t, ctx, c, `not json`)
+	if f := readUntil(t, ctx, c, replyTo("")); f.Error == nil || f.Error.Code != CodeBadRequest {
+		t.Fatalf("expected bad_request for invalid frame, got %+v", f)
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	store := persistence.NewMemoryStore()
+	convID, _ := store.CreateConversation(ctx, "history")
+	for _, content := range []string{"one", "two", "three", "four"} {
+		logEvent(t, store, convID, content)
+	}
+	sess := &session{store: store, convID: convID}
+
+	reply := sess.handleFrame(ctx, []byte(`{"id":"h","command":"history","data":{"before_seq":4,"limit":2}}`))
+	data, _ := json.Marshal(reply.Data)
+	var page struct {
+		Events  []events.Event `json:"events"`
+		HasMore bool           `json:"has_more"`
+	}
+	_ = json.Unmarshal(data, &page)
+	if len(page.Events) != 2 || page.Events[0].Seq != 2 || page.Events[1].Seq != 3 || !page.HasMore {
+		t.Fatalf("expected seq 2-3 with more, got %s", data)
+	}
+}
+
+func TestAckCommand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("AUTH_TOKEN", "")
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	defer broker.Close()
+	orch := orchestrator.NewOrchestrator(store, broker, nil)
+	convID, _ := orch.CreateConversation(ctx, "acks", nil, nil)
+	for _, content := range []string{"one", "two", "three"} {
+		if _, err := orch.HandleUserMessage(ctx, convID, "u2", content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := httptest.NewServer(HandleConversationWS(orch, broker, store))
+	defer srv.Close()
+	dial := func() *websocket.Conn {
+		c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/conversations/"+convID+"?token="+userToken(t, "u1"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	messages := func(f frame) bool { return f.Event == events.TypeMessageCreated }
+
+	c := dial()
+	readUntil(t, ctx, c, messages)
+	second := readUntil(t, ctx, c, messages)
+	send(t, ctx, c, `{"id":"a1","command":"ack","data":{"seq":99}}`)
+	if f := readUntil(t, ctx, c, replyTo("a1")); f.Error == nil || f.Error.Code != CodeBadRequest {
+		t.Fatalf("expected bad_request acking an undelivered event, got %+v", f)
+	}
+	send(t, ctx, c, `{"id":"a2","command":"ack","data":{"seq":`+strconv.FormatInt(second.Seq, 10)+`}}`)
+	if f := readUntil(t, ctx, c, replyTo("a2")); f.Event != EventReply {
+		t.Fatalf("unexpected ack reply %+v", f)
+	}
+	c.Close(websocket.StatusNormalClosure, "")
+
+	// the next socket resumes after the acknowledged event
+	c = dial()
+	defer c.Close(websocket.StatusNormalClosure, "")
+	if f := readUntil(t, ctx, c, messages); f.Seq != second.Seq+1 {
+		t.Fatalf("expected the replay to resume at seq %d, got %+v", second.Seq+1, f)
+	}
+}
+
+func TestPollCommands(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	c, orch := dialWith(t, ctx, "?token="+userToken(t, "u1"))
+
+	send(t, ctx, c, `{"id":"p1","command":"create_poll","data":{"question":"tea or coffee?","options":["tea","coffee"]}}`)
+	reply := readUntil(t, ctx, c, replyTo("p1"))
+	var poll persistence.Poll
+	if err := json.Unmarshal(reply.Data, &poll); err != nil || reply.Event != EventReply || poll.ID == "" {
+		t.Fatalf("unexpected reply %+v (%v)", reply, err)
+	}
+	readUntil(t, ctx, c, func(f frame) bool { return f.Event == events.TypePollCreated })
+
+	send(t, ctx, c, `{"id":"v1","command":"vote","data":{"poll_id":"`+poll.ID+`","option":1}}`)
+	if f := readUntil(t, ctx, c, replyTo("v1")); f.Event != EventReply || json.Unmarshal(f.Data, &poll) != nil || poll.Counts[1] != 1 {
+		t.Fatalf("unexpected vote reply %+v", f)
+	}
+	var tally events.PollPayload
+	if f := readUntil(t, ctx, c, func(f frame) bool { return f.Event == events.TypePollVoted }); json.Unmarshal(f.Payload, &tally) != nil || tally.Total != 1 {
+		t.Fatalf("unexpected tally %s", f.Payload)
+	}
+
+	send(t, ctx, c, `{"id":"v2","command":"vote","data":{"poll_id":"`+poll.ID+`","option":5}}`)
+	if f := readUntil(t, ctx, c, replyTo("v2")); f.Error == nil || f.Error.Code != CodeBadRequest {
+		t.Fatalf("expected bad_request, got %+v", f)
+	}
+	send(t, ctx, c, `{"id":"v3","command":"vote","data":{"poll_id":"nope","option":0}}`)
+	if f := readUntil(t, ctx, c, replyTo("v3")); f.Error == nil || f.Error.Code != CodeNotFound {
+		t.Fatalf("expected not_found, got %+v", f)
+	}
+
+	// polls of other conversations are out of the socket's reach
+	other, _ := orch.CreateConversation(ctx, "other", nil, nil)
+	foreign, err := orch.CreatePoll(ctx, other, "u2", orchestrator.PollOptions{Question: "elsewhere?", Options: []string{"yes", "no"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(t, ctx, c, `{"id":"v4","command":"vote","data":{"poll_id":"`+foreign.ID+`","option":0}}`)
+	if f := readUntil(t, ctx, c, replyTo("v4")); f.Error == nil || f.Error.Code != CodeNotFound {
+		t.Fatalf("expected not_found voting in another conversation, got %+v", f)
+	}
+	if p, _ := orch.Poll(ctx, foreign.ID); p.Counts[0] != 0 {
+		t.Fatalf("vote counted in another conversation: %+v", p)
+	}
+}
+
+func TestAutonomousCommands(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	c := dialConversation(t, ctx)
+
+	// the conversation has nobody to talk
+	send(t, ctx, c, `{"id":"a1","command":"start_autonomous","data":{"topic":"tea","max_turns":5}}`)
+	if f := readUntil(t, ctx, c, replyTo("a1")); f.Error == nil || f.Error.Code != CodeBadRequest {
+		t.Fatalf("expected bad_request, got %+v", f)
+	}
+	send(t, ctx, c, `{"id":"a2","command":"stop_autonomous"}`)
+	if f := readUntil(t, ctx, c, replyTo("a2")); f.Error == nil || f.Error.Code != CodeNotFound {
+		t.Fatalf("expected not_found, got %+v", f)
+	}
+}
+
+func TestSocketIdentity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// an anonymous socket follows the conversation but cannot speak for anyone
+	c, _ := dialWith(t, ctx, "?user_id=mallory")
+	send(t, ctx, c, `{"id":"r1","command":"post_message","data":{"content":"hello"}}`)
+	if f := readUntil(t, ctx, c, replyTo("r1")); f.Error == nil || f.Error.Code != CodeUnauthorized {
+		t.Fatalf("expected unauthorized, got %+v", f)
+	}
+	for _, command := range []string{"start_debate", "stop_debate", "start_autonomous", "stop_autonomous", "ack"} {
+		send(t, ctx, c, `{"id":"`+command+`","command":"`+command+`"}`)
+		if f := readUntil(t, ctx, c, replyTo(command)); f.Error == nil || f.Error.Code != CodeUnauthorized {
+			t.Fatalf("expected %s to be unauthorized, got %+v", command, f)
+		}
+	}
+	send(t, ctx, c, `{"id":"r2","command":"history","data":{"before_seq":1}}`)
+	if f := readUntil(t, ctx, c, replyTo("r2")); f.Event != EventReply {
+		t.Fatalf("expected history to work anonymously, got %+v", f)
+	}
+
+	// the token names the sender
+	c = dialConversation(t, ctx)
+	send(t, ctx, c, `{"id":"r3","command":"post_message","data":{"content":"hello"}}`)
+	readUntil(t, ctx, c, replyTo("r3"))
+	if f := readUntil(t, ctx, c, func(f frame) bool { return f.Event == events.TypeMessageCreated }); f.Sender == nil || f.Sender.ID != "u1" {
+		t.Fatalf("unexpected message %+v", f)
+	}
+
+	// an invalid token is refused rather than downgraded
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	defer broker.Close()
+	srv := httptest.NewServer(HandleConversationWS(orchestrator.NewOrchestrator(store, broker, nil), broker, store))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/conversations/c1?token="
+	if _, resp, err := websocket.Dial(ctx, url+"forged", nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a forged token, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+)
+
+// replayPageSize bounds how many logged events are read per query while catching up.
+const replayPageSize = 200
+
+// EventLog is the part of persistence.Store used to replay conversation events.
+type EventLog interface {
+	ListEvents(ctx context.Context, conversationID string, afterSeq int64, limit int) ([]events.Event, error)
+}
+
+// resumeParam returns the raw resume point of r, or "" when the client gave none.
+func resumeParam(r *http.Request) string {
+	raw := r.URL.Query().Get("last_event_id")
+	if raw == "" {
+		raw = r.URL.Query().Get("since")
+	}
+	if raw == "" {
+		raw = r.Header.Get("Last-Event-ID")
+	}
+	return raw
+}
+
+// ResumePoint reads the sequence number a client has already seen from the
+// last_event_id or since query parameter, or the SSE Last-Event-ID header.
+// It returns 0 (replay everything) when none is given.
+func ResumePoint(r *http.Request) (int64, error) {
+	raw := resumeParam(r)
+	if raw == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, fmt.Errorf("invalid event id %q", raw)
+	}
+	return seq, nil
+}
+
+// InitialPageSize is how many of the latest logged events a client without a
+// resume point is sent: the page_size query parameter, else WS_INITIAL_PAGE_SIZE,
+// else 50. 0 replays the whole log. Older events are fetched with the history command.
+func InitialPageSize(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("page_size")
+	if raw == "" {
+		raw = os.Getenv("WS_INITIAL_PAGE_SIZE")
+	}
+	if raw == "" {
+		return defaultHistoryLimit, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid page size %q", raw)
+	}
+	return n, nil
+}
+
+// startPoint returns the seq to replay after for a new connection: the client's
+// resume point, else the last event userID acknowledged with the ack command,
+// else just before the latest pageSize events.
+func startPoint(ctx context.Context, r *http.Request, store persistence.Store, conversationID, userID string) (int64, error) {
+	since, err := ResumePoint(r)
+	if err != nil || resumeParam(r) != "" {
+		return since, err
+	}
+	size, err := InitialPageSize(r)
+	if err != nil || store == nil {
+		return 0, err
+	}
+	if userID != "" {
+		if acked, err := store.EventAck(ctx, conversationID, userID); err == nil && acked > 0 {
+			return acked, nil
+		}
+	}
+	if size == 0 {
+		return 0, nil
+	}
+	c, err := store.GetConversation(ctx, conversationID)
+	if err != nil {
+		return 0, nil
+	}
+	return max(c.LastEventSeq-int64(size), 0), nil
+}
+
+// ForwardEvents sends a conversation's events in sequence order, starting after since.
+// sub must already be subscribed to the conversation: logged events are replayed first,
+// then live events are forwarded. Live events that were already replayed are dropped,
+// and gaps (events published out of order by another server, or dropped by the broker)
+// are filled from the log, so the client sees every event exactly once. Events without
+// a sequence number (e.g. pings) are forwarded as they arrive. It returns when ctx is
+// done, the subscription closes or send fails.
+func ForwardEvents(ctx context.Context, store EventLog, sub Subscriber, conversationID string, since int64, send func(*events.Event) error) error {
+	last := since
+	// catchUp sends logged events after last, up to and including upTo (0 = to the end).
+	catchUp := func(upTo int64) error {
+		if store == nil {
+			return nil
+		}
+		for {
+			page, err := store.ListEvents(ctx, conversationID, last, replayPageSize)
+			if err != nil {
+				return fmt.Errorf("replay events: %w", err)
+			}
+			for i := range page {
+				if upTo > 0 && page[i].Seq > upTo {
+					return nil
+				}
+				if err := send(&page[i]); err != nil {
+					return err
+				}
+				last = page[i].Seq
+			}
+			if len(page) < replayPageSize {
+				return nil
+			}
+		}
+	}
+	if err := catchUp(0); err != nil {
+		return err
+	}
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case payload, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var evt events.Event
+			if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+				log.Printf("ws: unmarshal err: %v", err)
+				continue
+			}
+			switch {
+			case evt.Seq == 0:
+				if err := send(&evt); err != nil {
+					return err
+				}
+			case evt.Seq <= last:
+				// already delivered from the log
+			case evt.Seq > last+1:
+				if err := catchUp(evt.Seq); err != nil {
+					return err
+				}
+				if last < evt.Seq {
+					// not in the log (yet); deliver it rather than stall
+					if err := send(&evt); err != nil {
+						return err
+					}
+					last = evt.Seq
+				}
+			default:
+				if err := send(&evt); err != nil {
+					return err
+				}
+				last = evt.Seq
+			}
+		}
+	}
+}
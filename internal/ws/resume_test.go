@@ -0,0 +1,123 @@
+package ws
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourname/multiagent-social/internal/events"
+	"github.com/yourname/multiagent-social/internal/persistence"
+	"github.com/yourname/multiagent-social/internal/pubsub"
+)
+
+// logEvent appends a message event to the store and returns it with its seq.
+func logEvent(t *testing.T, store persistence.Store, convID, content string) *events.Event {
+	t.Helper()
+	evt, _ := events.New(events.TypeMessageCreated, convID, nil, events.MessagePayload{Content: content})
+	if err := store.AppendEvent(context.Background(), evt); err != nil {
+		t.Fatal(err)
+	}
+	return evt
+}
+
+func TestForwardEventsResumesWithoutGapsOrDuplicates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	store := persistence.NewMemoryStore()
+	broker := pubsub.NewMemoryBroker()
+	defer broker.Close()
+	convID, _ := store.CreateConversation(ctx, "resume")
+	sub, _ := broker.Subscribe(ctx, events.Channel(convID))
+
+	for _, c := range []string{"one", "two", "three"} {
+		logEvent(t, store, convID, c)
+	}
+	// seq 3 is also published live, as if it raced with the replay
+	three, _ := store.ListEvents(ctx, convID, 2, 0)
+	_ = broker.Publish(ctx, events.Channel(convID), three[0])
+	// seq 4 is logged but its publish was lost; seq 5 arrives live
+	logEvent(t, store, convID, "four")
+	_ = broker.Publish(ctx, events.Channel(convID), logEvent(t, store, convID, "five"))
+	ping, _ := events.New(events.TypePing, convID, nil, nil)
+	_ = broker.Publish(ctx, events.Channel(convID), ping)
+
+	var got []int64
+	err := ForwardEvents(ctx, store, sub, convID, 1, func(evt *events.Event) error {
+		got = append(got, evt.Seq)
+		if evt.Type == events.TypePing {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	want := []int64{2, 3, 4, 5, 0}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestResumePoint(t *testing.T) {
+	r := httptest.NewRequest("GET", "/ws/conversations/c?last_event_id=7", nil)
+	if seq, err := ResumePoint(r); err != nil || seq != 7 {
+		t.Fatalf("expected 7, got %d, %v", seq, err)
+	}
+	r = httptest.NewRequest("GET", "/events/conversations/c", nil)
+	r.Header.Set("Last-Event-ID", "3")
+	if seq, err := ResumePoint(r); err != nil || seq != 3 {
+		t.Fatalf("expected 3, got %d, %v", seq, err)
+	}
+	r = httptest.NewRequest("GET", "/ws/conversations/c?since=abc", nil)
+	if _, err := ResumePoint(r); err == nil {
+		t.Fatal("expected error for non-numeric since")
+	}
+}
+
+func TestStartPointReplaysLatestPage(t *testing.T) {
+	t.Setenv("WS_INITIAL_PAGE_SIZE", "")
+	ctx := context.Background()
+	store := persistence.NewMemoryStore()
+	convID, _ := store.CreateConversation(ctx, "paging")
+	for i := 0; i < 80; i++ {
+		logEvent(t, store, convID, "event")
+	}
+	if err := store.AckEvents(ctx, convID, "u1", 40); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query string
+		env   string
+		user  string
+		want  int64
+	}{
+		{"", "", "", 30},                    // latest 50 by default
+		{"?page_size=10", "", "", 70},       // query parameter
+		{"", "5", "", 75},                   // environment
+		{"?page_size=0", "", "", 0},         // whole log
+		{"?page_size=500", "", "", 0},       // fewer events than a page
+		{"?last_event_id=12", "", "", 12},   // resume point wins
+		{"", "", "u1", 40},                  // after the user's ack
+		{"?page_size=10", "", "u2", 70},     // another user has not acked
+		{"?last_event_id=12", "", "u1", 12}, // resume point wins over the ack
+		{"?last_event_id=0&page_size=10", "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("WS_INITIAL_PAGE_SIZE", tt.env)
+		r := httptest.NewRequest("GET", "/ws/conversations/"+convID+tt.query, nil)
+		got, err := startPoint(ctx, r, store, convID, tt.user)
+		if err != nil || got != tt.want {
+			t.Errorf("%q (env %q, user %q): got %d, %v; want %d", tt.query, tt.env, tt.user, got, err, tt.want)
+		}
+	}
+	r := httptest.NewRequest("GET", "/ws/conversations/"+convID+"?page_size=-1", nil)
+	if _, err := startPoint(ctx, r, store, convID, ""); err == nil {
+		t.Error("expected an error for a negative page size")
+	}
+}
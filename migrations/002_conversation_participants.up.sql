@@ -0,0 +1,9 @@
+-- agents taking part in a conversation; only participants reply to messages
+CREATE TABLE IF NOT EXISTS conversation_participants (
+  conversation_id uuid REFERENCES conversations(id) ON DELETE CASCADE,
+  agent_id uuid REFERENCES agents(id) ON DELETE CASCADE,
+  joined_at timestamptz DEFAULT now(),
+  PRIMARY KEY (conversation_id, agent_id)
+);
+
+CREATE INDEX IF NOT EXISTS conversation_participants_agent_idx ON conversation_participants (agent_id);
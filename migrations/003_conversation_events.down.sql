@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS conversation_events;
+ALTER TABLE conversations DROP COLUMN IF EXISTS last_event_seq;
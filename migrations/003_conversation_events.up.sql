@@ -0,0 +1,33 @@
+-- durable, ordered log of conversation events; clients resume from a sequence number
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS last_event_seq bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS conversation_events (
+  conversation_id uuid NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  seq bigint NOT NULL,
+  type text NOT NULL,
+  version int NOT NULL,
+  message_id uuid,
+  sender_type text,
+  sender_id text,
+  sender_name text,
+  payload jsonb,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (conversation_id, seq)
+);
+
+-- backfill message.created events for conversations stored before the log existed
+INSERT INTO conversation_events (conversation_id, seq, type, version, message_id, sender_type, sender_id, sender_name, payload, created_at)
+SELECT m.conversation_id,
+       row_number() OVER (PARTITION BY m.conversation_id ORDER BY m.created_at, m.id),
+       'message.created', 1, m.id, m.sender_type, m.sender_id,
+       COALESCE(a.name, CASE WHEN m.sender_type = 'user' THEN m.sender_id END),
+       jsonb_build_object('content', m.content),
+       COALESCE(m.created_at, now())
+FROM messages m
+LEFT JOIN agents a ON m.sender_type = 'agent' AND a.id::text = m.sender_id
+WHERE m.conversation_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM conversation_events e WHERE e.conversation_id = m.conversation_id);
+
+UPDATE conversations c SET last_event_seq = e.max_seq
+FROM (SELECT conversation_id, max(seq) AS max_seq FROM conversation_events GROUP BY conversation_id) e
+WHERE c.id = e.conversation_id AND c.last_event_seq < e.max_seq;
@@ -0,0 +1,3 @@
+ALTER TABLE messages DROP COLUMN IF EXISTS metadata;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_to;
+ALTER TABLE messages DROP COLUMN IF EXISTS sender_name;
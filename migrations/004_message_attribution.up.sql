@@ -0,0 +1,10 @@
+-- messages carry who sent them by name, what they answer and free-form metadata
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS sender_name text;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to uuid REFERENCES messages(id) ON DELETE SET NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS metadata jsonb;
+
+-- backfill names for messages stored before the column existed
+UPDATE messages m SET sender_name = a.name
+FROM agents a
+WHERE m.sender_name IS NULL AND m.sender_type = 'agent' AND a.id::text = m.sender_id;
+UPDATE messages SET sender_name = sender_id WHERE sender_name IS NULL AND sender_type = 'user';
@@ -0,0 +1,15 @@
+-- long-term agent memories, carried across conversations
+CREATE TABLE IF NOT EXISTS agent_memories (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  agent_id uuid NOT NULL REFERENCES agents(id) ON DELETE CASCADE,
+  kind text NOT NULL,
+  subject text,
+  content text NOT NULL,
+  conversation_id uuid REFERENCES conversations(id) ON DELETE SET NULL,
+  source_message_id uuid REFERENCES messages(id) ON DELETE SET NULL,
+  -- same representation as embeddings.vector
+  vector double precision[],
+  created_at timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS agent_memories_agent_created_idx ON agent_memories (agent_id, created_at DESC);
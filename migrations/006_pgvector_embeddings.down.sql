@@ -0,0 +1,2 @@
+-- dropping the column also drops every index built on it; embeddings.vector keeps the data
+ALTER TABLE embeddings DROP COLUMN IF EXISTS embedding;
@@ -0,0 +1,18 @@
+-- store message embeddings in a real pgvector column with an HNSW index when
+-- the extension is available. The double precision[] column stays as the
+-- portable copy used by the brute-force fallback. vector(1536) matches the
+-- default EMBEDDING_DIMENSIONS. The server only detects the column: another
+-- size, or pgvector installed after this ran, needs a migration of its own.
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'vector') THEN
+    CREATE EXTENSION IF NOT EXISTS vector;
+    ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS embedding vector(1536);
+    UPDATE embeddings SET embedding = vector::vector(1536)
+      WHERE embedding IS NULL AND array_length(vector, 1) = 1536;
+    CREATE INDEX IF NOT EXISTS embeddings_embedding_cosine_hnsw_idx ON embeddings USING hnsw (embedding vector_cosine_ops);
+  ELSE
+    RAISE NOTICE 'pgvector is not available; similarity search uses the brute-force fallback';
+  END IF;
+END
+$$;
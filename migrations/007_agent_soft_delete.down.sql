@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS agents_live_idx;
+DELETE FROM agents WHERE deleted_at IS NOT NULL;
+ALTER TABLE agents DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+-- deleted agents keep their row so messages and memories stay attributed
+ALTER TABLE agents ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS agents_live_idx ON agents (created_at) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- conversation lifecycle: only active conversations take agent turns, closed and archived ones take no messages
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'active'
+  CHECK (status IN ('active', 'paused', 'closed', 'archived'));
@@ -0,0 +1,2 @@
+-- message history pages are read by conversation in (created_at, id) order
+CREATE INDEX IF NOT EXISTS messages_conversation_created_idx ON messages (conversation_id, created_at, id);
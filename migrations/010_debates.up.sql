@@ -0,0 +1,16 @@
+-- debate sessions run in the background; their progress is tracked here
+CREATE TABLE IF NOT EXISTS debates (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  conversation_id uuid NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  participants text[] NOT NULL,
+  rounds int NOT NULL,
+  state text NOT NULL,
+  round int NOT NULL DEFAULT 0,
+  speaker_id text,
+  error text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  ended_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS debates_conversation_created_idx ON debates (conversation_id, created_at DESC);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS debate_results;
+
+ALTER TABLE debates
+  DROP COLUMN IF EXISTS format,
+  DROP COLUMN IF EXISTS topic,
+  DROP COLUMN IF EXISTS moderator_id,
+  DROP COLUMN IF EXISTS judge_id,
+  DROP COLUMN IF EXISTS sides,
+  DROP COLUMN IF EXISTS phase;
@@ -0,0 +1,20 @@
+-- debate formats: how a debate is run, who moderates and judges it, and the judge's verdict
+ALTER TABLE debates
+  ADD COLUMN IF NOT EXISTS format text NOT NULL DEFAULT 'open',
+  ADD COLUMN IF NOT EXISTS topic text,
+  ADD COLUMN IF NOT EXISTS moderator_id text,
+  ADD COLUMN IF NOT EXISTS judge_id text,
+  ADD COLUMN IF NOT EXISTS sides jsonb,
+  ADD COLUMN IF NOT EXISTS phase text;
+
+CREATE TABLE IF NOT EXISTS debate_results (
+  debate_id uuid PRIMARY KEY REFERENCES debates(id) ON DELETE CASCADE,
+  judge_id text NOT NULL,
+  rounds jsonb NOT NULL DEFAULT '[]',
+  totals jsonb NOT NULL DEFAULT '{}',
+  side_totals jsonb,
+  winner_id text,
+  winning_side text,
+  final boolean NOT NULL DEFAULT false,
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
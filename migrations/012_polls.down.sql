@@ -0,0 +1,10 @@
+DELETE FROM debate_results WHERE judge_id IS NULL;
+ALTER TABLE debate_results
+  DROP COLUMN IF EXISTS decided_by,
+  DROP COLUMN IF EXISTS audience,
+  ALTER COLUMN judge_id SET NOT NULL;
+
+ALTER TABLE debates DROP COLUMN IF EXISTS audience_vote;
+
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS polls;
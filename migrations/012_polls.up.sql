@@ -0,0 +1,31 @@
+-- polls in conversations, one vote per voter and poll; debates may poll the audience before and after
+CREATE TABLE IF NOT EXISTS polls (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  conversation_id uuid NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  question text NOT NULL,
+  options text[] NOT NULL,
+  created_by text NOT NULL,
+  debate_id uuid REFERENCES debates(id) ON DELETE CASCADE,
+  stage text,
+  deadline timestamptz,
+  closed_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS polls_conversation_created_idx ON polls (conversation_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+  poll_id uuid NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+  voter_id text NOT NULL,
+  option int NOT NULL,
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (poll_id, voter_id)
+);
+
+ALTER TABLE debates ADD COLUMN IF NOT EXISTS audience_vote boolean NOT NULL DEFAULT false;
+
+-- debates decided by the audience alone have no judge
+ALTER TABLE debate_results
+  ALTER COLUMN judge_id DROP NOT NULL,
+  ADD COLUMN IF NOT EXISTS audience jsonb,
+  ADD COLUMN IF NOT EXISTS decided_by text;
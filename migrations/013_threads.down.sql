@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS messages_mentions_idx;
+DROP INDEX IF EXISTS messages_thread_created_idx;
+ALTER TABLE messages DROP COLUMN IF EXISTS mentions;
+ALTER TABLE messages DROP COLUMN IF EXISTS thread_id;
@@ -0,0 +1,14 @@
+-- @mentions as structured references and the thread (first message of the reply chain) of replies
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS thread_id uuid REFERENCES messages(id);
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS mentions jsonb NOT NULL DEFAULT '[]';
+
+WITH RECURSIVE chain AS (
+  SELECT id, id AS thread_id FROM messages WHERE reply_to IS NULL
+  UNION ALL
+  SELECT m.id, chain.thread_id FROM messages m JOIN chain ON m.reply_to = chain.id
+)
+UPDATE messages m SET thread_id = chain.thread_id
+FROM chain WHERE m.id = chain.id AND m.reply_to IS NOT NULL AND m.thread_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS messages_thread_created_idx ON messages (thread_id, created_at, id) WHERE thread_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS messages_mentions_idx ON messages USING gin (mentions jsonb_path_ops);
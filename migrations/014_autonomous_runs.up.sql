@@ -0,0 +1,21 @@
+-- autonomous runs: agents converse among themselves in the background until a limit or stopping condition
+CREATE TABLE IF NOT EXISTS autonomous_runs (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  conversation_id uuid NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  topic text NOT NULL,
+  participants text[] NOT NULL,
+  max_turns int NOT NULL,
+  max_duration_seconds int NOT NULL,
+  stop_on text[] NOT NULL DEFAULT '{}',
+  state text NOT NULL,
+  turns int NOT NULL DEFAULT 0,
+  speaker_id text,
+  left_ids text[] NOT NULL DEFAULT '{}',
+  stop_reason text,
+  error text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  ended_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS autonomous_runs_conversation_created_idx ON autonomous_runs (conversation_id, created_at DESC);
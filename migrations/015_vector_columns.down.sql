@@ -0,0 +1,2 @@
+-- agent_memories.vector keeps the data
+ALTER TABLE agent_memories DROP COLUMN IF EXISTS embedding;
@@ -0,0 +1,15 @@
+-- give agent_memories the same vector(1536) column and cosine HNSW index that
+-- 006 gives embeddings, so memory recall is ranked in Postgres. See 006 for the
+-- embeddings column; the server only detects these columns and indexes.
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector') THEN
+    ALTER TABLE agent_memories ADD COLUMN IF NOT EXISTS embedding vector(1536);
+    UPDATE agent_memories SET embedding = vector::vector(1536)
+      WHERE embedding IS NULL AND array_length(vector, 1) = 1536;
+    CREATE INDEX IF NOT EXISTS agent_memories_embedding_cosine_hnsw_idx ON agent_memories USING hnsw (embedding vector_cosine_ops);
+  ELSE
+    RAISE NOTICE 'pgvector is not installed; memory recall uses the brute-force fallback';
+  END IF;
+END
+$$;
@@ -0,0 +1,8 @@
+-- the latest event each user acknowledged per conversation; sockets resume after it
+CREATE TABLE IF NOT EXISTS conversation_acks (
+  conversation_id uuid NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  user_id text NOT NULL,
+  seq bigint NOT NULL,
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (conversation_id, user_id)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS embeddings_embedding_ip_hnsw_idx;
+DROP INDEX IF EXISTS embeddings_embedding_l2_hnsw_idx;
@@ -0,0 +1,12 @@
+-- HNSW indexes on embeddings.embedding for the other VECTOR_METRIC values, so
+-- l2 and inner_product searches use an index like cosine does with 006's.
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM pg_attribute WHERE attrelid = to_regclass('embeddings') AND attname = 'embedding' AND NOT attisdropped) THEN
+    CREATE INDEX IF NOT EXISTS embeddings_embedding_l2_hnsw_idx ON embeddings USING hnsw (embedding vector_l2_ops);
+    CREATE INDEX IF NOT EXISTS embeddings_embedding_ip_hnsw_idx ON embeddings USING hnsw (embedding vector_ip_ops);
+  ELSE
+    RAISE NOTICE 'embeddings.embedding is missing; similarity search uses the brute-force fallback';
+  END IF;
+END
+$$;
@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL schema migrations so binaries can apply
+// them without the source tree. Files are named NNN_name.up.sql and
+// NNN_name.down.sql; see internal/migrate for the runner.
+package migrations
+
+import "embed"
+
+// FS holds every migration file.
+//
+//go:embed *.sql
+var FS embed.FS
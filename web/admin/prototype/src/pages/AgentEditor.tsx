@@ -50,7 +50,9 @@ export default function AgentEditor(){
 
   const update = async () => {
     if (!selected) return
-    const res = await fetch(`/api/v1/agents/${selected}`, { method: 'PUT', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ name, persona }) })
+    let behavior_profile
+    try { behavior_profile = JSON.parse(behavior) } catch { alert('behavior JSON is invalid'); return }
+    const res = await fetch(`/api/v1/agents/${selected}`, { method: 'PATCH', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ name, persona, behavior_profile }) })
     if (res.ok) {
       setAgents(a=> a.map(x=> x.id===selected ? { ...x, name, persona } : x))
       alert('Updated')
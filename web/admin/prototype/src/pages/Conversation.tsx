@@ -1,5 +1,13 @@
 import React, { useEffect, useState, useRef } from 'react'
 
+// formatEvent renders a conversation event envelope ({event, sender, payload}) as a chat line.
+function formatEvent(e: any): string | null {
+  const content = e && e.payload && e.payload.content
+  if (!content) return null
+  const who = e.sender && (e.sender.name || e.sender.id)
+  return who ? who + ': ' + content : content
+}
+
 export default function Conversation(){
   const [convId, setConvId] = useState<string>('conv-12345')
   const [messages, setMessages] = useState<any[]>([])
@@ -11,19 +19,17 @@ export default function Conversation(){
   const endRef = useRef<HTMLDivElement|null>(null)
 
   useEffect(()=> {
-    // load messages
-    fetch(`/api/v1/conversations/${encodeURIComponent(convId)}/messages`)
-      .then(r=> r.json())
-      .then(d=> setMessages(d))
-      .catch(()=> setMessages(['Alice: sample message','Bob: sample reply']))
+    // the event stream replays the conversation log, so history needs no separate fetch
+    setMessages([])
 
     // prefer SSE with reconnect/backoff, fallback to WebSocket then mock
     let cancelled = false
     let backoff = 500
+    let lastSeq = 0 // resume point after reconnects
     function connectSSE() {
       if (cancelled) return
       try {
-        const es = new EventSource(`/events/conversations/${encodeURIComponent(convId)}`)
+        const es = new EventSource(`/events/conversations/${encodeURIComponent(convId)}?since=${lastSeq}`)
         esRef.current = es
         es.onopen = () => {
           setConnected(true)
@@ -32,7 +38,9 @@ export default function Conversation(){
         es.onmessage = (ev) => {
           try {
             const data = JSON.parse(ev.data)
-            setMessages(m=> [...m, (data.sender ? data.sender+': '+data.content : ev.data)])
+            if (data.seq) lastSeq = Math.max(lastSeq, data.seq)
+            const line = formatEvent(data)
+            if (line) setMessages(m=> [...m, line])
           } catch(e) {
             setMessages(m=> [...m, ev.data])
           }
@@ -49,7 +57,7 @@ export default function Conversation(){
         try {
           const ws = new WebSocket(`ws://${location.host}/ws/conversations/${encodeURIComponent(convId)}`)
           ws.onmessage = (ev)=> {
-            try { const data = JSON.parse(ev.data); setMessages(m=> [...m, data.content||ev.data]) } catch(e){ setMessages(m=> [...m, ev.data])}
+            try { const line = formatEvent(JSON.parse(ev.data)); if (line) setMessages(m=> [...m, line]) } catch(e){ setMessages(m=> [...m, ev.data])}
           }
           ws.onopen = ()=> setConnected(true)
           ws.onclose = ()=> setConnected(false)